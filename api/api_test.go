@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/shutdown"
+)
+
+func acceptAll(taskNames []string) error {
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	t.Run("process submitted jobs in order", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		processed := []string{}
+		q := NewQueue(acceptAll, func(path string, taskNames []string) error {
+			processed = append(processed, path)
+			if path == "/in/b" {
+				return errors.New("failed")
+			}
+			return nil
+		}, 10)
+
+		a, err := q.Submit("/in/a", []string{"video"})
+		assert.NotError(err)
+		b, err := q.Submit("/in/b", []string{"video"})
+		assert.NotError(err)
+		assert.StringsEqual(STATE_QUEUED, a.State)
+		q.Close()
+		q.Process()
+
+		assert.StringSlicesEqual([]string{"/in/a", "/in/b"}, processed)
+		assert.StringsEqual(STATE_DONE, q.Get(a.Id).State)
+		assert.StringsEqual(STATE_FAILED, q.Get(b.Id).State)
+		assert.StringsEqual("failed", q.Get(b.Id).Error)
+		assert.IntsEqual(2, len(q.List()["finished"]))
+	})
+
+	t.Run("skip canceled jobs", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		runs := 0
+		q := NewQueue(acceptAll, func(path string, taskNames []string) error { runs++; return nil }, 10)
+		job, err := q.Submit("/in/a", []string{"video"})
+		assert.NotError(err)
+		canceled, err := q.Cancel(job.Id)
+		assert.NotError(err)
+		assert.StringsEqual(STATE_CANCELED, canceled.State)
+		q.Close()
+		q.Process()
+		assert.IntsEqual(0, runs)
+
+		_, err = q.Cancel(job.Id)
+		assert.ExpectError("expected error when canceling finished job")(err)
+	})
+
+	t.Run("interrupt running job with hooks using the queue", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		started, interrupted := make(chan bool), make(chan bool)
+		var q *Queue
+		q = NewQueue(acceptAll, func(path string, taskNames []string) error {
+			shutdown.OnAbort("stop tool", func() error {
+				q.List() // must not deadlock
+				close(interrupted)
+				return nil
+			})
+			close(started)
+			<-interrupted
+			return errors.New("killed")
+		}, 10)
+		job, err := q.Submit("/in/a", []string{"video"})
+		assert.NotError(err)
+		q.Close()
+		go q.Process()
+
+		<-started
+		canceled, err := q.Cancel(job.Id)
+		assert.NotError(err)
+		assert.StringsEqual(STATE_CANCELED, canceled.State)
+		q.Wait()
+		assert.True("expected finished job after waiting for the queue")(q.Get(job.Id).FinishedAt != nil)
+		assert.False("expected interrupt to be cleared")(shutdown.IsAborting())
+	})
+
+	t.Run("reject invalid submissions", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		q := NewQueue(func(taskNames []string) error { return errors.New("unknown task") }, nil, 1)
+		_, err := q.Submit("/in/a", []string{"unknown"})
+		assert.ExpectError("expected error for unknown task")(err)
+		_, err = q.Submit("/in/a", nil)
+		assert.ExpectError("expected error for missing tasks")(err)
+		_, err = q.Submit("", []string{"video"})
+		assert.ExpectError("expected error for missing path")(err)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	assert := test.AssertOn(t)
+	q := NewQueue(acceptAll, func(path string, taskNames []string) error { return nil }, 10)
+	server := httptest.NewServer(Handler(q))
+	defer server.Close()
+
+	body, _ := json.Marshal(submission{Path: "/in/a", Tasks: []string{"video"}})
+	resp, err := http.Post(server.URL+pathJobs, "application/json", bytes.NewReader(body))
+	assert.NotError(err)
+	assert.IntsEqual(http.StatusCreated, resp.StatusCode)
+	job := Job{}
+	assert.NotError(json.NewDecoder(resp.Body).Decode(&job))
+	resp.Body.Close()
+	assert.StringSlicesEqual([]string{"video"}, job.Tasks)
+
+	resp, err = http.Get(server.URL + pathJobs)
+	assert.NotError(err)
+	jobs := map[string][]*Job{}
+	assert.NotError(json.NewDecoder(resp.Body).Decode(&jobs))
+	resp.Body.Close()
+	assert.IntsEqual(1, len(jobs[STATE_QUEUED]))
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+pathJobs+"/1", nil)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NotError(err)
+	resp.Body.Close()
+	assert.IntsEqual(http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + pathJobs + "/42")
+	assert.NotError(err)
+	resp.Body.Close()
+	assert.IntsEqual(http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + pathProgress)
+	assert.NotError(err)
+	resp.Body.Close()
+	assert.IntsEqual(http.StatusNoContent, resp.StatusCode)
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/thomasschoeftner/go-ripper/shutdown"
+)
+
+const (
+	STATE_QUEUED   = "queued"
+	STATE_RUNNING  = "running"
+	STATE_DONE     = "done"
+	STATE_FAILED   = "failed"
+	STATE_CANCELED = "canceled"
+)
+
+type Job struct {
+	Id          int        `json:"id"`
+	Path        string     `json:"path"`
+	Tasks       []string   `json:"tasks"`
+	State       string     `json:"state"`
+	SubmittedAt time.Time  `json:"submittedAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+func (job *Job) isFinished() bool {
+	return job.State == STATE_DONE || job.State == STATE_FAILED || job.State == STATE_CANCELED
+}
+
+// Validator checks if the selected tasks can be run
+type Validator func(taskNames []string) error
+
+// Runner processes a path with the selected tasks and returns once processing is complete
+type Runner func(path string, taskNames []string) error
+
+// Queue runs submitted jobs one after another
+type Queue struct {
+	lock       sync.Mutex
+	lastId     int
+	jobs       []*Job
+	pending    chan *Job
+	validate   Validator
+	run        Runner
+	interrupts sync.WaitGroup // interrupts of the running job in progress
+	processed  chan struct{}  // closed once all jobs are processed
+}
+
+func NewQueue(validate Validator, run Runner, capacity int) *Queue {
+	return &Queue{jobs: []*Job{}, pending: make(chan *Job, capacity), validate: validate, run: run, processed: make(chan struct{})}
+}
+
+func (q *Queue) Submit(path string, taskNames []string) (*Job, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("path is undefined")
+	}
+	if len(taskNames) == 0 {
+		return nil, fmt.Errorf("no task(s) specified")
+	}
+	if err := q.validate(taskNames); err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	q.lock.Lock()
+	q.lastId++
+	job := &Job{Id: q.lastId, Path: abs, Tasks: taskNames, State: STATE_QUEUED, SubmittedAt: time.Now()}
+	q.jobs = append(q.jobs, job)
+	q.lock.Unlock()
+
+	select {
+	case q.pending <- job:
+		return q.Get(job.Id), nil
+	default:
+		q.finish(job, STATE_FAILED, fmt.Errorf("queue is full"))
+		return nil, fmt.Errorf("queue is full - cannot accept more than %d pending jobs", cap(q.pending))
+	}
+}
+
+// Get returns a copy of the job
+func (q *Queue) Get(id int) *Job {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, job := range q.jobs {
+		if job.Id == id {
+			copied := *job
+			return &copied
+		}
+	}
+	return nil
+}
+
+// List returns copies of all jobs grouped by queued, running, and finished
+func (q *Queue) List() map[string][]*Job {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	jobs := map[string][]*Job{STATE_QUEUED: {}, STATE_RUNNING: {}, "finished": {}}
+	for _, job := range q.jobs {
+		copied := *job
+		group := job.State
+		if job.isFinished() {
+			group = "finished"
+		}
+		jobs[group] = append(jobs[group], &copied)
+	}
+	return jobs
+}
+
+// Cancel removes a queued job from the queue, or interrupts the running job
+func (q *Queue) Cancel(id int) (*Job, error) {
+	q.lock.Lock()
+	var job *Job
+	for _, j := range q.jobs {
+		if j.Id == id {
+			job = j
+		}
+	}
+	if job == nil {
+		q.lock.Unlock()
+		return nil, nil
+	}
+
+	switch job.State {
+	case STATE_QUEUED:
+		now := time.Now()
+		job.State, job.FinishedAt = STATE_CANCELED, &now
+		q.lock.Unlock()
+	case STATE_RUNNING:
+		// the next job is not started before the interrupt is complete - otherwise the interrupt might hit the next job
+		job.State = STATE_CANCELED
+		q.interrupts.Add(1)
+		q.lock.Unlock()
+		errs := shutdown.Interrupt()
+		q.interrupts.Done()
+		if len(errs) > 0 {
+			return nil, errs[0]
+		}
+	default:
+		q.lock.Unlock()
+		return nil, fmt.Errorf("job %d is %s already", id, job.State)
+	}
+	return q.Get(id), nil
+}
+
+// Process runs all submitted jobs until the queue is closed
+func (q *Queue) Process() {
+	defer close(q.processed)
+	for job := range q.pending {
+		if shutdown.IsAborting() {
+			q.finish(job, STATE_CANCELED, nil)
+			continue
+		}
+
+		q.lock.Lock()
+		if job.State != STATE_QUEUED {
+			q.lock.Unlock()
+			continue // canceled while queued
+		}
+		now := time.Now()
+		job.State, job.StartedAt = STATE_RUNNING, &now
+		q.lock.Unlock()
+
+		err := q.run(job.Path, job.Tasks)
+		if err != nil {
+			q.finish(job, STATE_FAILED, err)
+		} else {
+			q.finish(job, STATE_DONE, nil)
+		}
+		q.interrupts.Wait()
+		shutdown.ClearInterrupt()
+	}
+}
+
+func (q *Queue) finish(job *Job, state string, err error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if job.State != STATE_CANCELED {
+		job.State = state
+	}
+	if err != nil {
+		job.Error = err.Error()
+	}
+	now := time.Now()
+	job.FinishedAt = &now
+}
+
+// Close stops accepting jobs - pending jobs are still processed (or canceled if the application is aborted)
+func (q *Queue) Close() {
+	close(q.pending)
+}
+
+// Wait blocks until Process has finished the running job and all pending jobs
+func (q *Queue) Wait() {
+	<-q.processed
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/thomasschoeftner/go-ripper/progress"
+)
+
+const (
+	pathJobs     = "/jobs"
+	pathProgress = "/progress"
+)
+
+type submission struct {
+	Path  string   `json:"path"`
+	Tasks []string `json:"tasks"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler provides the HTTP control API:
+//
+//	POST   /jobs       {"path": "...", "tasks": ["video"]} - submit a path for processing
+//	GET    /jobs       list queued, running, and finished jobs
+//	GET    /jobs/<id>  get a single job
+//	DELETE /jobs/<id>  cancel a queued or running job
+//	GET    /progress   progress of the current encode (204 if nothing is encoded)
+func Handler(q *Queue) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pathJobs, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			respond(w, http.StatusOK, q.List())
+		case http.MethodPost:
+			sub := submission{}
+			if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+				respond(w, http.StatusBadRequest, errorResponse{err.Error()})
+				return
+			}
+			job, err := q.Submit(sub.Path, sub.Tasks)
+			if err != nil {
+				respond(w, http.StatusBadRequest, errorResponse{err.Error()})
+				return
+			}
+			respond(w, http.StatusCreated, job)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc(pathJobs+"/", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, pathJobs+"/"))
+		if err != nil {
+			respond(w, http.StatusNotFound, errorResponse{"invalid job id"})
+			return
+		}
+		var job *Job
+		switch r.Method {
+		case http.MethodGet:
+			job = q.Get(id)
+		case http.MethodDelete:
+			if job, err = q.Cancel(id); err != nil {
+				respond(w, http.StatusConflict, errorResponse{err.Error()})
+				return
+			}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if job == nil {
+			respond(w, http.StatusNotFound, errorResponse{"unknown job id"})
+			return
+		}
+		respond(w, http.StatusOK, job)
+	})
+
+	mux.HandleFunc(pathProgress, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		status := progress.Current()
+		if status == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		respond(w, http.StatusOK, status)
+	})
+	return mux
+}
+
+func respond(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
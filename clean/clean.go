@@ -11,12 +11,12 @@ import (
 func CleanHandler(ctx task.Context) task.HandlerFunc {
 	return func (job task.Job) ([]task.Job, error) {
 		conf := ctx.Config.(*ripper.AppConf)
-		return clean(ctx.Printf, "work data", job, conf.WorkDirectory)
+		return clean(ctx.Printf, "work data", job, conf.WorkDirectory, conf.DryRun)
 	}
 }
 
 
-func clean(printf commons.FormatPrinter, desc string, job task.Job, workDir string) ([]task.Job, error) {
+func clean(printf commons.FormatPrinter, desc string, job task.Job, workDir string, dryRun bool) ([]task.Job, error) {
 	result := []task.Job{job}
 	workPath, err := ripper.GetWorkPathForJob(workDir, job)
 	if err != nil {
@@ -34,10 +34,16 @@ func clean(printf commons.FormatPrinter, desc string, job task.Job, workDir stri
 		printf("  cleaning failed\n  due to: %s\n", err)
 	} else {
 		for _, f := range filesToDelete {
-			printf("  deleting file: %s\n", f)
-			os.Remove(f)
+			if dryRun {
+				printf("  dry-run - would delete file: %s\n", f)
+			} else {
+				printf("  deleting file: %s\n", f)
+				os.Remove(f)
+			}
+		}
+		if !dryRun {
+			printf("  all artifacts removed\n")
 		}
-		printf("  all artifacts removed\n")
 	}
 	return result, err
 }
@@ -37,7 +37,7 @@ func TestClean(t *testing.T) {
 	assert.TrueNotError("json file was not created")(files.Exists(jsonFile))
 	assert.TrueNotError("unrelated file was not created")(files.Exists(otherFile))
 
-	clean(commons.Printf, "test clean", job,  workDir)
+	clean(commons.Printf, "test clean", job,  workDir, false)
 
 	assert.FalseNotError("target file was not deleted")(files.Exists(targetFile))
 	assert.FalseNotError("json file was not deleted")(files.Exists(jsonFile))
@@ -0,0 +1,43 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// names of folders containing DVD and Blu-ray disc structures
+var discFolderNames = []string{"VIDEO_TS", "BDMV"}
+
+const DISC_IMAGE_EXTENSION = "iso"
+
+// IsDiscFolder checks if a path refers to a DVD (VIDEO_TS) or Blu-ray (BDMV) folder structure
+func IsDiscFolder(path string) bool {
+	name := filepath.Base(path)
+	for _, discFolder := range discFolderNames {
+		if strings.EqualFold(name, discFolder) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDisc checks if a path refers to a disc folder structure or a disc image
+func IsDisc(path string) bool {
+	return IsDiscFolder(path) || strings.EqualFold(GetExtension(path), DISC_IMAGE_EXTENSION)
+}
+
+// Size returns the size of a file, or the total size of all files within a folder
+func Size(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,39 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+)
+
+func TestIsDisc(t *testing.T) {
+	assert := test.AssertOn(t)
+	assert.True("expected VIDEO_TS to be a disc folder")(IsDiscFolder("/a/tt0001/VIDEO_TS"))
+	assert.True("expected BDMV to be a disc folder")(IsDiscFolder("/a/tt0001/bdmv"))
+	assert.False("expected other folder not to be a disc folder")(IsDiscFolder("/a/tt0001/extras"))
+	assert.True("expected VIDEO_TS to be a disc")(IsDisc("/a/tt0001/VIDEO_TS"))
+	assert.True("expected iso image to be a disc")(IsDisc("/a/tt0001.ISO"))
+	assert.False("expected video file not to be a disc")(IsDisc("/a/tt0001.mkv"))
+}
+
+func TestSize(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	assert := test.AssertOn(t)
+
+	disc := filepath.Join(dir, "VIDEO_TS")
+	assert.NotError(CreateFolderStructure(disc))
+	assert.NotError(ioutil.WriteFile(filepath.Join(disc, "VTS_01_1.VOB"), make([]byte, 100), os.ModePerm))
+	assert.NotError(ioutil.WriteFile(filepath.Join(disc, "VTS_01_2.VOB"), make([]byte, 50), os.ModePerm))
+
+	size, err := Size(disc)
+	assert.NotError(err)
+	assert.True("expected total size of folder contents")(size == 150)
+
+	size, err = Size(filepath.Join(disc, "VTS_01_2.VOB"))
+	assert.NotError(err)
+	assert.True("expected size of single file")(size == 50)
+}
@@ -36,6 +36,17 @@ func Copy(from, to string, append bool) (int64, error) {
 	return io.Copy(dst, src)
 }
 
+// Move renames a file - if renaming fails (e.g. across devices), the file is copied and the original is removed
+func Move(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	if _, err := Copy(from, to, false); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
 func GetDirectoryContents(dir string) ([] string, error) {
 	f, err := os.Open(dir)
 	if err != nil {
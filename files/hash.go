@@ -0,0 +1,76 @@
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// size of the chunks at the start and the end of files hashed for fingerprints
+const fingerprintChunkSize = 1 << 20
+
+// Fingerprint quickly identifies the contents of files by their total size and a hash of the first and last chunk of each file.
+// Folders (e.g. DVD/Blu-ray structures) are identified by the relative paths and sizes of all files they contain.
+func Fingerprint(paths ...string) (string, error) {
+	h := sha256.New()
+	var size int64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		if info.IsDir() {
+			s, err := fingerprintFolder(h, path)
+			if err != nil {
+				return "", err
+			}
+			size += s
+			continue
+		}
+		if err := fingerprintFile(h, path, info.Size()); err != nil {
+			return "", err
+		}
+		size += info.Size()
+	}
+	return fmt.Sprintf("%d-%s", size, hex.EncodeToString(h.Sum(nil))[:32]), nil
+}
+
+func fingerprintFile(w io.Writer, path string, size int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if size <= 2*fingerprintChunkSize {
+		_, err = io.Copy(w, f)
+		return err
+	}
+	if _, err := io.CopyN(w, f, fingerprintChunkSize); err != nil {
+		return err
+	}
+	if _, err := f.Seek(-fingerprintChunkSize, io.SeekEnd); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func fingerprintFolder(w io.Writer, folder string) (int64, error) {
+	var size int64
+	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(folder, path)
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		_, err = fmt.Fprintf(w, "%s:%d\n", filepath.ToSlash(rel), info.Size())
+		return err
+	})
+	return size, err
+}
@@ -0,0 +1,45 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+)
+
+func TestFingerprint(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	large := filepath.Join(dir, "large")
+	content := make([]byte, 3*fingerprintChunkSize)
+	test.CheckError(t, ioutil.WriteFile(large, content, os.ModePerm))
+	fingerprint := assert.StringNotError(Fingerprint(large))
+
+	t.Run("ignore changes between first and last chunk", func(t *testing.T) {
+		content[fingerprintChunkSize+1] = 1
+		test.CheckError(t, ioutil.WriteFile(large, content, os.ModePerm))
+		assert.StringsEqual(fingerprint, assert.StringNotError(Fingerprint(large)))
+	})
+
+	t.Run("detect changes of size and last chunk", func(t *testing.T) {
+		test.CheckError(t, ioutil.WriteFile(large, append(content, 0), os.ModePerm))
+		assert.True("expected fingerprint to change with size")(fingerprint != assert.StringNotError(Fingerprint(large)))
+		content[len(content)-1] = 1
+		test.CheckError(t, ioutil.WriteFile(large, content, os.ModePerm))
+		assert.True("expected fingerprint to change with last chunk")(fingerprint != assert.StringNotError(Fingerprint(large)))
+	})
+
+	t.Run("identify folders by their files", func(t *testing.T) {
+		a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+		for _, folder := range []string{a, b} {
+			test.CheckError(t, os.MkdirAll(filepath.Join(folder, "VIDEO_TS"), os.ModePerm))
+			test.CheckError(t, ioutil.WriteFile(filepath.Join(folder, "VIDEO_TS", "VTS_01_1.VOB"), []byte("vob"), os.ModePerm))
+		}
+		assert.StringsEqual(assert.StringNotError(Fingerprint(a)), assert.StringNotError(Fingerprint(b)))
+		test.CheckError(t, ioutil.WriteFile(filepath.Join(b, "VIDEO_TS", "VTS_01_2.VOB"), []byte("vob"), os.ModePerm))
+		assert.True("expected fingerprint to change with files")(assert.StringNotError(Fingerprint(a)) != assert.StringNotError(Fingerprint(b)))
+	})
+}
@@ -0,0 +1,226 @@
+package ledger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/thomasschoeftner/go-ripper/files"
+)
+
+const ledgerFileName = "ledger.json"
+
+const (
+	STAGE_SCANNED  = "scanned"
+	STAGE_RESOLVED = "resolved"
+	STAGE_RIPPED   = "ripped"
+	STAGE_TAGGED   = "tagged"
+	STAGE_REMOVED  = "removed"
+)
+
+// all stages in order of processing
+var Stages = []string{STAGE_SCANNED, STAGE_RESOLVED, STAGE_RIPPED, STAGE_TAGGED, STAGE_REMOVED}
+
+const (
+	STATE_DONE   = "done"
+	STATE_FAILED = "failed"
+)
+
+type StageResult struct {
+	State         string    `json:"state"`
+	At            time.Time `json:"at"`
+	Error         string    `json:"error,omitempty"`
+	WorkDirectory string    `json:"workDirectory,omitempty"` // location of the stage's processing artifacts
+}
+
+type Entry struct {
+	Target      string                  `json:"target"`
+	Media       string                  `json:"media,omitempty"`
+	Stages      map[string]*StageResult `json:"stages"`
+	Destination string                  `json:"destination,omitempty"` // final location of the tagged output - may differ from the calculated destination
+	SkippedBy   string                  `json:"skippedBy,omitempty"`   // existing file at the destination, which caused tagging to be skipped
+}
+
+// IsDone checks if a stage was completed successfully during its latest execution
+func (e *Entry) IsDone(stage string) bool {
+	result := e.Stages[stage]
+	return result != nil && result.State == STATE_DONE
+}
+
+// IsDoneIn checks if a stage was completed successfully in a specific work directory - only then its processing artifacts are available
+func (e *Entry) IsDoneIn(stage string, workDir string) bool {
+	if !e.IsDone(stage) {
+		return false
+	}
+	recordedIn := e.Stages[stage].WorkDirectory
+	return len(recordedIn) == 0 || recordedIn == key(workDir)
+}
+
+// State returns the failed stage (if any), or the latest stage completed
+func (e *Entry) State() (stage string, result *StageResult) {
+	for _, s := range Stages {
+		r := e.Stages[s]
+		if r == nil {
+			continue
+		}
+		if r.State == STATE_FAILED {
+			return s, r
+		}
+		stage, result = s, r
+	}
+	return stage, result
+}
+
+type Ledger struct {
+	file    string
+	lock    sync.Mutex
+	Targets map[string]*Entry `json:"targets"`
+}
+
+var (
+	openLedgersLock = sync.Mutex{}
+	openLedgers     = map[string]*Ledger{}
+)
+
+// Open loads the ledger from the state directory (see ripper.AppConf.StateDirectory), so it survives runs with varying work directories.
+// All callers share the same ledger instance per state directory.
+func Open(stateDir string) (*Ledger, error) {
+	file, err := filepath.Abs(filepath.Join(stateDir, ledgerFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	openLedgersLock.Lock()
+	defer openLedgersLock.Unlock()
+	if l, isOpen := openLedgers[file]; isOpen {
+		return l, nil
+	}
+
+	l := &Ledger{file: file, Targets: map[string]*Entry{}}
+	raw, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(raw, l); err != nil {
+			return nil, err
+		}
+	}
+	openLedgers[file] = l
+	return l, nil
+}
+
+// Record stores the result of a processing stage for a target along with the work directory of its artifacts and persists the ledger
+func (l *Ledger) Record(target string, media string, stage string, workDir string, stageErr error) error {
+	target = key(target)
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	entry := l.entryFor(target)
+	if len(media) > 0 {
+		entry.Media = media
+	}
+	result := &StageResult{State: STATE_DONE, At: time.Now(), WorkDirectory: key(workDir)}
+	if stageErr != nil {
+		result.State = STATE_FAILED
+		result.Error = stageErr.Error()
+	}
+	entry.Stages[stage] = result
+	return l.save()
+}
+
+// RecordDestination stores the final location of the tagged output of a target
+func (l *Ledger) RecordDestination(target string, dst string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	entry := l.entryFor(key(target))
+	entry.Destination, entry.SkippedBy = dst, ""
+	return l.save()
+}
+
+// RecordSkipped stores the existing file which caused tagging of a target to be skipped
+func (l *Ledger) RecordSkipped(target string, existing string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	entry := l.entryFor(key(target))
+	entry.Destination, entry.SkippedBy = "", existing
+	return l.save()
+}
+
+// entryFor returns the entry of a target - new entries are added on first use
+func (l *Ledger) entryFor(target string) *Entry {
+	entry := l.Targets[target]
+	if entry == nil {
+		entry = &Entry{Target: target, Stages: map[string]*StageResult{}}
+		l.Targets[target] = entry
+	}
+	return entry
+}
+
+func (l *Ledger) Get(target string) *Entry {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.Targets[key(target)]
+}
+
+func (l *Ledger) IsDone(target string, stage string) bool {
+	entry := l.Get(target)
+	return entry != nil && entry.IsDone(stage)
+}
+
+func (l *Ledger) IsDoneIn(target string, stage string, workDir string) bool {
+	entry := l.Get(target)
+	return entry != nil && entry.IsDoneIn(stage, workDir)
+}
+
+// Move transfers the recorded stages of a target to its new location (e.g. after renaming or moving its input)
+func (l *Ledger) Move(from string, to string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	entry := l.Targets[key(from)]
+	if entry == nil {
+		return nil
+	}
+	delete(l.Targets, key(from))
+	entry.Target = key(to)
+	l.Targets[entry.Target] = entry
+	return l.save()
+}
+
+// Forget removes all recorded stages of a target (e.g. after its input changed)
+func (l *Ledger) Forget(target string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.Targets[key(target)] == nil {
+		return nil
+	}
+	delete(l.Targets, key(target))
+	return l.save()
+}
+
+// targets are recorded with absolute paths, so relative command line targets refer to the same entries
+func key(target string) string {
+	if abs, err := filepath.Abs(target); err == nil {
+		return abs
+	}
+	return target
+}
+
+// save writes the ledger to a temp file first, so a crash never leaves a truncated ledger behind
+func (l *Ledger) save() error {
+	if err := files.CreateFolderStructure(filepath.Dir(l.file)); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := l.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, raw, os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.file)
+}
@@ -0,0 +1,210 @@
+package ledger
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+func forgetOpenLedgers() {
+	openLedgersLock.Lock()
+	defer openLedgersLock.Unlock()
+	openLedgers = map[string]*Ledger{}
+}
+
+func TestRecord(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	target := filepath.Join(dir, "in", "movie.avi")
+
+	l, err := Open(dir)
+	assert.NotError(err)
+	assert.NotError(l.Record(target, ripper.MEDIA_VIDEO, STAGE_SCANNED, dir, nil))
+	assert.NotError(l.Record(target, "", STAGE_RESOLVED, dir, nil))
+	assert.NotError(l.Record(target, "", STAGE_RIPPED, dir, errors.New("handbrake failed")))
+
+	t.Run("share ledger per work directory", func(t *testing.T) {
+		again, err := Open(dir)
+		test.AssertOn(t).NotError(err)
+		test.AssertOn(t).True("expected same ledger instance for same work directory")(l == again)
+	})
+
+	t.Run("load persisted ledger", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		forgetOpenLedgers()
+		loaded, err := Open(dir)
+		assert.NotError(err)
+
+		entry := loaded.Get(target)
+		assert.True("expected entry for target in persisted ledger")(entry != nil)
+		assert.StringsEqual(ripper.MEDIA_VIDEO, entry.Media)
+		assert.True("expected resolve stage to be done")(loaded.IsDone(target, STAGE_RESOLVED))
+		assert.False("expected rip stage not to be done")(loaded.IsDone(target, STAGE_RIPPED))
+
+		stage, result := entry.State()
+		assert.StringsEqual(STAGE_RIPPED, stage)
+		assert.StringsEqual(STATE_FAILED, result.State)
+		assert.StringsEqual("handbrake failed", result.Error)
+	})
+
+	t.Run("successful retry replaces failure", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		assert.NotError(l.Record(target, "", STAGE_RIPPED, dir, nil))
+		stage, result := l.Get(target).State()
+		assert.StringsEqual(STAGE_RIPPED, stage)
+		assert.StringsEqual(STATE_DONE, result.State)
+	})
+}
+
+func TestMoveAndForget(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	forgetOpenLedgers()
+	from, to := filepath.Join(dir, "in", "movie.avi"), filepath.Join(dir, "moved", "renamed.avi")
+
+	l, err := Open(dir)
+	assert.NotError(err)
+	assert.NotError(l.Record(from, ripper.MEDIA_VIDEO, STAGE_RIPPED, dir, nil))
+	assert.NotError(l.Move(from, to))
+	assert.True("expected no entry for previous path")(l.Get(from) == nil)
+	assert.True("expected stages to be kept for new path")(l.IsDone(to, STAGE_RIPPED))
+	assert.StringsEqual(to, l.Get(to).Target)
+
+	assert.NotError(l.Forget(to))
+	assert.True("expected no entry for forgotten target")(l.Get(to) == nil)
+	forgetOpenLedgers()
+	loaded, err := Open(dir)
+	assert.NotError(err)
+	assert.True("expected forgotten target not to be persisted")(loaded.Get(to) == nil)
+}
+
+func TestTracked(t *testing.T) {
+	testTracked := func(resume bool, expectedCalls int) func(t *testing.T) {
+		return func(t *testing.T) {
+			assert := test.AssertOn(t)
+			dir := test.MkTempFolder(t)
+			defer test.RmTempFolder(t, dir)
+			forgetOpenLedgers()
+
+			target := filepath.Join(dir, "in", "movie.avi")
+			l, err := Open(dir)
+			assert.NotError(err)
+			assert.NotError(l.Record(target, ripper.MEDIA_VIDEO, STAGE_RIPPED, dir, nil))
+
+			calls := 0
+			handler := func(ctx task.Context) task.HandlerFunc {
+				return func(job task.Job) ([]task.Job, error) {
+					calls++
+					return []task.Job{job}, nil
+				}
+			}
+			ctx := task.Context{Config: &ripper.AppConf{WorkDirectory: dir}, Printf: commons.DevNullPrintf}
+			job := task.Job{ripper.JobField_Path: target, ripper.JobField_Media: ripper.MEDIA_VIDEO}
+
+			jobs, err := Tracked(STAGE_RIPPED, resume, handler, ripper.MEDIA_VIDEO)(ctx)(job)
+			assert.NotError(err)
+			assert.IntsEqual(1, len(jobs))
+			assert.IntsEqual(expectedCalls, calls)
+		}
+	}
+
+	t.Run("re-run completed stage without resume", testTracked(false, 1))
+	t.Run("skip completed stage on resume", testTracked(true, 0))
+}
+
+func TestResumeInOtherWorkDirectory(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	forgetOpenLedgers()
+	stateDir, previousWorkDir, workDir := filepath.Join(dir, "storage"), filepath.Join(dir, "work-1"), filepath.Join(dir, "work-2")
+	ripped, tagged := filepath.Join(dir, "in", "ripped.avi"), filepath.Join(dir, "in", "tagged.avi")
+
+	l, err := Open(stateDir)
+	test.AssertOn(t).NotError(err)
+	test.AssertOn(t).NotError(l.Record(ripped, ripper.MEDIA_VIDEO, STAGE_RIPPED, previousWorkDir, nil))
+	test.AssertOn(t).NotError(l.Record(tagged, ripper.MEDIA_VIDEO, STAGE_TAGGED, previousWorkDir, nil))
+
+	calls := 0
+	handler := func(jobs ...task.Job) task.Handler {
+		return func(ctx task.Context) task.HandlerFunc {
+			return func(job task.Job) ([]task.Job, error) {
+				calls++
+				if len(jobs) > 0 {
+					return jobs, nil
+				}
+				return []task.Job{job}, nil
+			}
+		}
+	}
+	conf := &ripper.AppConf{StoragePath: stateDir, WorkDirectory: workDir}
+	ctx := task.Context{Config: conf, Printf: commons.DevNullPrintf}
+
+	t.Run("re-run stage completed in other work directory", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		calls = 0
+		job := task.Job{ripper.JobField_Path: ripped, ripper.JobField_Media: ripper.MEDIA_VIDEO}
+		_, err := Tracked(STAGE_RIPPED, true, handler(), ripper.MEDIA_VIDEO)(ctx)(job)
+		assert.NotError(err)
+		assert.IntsEqual(1, calls)
+		assert.True("expected stage to be recorded for current work directory")(l.IsDoneIn(ripped, STAGE_RIPPED, workDir))
+	})
+
+	t.Run("skip stages up to tagging of targets completely processed in other work directory", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		job := task.Job{ripper.JobField_Path: tagged, ripper.JobField_Media: ripper.MEDIA_VIDEO}
+		for _, stage := range []string{STAGE_RESOLVED, STAGE_RIPPED, STAGE_TAGGED} {
+			calls = 0
+			jobs, err := Tracked(stage, true, handler(), ripper.MEDIA_VIDEO)(ctx)(job)
+			assert.NotError(err)
+			assert.IntsEqual(1, len(jobs))
+			assert.IntsEqual(0, calls)
+		}
+
+		calls = 0
+		_, err := Tracked(STAGE_REMOVED, true, handler(), ripper.MEDIA_VIDEO)(ctx)(job)
+		assert.NotError(err)
+		assert.IntsEqual(1, calls)
+	})
+
+	t.Run("keep targets completely processed in other work directory when scanning", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		folder := task.Job{ripper.JobField_Path: filepath.Join(dir, "in")}
+		found := []task.Job{
+			{ripper.JobField_Path: ripped, ripper.JobField_Media: ripper.MEDIA_VIDEO},
+			{ripper.JobField_Path: tagged, ripper.JobField_Media: ripper.MEDIA_VIDEO},
+			folder}
+		jobs, err := Tracked(STAGE_SCANNED, true, handler(found...))(ctx)(folder)
+		assert.NotError(err)
+		assert.IntsEqual(3, len(jobs))
+		assert.True("expected tagging in other work directory to be kept")(l.IsDone(tagged, STAGE_TAGGED))
+	})
+}
+
+func TestGroupByState(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	forgetOpenLedgers()
+
+	in := filepath.Join(dir, "in")
+	l, err := Open(dir)
+	assert.NotError(err)
+	assert.NotError(l.Record(filepath.Join(in, "a.avi"), ripper.MEDIA_VIDEO, STAGE_TAGGED, dir, nil))
+	assert.NotError(l.Record(filepath.Join(in, "b.avi"), ripper.MEDIA_VIDEO, STAGE_RIPPED, dir, nil))
+	assert.NotError(l.Record(filepath.Join(in, "c.avi"), ripper.MEDIA_VIDEO, STAGE_RIPPED, dir, nil))
+	assert.NotError(l.Record(filepath.Join(in, "c.avi"), ripper.MEDIA_VIDEO, STAGE_TAGGED, dir, errors.New("boom")))
+	assert.NotError(l.Record(filepath.Join(dir, "other", "d.avi"), ripper.MEDIA_VIDEO, STAGE_TAGGED, dir, nil))
+
+	groups := groupByState(l, in)
+	assert.IntsEqual(1, len(groups[STAGE_TAGGED]))
+	assert.IntsEqual(1, len(groups[STAGE_RIPPED]))
+	assert.IntsEqual(1, len(groups[STATE_FAILED]))
+	assert.StringsEqual(filepath.Join(in, "c.avi"), groups[STATE_FAILED][0].Target)
+}
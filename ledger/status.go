@@ -0,0 +1,89 @@
+package ledger
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+// StatusHandler prints the processing state of all targets within the job's path, grouped by state
+func StatusHandler(ctx task.Context) task.HandlerFunc {
+	conf := ctx.Config.(*ripper.AppConf)
+	return func(job task.Job) ([]task.Job, error) {
+		l, err := Open(conf.StateDirectory())
+		if err != nil {
+			return nil, err
+		}
+		printStatus(ctx.Printf, l, ripper.GetTargetFileFromJob(job))
+		return []task.Job{job}, nil
+	}
+}
+
+func printStatus(printf commons.FormatPrinter, l *Ledger, path string) {
+	path = key(path)
+	groups := groupByState(l, path)
+
+	printf("status of targets in \"%s\":\n", path)
+	states := append([]string{STATE_FAILED}, reversed(Stages)...)
+	for _, state := range states {
+		entries := groups[state]
+		if len(entries) == 0 {
+			continue
+		}
+		printf("  %s (%d):\n", state, len(entries))
+		for _, e := range entries {
+			stage, result := e.State()
+			if result.State == STATE_FAILED {
+				printf("    %s - %s failed at %s: %s\n", e.Target, stage, result.At.Format(timeFormat), result.Error)
+			} else {
+				printf("    %s - %s at %s\n", e.Target, stage, result.At.Format(timeFormat))
+			}
+		}
+	}
+}
+
+func groupByState(l *Ledger, path string) map[string][]*Entry {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	groups := map[string][]*Entry{}
+	for target, e := range l.Targets {
+		if !isWithin(target, path) {
+			continue
+		}
+		stage, result := e.State()
+		if result == nil {
+			continue
+		}
+		group := stage
+		if result.State == STATE_FAILED {
+			group = STATE_FAILED
+		}
+		groups[group] = append(groups[group], e)
+	}
+	for _, entries := range groups {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Target < entries[j].Target
+		})
+	}
+	return groups
+}
+
+func isWithin(target string, path string) bool {
+	rel, err := filepath.Rel(path, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func reversed(s []string) []string {
+	r := make([]string, 0, len(s))
+	for i := len(s) - 1; i >= 0; i-- {
+		r = append(r, s[i])
+	}
+	return r
+}
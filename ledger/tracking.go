@@ -0,0 +1,100 @@
+package ledger
+
+import (
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+// Tracked records the outcome of a task handler for each target in the ledger.
+// If resume is enabled, targets which already completed the stage in the current work directory are passed on without invoking the handler.
+// Targets completely processed in other work directories skip all stages up to tagging, but still run later stages (e.g. removal of originals).
+// Only targets of the given media types (or all media types if none are given) are tracked.
+func Tracked(stage string, resume bool, handler task.Handler, media ...string) task.Handler {
+	return func(ctx task.Context) task.HandlerFunc {
+		conf := ctx.Config.(*ripper.AppConf)
+		handle := handler(ctx)
+		l, err := Open(conf.StateDirectory())
+		if err != nil {
+			return ripper.ErrorHandler(err)
+		}
+
+		if stage == STAGE_SCANNED {
+			return trackScan(ctx, l, handle)
+		}
+
+		return func(job task.Job) ([]task.Job, error) {
+			if !ripper.IsTargetJobFor(job, media...) {
+				return handle(job)
+			}
+			target := ripper.GetTargetFileFromJob(job)
+			if resume && isDone(l.Get(target), stage, conf.WorkDirectory) {
+				ctx.Printf("resume - skip stage \"%s\" for %s (already done)\n", stage, target)
+				return []task.Job{job}, nil
+			}
+
+			jobs, err := handle(job)
+			if err == nil && len(jobs) == 0 {
+				return jobs, nil // dropped without error (e.g. skipped) - the stage is not done
+			}
+			if recErr := l.Record(target, ripper.GetMediaFromJob(job), stage, conf.WorkDirectory, err); recErr != nil && err == nil {
+				err = recErr
+			}
+			return jobs, err
+		}
+	}
+}
+
+// scanners turn a single job (folder) into jobs for all targets found
+func trackScan(ctx task.Context, l *Ledger, handle task.HandlerFunc) task.HandlerFunc {
+	workDir := ctx.Config.(*ripper.AppConf).WorkDirectory
+	return func(job task.Job) ([]task.Job, error) {
+		if len(ripper.GetMediaFromJob(job)) > 0 {
+			return handle(job) // already scanned and tracked
+		}
+
+		jobs, err := handle(job)
+		if err != nil {
+			l.Record(ripper.GetTargetFileFromJob(job), "", STAGE_SCANNED, workDir, err)
+			return jobs, err
+		}
+		for _, j := range jobs {
+			media := ripper.GetMediaFromJob(j)
+			if len(media) == 0 {
+				continue
+			}
+			if err := l.Record(ripper.GetTargetFileFromJob(j), media, STAGE_SCANNED, workDir, nil); err != nil {
+				return jobs, err
+			}
+		}
+		return jobs, nil
+	}
+}
+
+// isDone checks if a stage can be skipped on resume
+func isDone(e *Entry, stage string, workDir string) bool {
+	if e == nil {
+		return false
+	}
+	if e.IsDoneIn(stage, workDir) {
+		return true
+	}
+	return isProcessedElsewhere(e, workDir) && !isAfter(stage, STAGE_TAGGED)
+}
+
+// isProcessedElsewhere checks if a target was tagged in another work directory - its remaining artifacts are not available in the current one
+func isProcessedElsewhere(e *Entry, workDir string) bool {
+	return e != nil && e.IsDone(STAGE_TAGGED) && !e.IsDoneIn(STAGE_TAGGED, workDir)
+}
+
+// isAfter checks if a stage is processed after another one
+func isAfter(stage string, other string) bool {
+	for _, s := range Stages {
+		if s == stage {
+			return false
+		}
+		if s == other {
+			return true
+		}
+	}
+	return false
+}
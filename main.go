@@ -6,29 +6,50 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/google/logger"
 	"github.com/thomasschoeftner/go-cli/cli"
+	"github.com/thomasschoeftner/go-cli/commons"
 	"github.com/thomasschoeftner/go-cli/pipeline"
 	"github.com/thomasschoeftner/go-cli/require"
 	"github.com/thomasschoeftner/go-cli/task"
 	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/metainfo/audio"
 	"github.com/thomasschoeftner/go-ripper/metainfo/video"
+	"github.com/thomasschoeftner/go-ripper/musicbrainz"
 	"github.com/thomasschoeftner/go-ripper/omdb"
+	"github.com/thomasschoeftner/go-ripper/report"
 	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/shutdown"
+	"github.com/thomasschoeftner/go-ripper/tmdb"
+	"github.com/thomasschoeftner/go-ripper/watch"
 )
 
 const (
 	cliFlagVerbose    = "verbose"
 	cliFlagLazy       = "lazy"
+	cliFlagResume     = "resume"
+	cliFlagDryRun     = "dry-run"
 	cliFlagConfigFile = "config"
+	cliFlagProfile    = "profile"
 	ApplicationName   = "go-ripper"
 )
 
+const (
+	exitCodeOk                  = 0
+	exitCodeAbortedBySignalBase = 128 // exit code is 128 + signal number (e.g. 130 for SIGINT, 143 for SIGTERM)
+)
+
 var isVerbose = cli.FromFlag(cliFlagVerbose, "full log output in console").GetBoolean().WithDefault(false)
 var isLazy = cli.FromFlag(cliFlagLazy, "avoid re-execution of task, if output from previous execution is available - defaults to true").GetBoolean().WithDefault(true)
+var isResume = cli.FromFlag(cliFlagResume, "skip targets which already completed a task according to the ledger in the work directory - defaults to false").GetBoolean().WithDefault(false)
+var isDryRun = cli.FromFlag(cliFlagDryRun, "show what would be done (scanned targets, tool command lines, destination paths) without modifying any files - meta-info is resolved from the local repo only").GetBoolean().WithDefault(false)
 var configFile = cli.FromFlag(cliFlagConfigFile, "the config file location").OrEnvironmentVar(ApplicationName + "-" + cliFlagConfigFile).GetString().WithDefault("/" + ApplicationName + "/config/" + ApplicationName + ".conf")
+var profileFile = cli.FromFlag(cliFlagProfile, "the optional profile file location (secrets and tool paths overriding the config file)").OrEnvironmentVar(ApplicationName + "-" + cliFlagProfile).GetString().WithDefault("/" + ApplicationName + "/config/" + ApplicationName + ".profile")
 
 func main() {
 	os.Exit(launch())
@@ -41,24 +62,60 @@ func launch() int {
 	logger.Init(ApplicationName, *isVerbose, false, ioutil.Discard)
 
 	// read config
-	conf := ripper.GetConfig(*configFile)
-	require.NotFailed(files.CreateFolderStructure(conf.OutputDirectory))
+	conf := ripper.GetConfig(*configFile, *profileFile)
+	conf.DryRun = *isDryRun
+	if !conf.DryRun {
+		require.NotFailed(files.CreateFolderStructure(conf.OutputDirectory))
+	}
 
 	switch conf.Resolve.Video.Resolver {
 	case omdb.CONF_OMDB_RESOLVER:
 		video.NewVideoMetaInfoSource = omdb.NewOmdbVideoMetaInfoSource
+	case tmdb.CONF_TMDB_RESOLVER:
+		video.NewVideoMetaInfoSource = tmdb.NewTmdbVideoMetaInfoSource
 	default:
 		logger.Fatalf("unknown video resolver configured: %s", conf.Resolve.Video.Resolver)
 	}
+	if conf.Resolve.Audio != nil {
+		switch conf.Resolve.Audio.Resolver {
+		case musicbrainz.CONF_MUSICBRAINZ_RESOLVER:
+			audio.NewAudioMetaInfoSource = musicbrainz.NewMusicBrainzAudioMetaInfoSource
+		default:
+			logger.Fatalf("unknown audio resolver configured: %s", conf.Resolve.Audio.Resolver)
+		}
+	}
+
+	// read command line params (flags & args)
+	taskMap, err := task.ValidateTasks(CreateTasks(*isResume, conf.DryRun, nil))
+	require.NotFailed(err)
+	taskNames, targets := getCliTasksAndTargets(taskMap, conf)
+
+	// read-only tasks and dry-runs are not reported
+	var rec *report.Recorder
+	if conf.Report != nil && !conf.DryRun && !isReadOnly(taskNames) {
+		rec, err = report.NewRecorder(conf.Report)
+		require.NotFailed(err)
+	}
 
 	// create task Tree
-	allTasks := CreateTasks()
-	taskMap, err := task.ValidateTasks(allTasks)
+	allTasks := CreateTasks(*isResume, conf.DryRun, rec)
+	taskMap, err = task.ValidateTasks(allTasks)
 	require.NotFailed(err)
 
-	// read command line params (flags & args)
-	taskNames, targets := getCliTasksAndTargets(taskMap)
-	//TODO add validation not to use workDir or repoDir as target folder
+	if commons.IsStringAmong(TaskName_Serve, taskNames) {
+		return serve(conf, allTasks, taskMap, taskNames, targets, rec)
+	}
+
+	// in watch mode, targets are folders to monitor - single failed jobs must not stop the pipeline
+	var watcher *watch.Watcher
+	if commons.IsStringAmong(TaskName_Watch, taskNames) {
+		require.True(len(taskNames) > 1, "no task(s) specified to run on new input files")
+		watcher, err = watch.NewWatcher(conf, targets)
+		require.NotFailed(err)
+		if conf.Processing.Sequential != nil {
+			conf.Processing.Sequential.StopAtError = false
+		}
+	}
 
 	// calculate tasks to be invoked
 	tasksToRun, err := taskMap.CompileTasksForNamesCompact(taskNames...)
@@ -68,18 +125,80 @@ func launch() int {
 	pipe, err := pipeline.Materialize(tasksToRun).WithConfig(conf.Processing, conf, allTasks, *isLazy)
 	require.NotFailed(err)
 
-	// ASYNCHRONOUSLY send a processing command for each target to pipeline
-	go fillPipelineAndClose(pipe, targets)
+	// abort processing gracefully on SIGINT / SIGTERM
+	aborted := make(chan os.Signal, 1)
+	go abortOnSignal(aborted)
 
-	err = handleProcessingEvents(pipe)
+	// ASYNCHRONOUSLY send a processing command for each target (or each new file in watch mode) to pipeline
+	if watcher != nil {
+		go watchAndFeedPipeline(pipe, watcher)
+	} else {
+		go fillPipelineAndClose(pipe, targets)
+	}
+
+	err = handleProcessingEvents(pipe, rec)
 	require.NotFailed(err)
 
+	if shutdown.IsAborting() {
+		sig := <-aborted // wait for cleanup to complete
+		return exitCodeAbortedBySignalBase + signalNumber(sig)
+	}
+	return exitCodeOk
+}
+
+// abortOnSignal kills running external tools, restores evacuated input files, and removes partial outputs.
+// The received signal is published to aborted after cleanup - a 2nd signal terminates immediately.
+func abortOnSignal(aborted chan<- os.Signal) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	sig := <-signals
+	fmt.Printf("\nreceived %s - aborting (repeat to terminate immediately)\n", sig)
+	go func() {
+		sig := <-signals
+		logger.Errorf("received %s again - terminate without cleanup", sig)
+		os.Exit(exitCodeAbortedBySignalBase + signalNumber(sig))
+	}()
+
+	for _, err := range shutdown.Abort() {
+		logger.Error(err)
+	}
+	aborted <- sig
+}
+
+func signalNumber(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return int(s)
+	}
 	return 0
 }
 
+func watchAndFeedPipeline(pipe *pipeline.Pipeline, watcher *watch.Watcher) {
+	// feed processing command for each new file to pipeline until processing is aborted
+	for !shutdown.IsAborting() {
+		newFiles, err := watcher.Poll(time.Now())
+		if err != nil {
+			logger.Error(err)
+		}
+		for _, f := range newFiles {
+			if shutdown.IsAborting() {
+				break
+			}
+			logger.Infof("new input file detected: %s", f)
+			pipe.Commands <- ripper.ProcessPath(f)
+		}
+		time.Sleep(watcher.Interval)
+	}
+	pipe.Commands <- pipeline.Stop()
+	close(pipe.Commands)
+}
+
 func fillPipelineAndClose(pipe *pipeline.Pipeline, targets []string) {
-	// feed processing command for each target to pipeline
+	// feed processing command for each target to pipeline - stop feeding if processing was aborted
 	for _, target := range targets {
+		if shutdown.IsAborting() {
+			break
+		}
 		pipe.Commands <- ripper.ProcessPath(target)
 	}
 	pipe.Commands <- pipeline.Stop()
@@ -88,7 +207,7 @@ func fillPipelineAndClose(pipe *pipeline.Pipeline, targets []string) {
 	close(pipe.Commands)
 }
 
-func handleProcessingEvents(pipe *pipeline.Pipeline) error {
+func handleProcessingEvents(pipe *pipeline.Pipeline, rec *report.Recorder) error {
 	pipeClosed := false
 	for !pipeClosed {
 		event, notClosed := <-pipe.Events
@@ -97,7 +216,14 @@ func handleProcessingEvents(pipe *pipeline.Pipeline) error {
 		}
 		if isClosed, statistics := event.IsClosed(); isClosed {
 			pipeClosed = true
-			fmt.Printf("statistics: %+v\n", *statistics) //TODO improve
+			fmt.Printf("statistics: launched at %s, finished at %s (took %s)\n",
+				statistics.LaunchedAt.Format(time.RFC3339), statistics.FinishedAt.Format(time.RFC3339), statistics.FinishedAt.Sub(statistics.LaunchedAt))
+			if rec != nil {
+				if err := rec.Finish(statistics.LaunchedAt, statistics.FinishedAt); err != nil {
+					return err
+				}
+				fmt.Printf("report written to %s\n", rec.File())
+			}
 		} else if isCanceled, reason := event.IsCanceled(); isCanceled {
 			logger.Infof("processing canceled due to reason: %s\n", reason)
 		} else if isError, err, job := event.IsError(); isError {
@@ -111,7 +237,7 @@ func handleProcessingEvents(pipe *pipeline.Pipeline) error {
 	return nil
 }
 
-func getCliTasksAndTargets(taskMap task.TaskMap) ([]string, []string) {
+func getCliTasksAndTargets(taskMap task.TaskMap, conf *ripper.AppConf) ([]string, []string) {
 	taskNames, targets, err := cli.ParseCommandLineArguments(taskMap.TaskNamesDefined())
 	errStr := ""
 	if err != nil {
@@ -129,6 +255,7 @@ func getCliTasksAndTargets(taskMap task.TaskMap) ([]string, []string) {
 	for _, t := range targets {
 		abs, err := filepath.Abs(t)
 		require.NotFailed(err)
+		require.NotFailed(ripper.ValidateTarget(conf, abs))
 		absoluteTargetPaths = append(absoluteTargetPaths, abs)
 	}
 	return taskNames, absoluteTargetPaths
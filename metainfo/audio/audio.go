@@ -0,0 +1,85 @@
+package audio
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+)
+
+const (
+	SUBDIR_ALBUMS = "albums"
+)
+
+var (
+	META_INFO_TYPE_ALBUM = "album"
+	META_INFO_TYPE_TRACK = "track"
+)
+
+const defaultCoverExtension = "jpg"
+
+type AudioMetaInfoSource interface {
+	FetchAlbumInfo(id string) (*AlbumMetaInfo, error)
+	FetchImage(location string) (metainfo.Image, error)
+}
+
+type AlbumMetaInfo struct {
+	metainfo.IdInfo
+	Title  string
+	Artist string
+	Year   string
+	Discs  int
+	Poster string
+	Tracks []*TrackMetaInfo
+}
+
+func (a *AlbumMetaInfo) GetType() string {
+	return META_INFO_TYPE_ALBUM
+}
+
+// Track returns the meta-info for a specific track on a disc (or nil if the album contains no such track)
+func (a *AlbumMetaInfo) Track(disc int, track int) *TrackMetaInfo {
+	for _, t := range a.Tracks {
+		if t.Disc == disc && t.Track == track {
+			return t
+		}
+	}
+	return nil
+}
+
+// TracksOnDisc returns the number of tracks on a specific disc
+func (a *AlbumMetaInfo) TracksOnDisc(disc int) int {
+	count := 0
+	for _, t := range a.Tracks {
+		if t.Disc == disc {
+			count++
+		}
+	}
+	return count
+}
+
+type TrackMetaInfo struct {
+	metainfo.IdInfo
+	Title  string
+	Artist string
+	Disc   int
+	Track  int
+}
+
+func (t *TrackMetaInfo) GetType() string {
+	return META_INFO_TYPE_TRACK
+}
+
+func AlbumFileName(repoPath string, id string) string {
+	return filepath.ToSlash(filepath.Join(repoPath, SUBDIR_ALBUMS, fmt.Sprintf("%s.%s", id, metainfo.METAINF_FILE_EXT)))
+}
+
+// CoverFileName returns the location of the album cover in the repo - cover URLs do not necessarily carry a file extension
+func CoverFileName(repoPath string, album *AlbumMetaInfo) string {
+	ext := files.GetExtension(album.Poster)
+	if len(ext) == 0 {
+		ext = defaultCoverExtension
+	}
+	return metainfo.ImageFileName(repoPath, album.Id, ext)
+}
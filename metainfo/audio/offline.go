@@ -0,0 +1,18 @@
+package audio
+
+import (
+	"fmt"
+
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+)
+
+// offlineAudioMetaInfoSource never fetches anything - used to resolve meta-info from the local repo only (e.g. during dry-runs)
+type offlineAudioMetaInfoSource struct{}
+
+func (src *offlineAudioMetaInfoSource) FetchAlbumInfo(id string) (*AlbumMetaInfo, error) {
+	return nil, fmt.Errorf("album meta-info for %s is not available in local meta-info repo", id)
+}
+
+func (src *offlineAudioMetaInfoSource) FetchImage(location string) (metainfo.Image, error) {
+	return nil, fmt.Errorf("cover art %s is not available in local meta-info repo", location)
+}
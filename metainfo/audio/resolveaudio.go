@@ -0,0 +1,100 @@
+package audio
+
+import (
+	"errors"
+
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+// needs to be set for successful creation of an audio meta-info source
+var NewAudioMetaInfoSource func(conf *ripper.AudioResolveConfig) (AudioMetaInfoSource, error)
+
+func ResolveAudio(ctx task.Context) task.HandlerFunc {
+	conf := ctx.Config.(*ripper.AppConf)
+	var metaInfoSrc AudioMetaInfoSource
+	lazy := ctx.RunLazy
+	if conf.DryRun {
+		metaInfoSrc, lazy = &offlineAudioMetaInfoSource{}, true
+	} else {
+		if nil == NewAudioMetaInfoSource {
+			return ripper.ErrorHandler(errors.New("audio meta-info source is undefined"))
+		}
+		var err error
+		if metaInfoSrc, err = NewAudioMetaInfoSource(conf.Resolve.Audio); err != nil {
+			return ripper.ErrorHandler(err)
+		}
+	}
+
+	return ripper.ForMedia(func(job task.Job) ([]task.Job, error) {
+		target := ripper.GetTargetFileFromJob(job)
+		ctx.Printf("resolve audio - target %s\n", target)
+
+		ti, err := targetinfo.ForTarget(conf.WorkDirectory, target)
+		if err != nil {
+			return nil, err
+		}
+
+		printf := ctx.Printf.WithIndent(2)
+		printf("recovered target-info: %s\n", ti.String())
+
+		if targetinfo.IsTrack(ti) {
+			err = resolveTrack(metaInfoSrc, conf.MetaInfoRepo, lazy, ti.(*targetinfo.Track))
+		} else {
+			//ignore other target-info types (e.g video)
+		}
+		if err != nil && conf.DryRun {
+			printf("dry-run - %s\n", err)
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []task.Job{job}, nil
+	}, ripper.MEDIA_AUDIO)
+}
+
+func resolveTrack(metaInfoSrc AudioMetaInfoSource, repoDir string, lazy bool, ti *targetinfo.Track) error {
+	albumFile := AlbumFileName(repoDir, ti.Id)
+	album := &AlbumMetaInfo{}
+	if needToResolve(albumFile, lazy) {
+		var err error
+		if album, err = metaInfoSrc.FetchAlbumInfo(ti.Id); err != nil {
+			return err
+		}
+		if err = metainfo.SaveMetaInfo(albumFile, album); err != nil {
+			return err
+		}
+	} else if err := metainfo.ReadMetaInfo(albumFile, album); err != nil {
+		return err
+	}
+
+	if album.Track(ti.Disc, ti.Track) == nil {
+		return errors.New("album meta-info does not contain target: " + ti.String())
+	}
+
+	// not all albums come with cover art
+	if len(album.Poster) == 0 {
+		return nil
+	}
+	coverFile := CoverFileName(repoDir, album)
+	if !needToResolve(coverFile, lazy) {
+		return nil
+	}
+	img, err := metaInfoSrc.FetchImage(album.Poster)
+	if err != nil {
+		return err
+	}
+	return metainfo.SaveImage(coverFile, img)
+}
+
+func needToResolve(metaInfFile string, lazy bool) bool {
+	if !lazy {
+		return true
+	}
+	alreadyExists, _ := files.Exists(metaInfFile)
+	return !alreadyExists
+}
@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+type testAudioMetaInfoSource struct {
+	album         *AlbumMetaInfo
+	albumFetched  int
+	imagesFetched int
+}
+
+func (src *testAudioMetaInfoSource) FetchAlbumInfo(id string) (*AlbumMetaInfo, error) {
+	if src.album == nil || src.album.Id != id {
+		return nil, errors.New("test error - album not found")
+	}
+	src.albumFetched++
+	return src.album, nil
+}
+
+func (src *testAudioMetaInfoSource) FetchImage(location string) (metainfo.Image, error) {
+	src.imagesFetched++
+	return []byte{1, 2, 3}, nil
+}
+
+func TestResolveTrack(t *testing.T) {
+	album := &AlbumMetaInfo{IdInfo: metainfo.IdInfo{Id: "album"}, Title: "title", Discs: 1, Poster: "http://cover/front",
+		Tracks: []*TrackMetaInfo{{Title: "first", Disc: 1, Track: 1}}}
+
+	t.Run("fetch album and cover once if lazy", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		repoDir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, repoDir)
+		src := &testAudioMetaInfoSource{album: album}
+		ti := targetinfo.NewTrack("1.flac", "/a", album.Id, 1, 1, 1)
+
+		assert.NotError(resolveTrack(src, repoDir, true, ti))
+		assert.NotError(resolveTrack(src, repoDir, true, ti))
+		assert.IntsEqual(1, src.albumFetched)
+		assert.IntsEqual(1, src.imagesFetched)
+		assert.TrueNotError("album meta-info was not saved")(files.Exists(AlbumFileName(repoDir, album.Id)))
+		assert.TrueNotError("album cover was not saved")(files.Exists(CoverFileName(repoDir, album)))
+	})
+
+	t.Run("expect error if album does not contain track", func(t *testing.T) {
+		repoDir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, repoDir)
+		src := &testAudioMetaInfoSource{album: album}
+		ti := targetinfo.NewTrack("9.flac", "/a", album.Id, 1, 9, 1)
+		test.AssertOn(t).ExpectError("expected error for unknown track, but got none")(resolveTrack(src, repoDir, true, ti))
+	})
+}
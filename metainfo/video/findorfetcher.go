@@ -1,6 +1,8 @@
 package video
 
 import (
+	"fmt"
+
 	"github.com/thomasschoeftner/go-ripper/files"
 	"github.com/thomasschoeftner/go-ripper/metainfo"
 	"github.com/thomasschoeftner/go-ripper/ripper"
@@ -20,21 +22,54 @@ type findOrFetcher struct {
 type fetchFunc func() (metainfo.MetaInfo, error)
 
 func (ff *findOrFetcher) doResolve(metaInfo metainfo.MetaInfo, metaInfoFileName string, doFetch fetchFunc) (metainfo.MetaInfo, error) {
-	if ff.needToResolve(metaInfoFileName, ff.lazy) {
-		mi, err := doFetch()
-		if err == nil {
-			err = metainfo.SaveMetaInfo(metaInfoFileName, mi)
-		}
-		if err != nil {
-			return nil, err
-		}
-		return mi, nil
-	} else {
+	var cached metainfo.MetaInfo
+	if !ff.needToResolve(metaInfoFileName, ff.lazy) {
 		err := metainfo.ReadMetaInfo(metaInfoFileName, metaInfo)
 		if err != nil {
 			return nil, err
 		}
-		return metaInfo, nil
+		if !lacksDetails(metaInfo) {
+			return metaInfo, nil
+		}
+		cached = metaInfo
+	}
+
+	mi, err := doFetch()
+	if err != nil && cached != nil {
+		return cached, nil // meta-info without details is better than none (e.g. during dry-runs)
+	}
+	if err == nil {
+		markDetailsResolved(mi)
+		err = metainfo.SaveMetaInfo(metaInfoFileName, mi)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mi, nil
+}
+
+// lacksDetails checks for meta-info saved before details were resolved - it is fetched again even if lazy
+func lacksDetails(metaInfo metainfo.MetaInfo) bool {
+	switch mi := metaInfo.(type) {
+	case *MovieMetaInfo:
+		return !mi.DetailsResolved
+	case *SeriesMetaInfo:
+		return !mi.DetailsResolved
+	case *EpisodeMetaInfo:
+		return !mi.DetailsResolved
+	default:
+		return false
+	}
+}
+
+func markDetailsResolved(metaInfo metainfo.MetaInfo) {
+	switch mi := metaInfo.(type) {
+	case *MovieMetaInfo:
+		mi.DetailsResolved = true
+	case *SeriesMetaInfo:
+		mi.DetailsResolved = true
+	case *EpisodeMetaInfo:
+		mi.DetailsResolved = true
 	}
 }
 
@@ -59,16 +94,49 @@ func (ff *findOrFetcher) series(ti *targetinfo.Episode) (*SeriesMetaInfo, error)
 	return mi.(*SeriesMetaInfo), nil
 }
 
-func (ff *findOrFetcher) episode(ti *targetinfo.Episode) (*EpisodeMetaInfo, error) {
-	mi, err := ff.doResolve(&EpisodeMetaInfo{}, EpisodeFileName(ff.conf.MetaInfoRepo, ti.Id, ti.Season, ti.Episode), func() (metainfo.MetaInfo, error) {
-		return ff.metaInfoSource.FetchEpisodeInfo(ti.Id, ti.Season, ti.Episode)
+func (ff *findOrFetcher) season(ti *targetinfo.Episode) (*SeasonMetaInfo, error) {
+	mi, err := ff.doResolve(&SeasonMetaInfo{}, SeasonFileName(ff.conf.MetaInfoRepo, ti.Id, ti.Season), func() (metainfo.MetaInfo, error) {
+		return ff.metaInfoSource.FetchSeasonInfo(ti.Id, ti.Season)
 	})
+	if IsNotFound(err) && ti.IsSpecial() && !ff.conf.DryRun {
+		// specials are often unknown to meta-info sources - use an empty season without episode list and poster, which is not saved
+		return &SeasonMetaInfo{IdInfo: metainfo.IdInfo{Id: ti.Id}, Season: ti.Season}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mi.(*SeasonMetaInfo), nil
+}
+
+// episode resolves a single episode of the target - multi-episode files require resolving every episode in the range
+func (ff *findOrFetcher) episode(ti *targetinfo.Episode, episode int) (*EpisodeMetaInfo, error) {
+	mi, err := ff.doResolve(&EpisodeMetaInfo{}, EpisodeFileName(ff.conf.MetaInfoRepo, ti.Id, ti.Season, episode), func() (metainfo.MetaInfo, error) {
+		return ff.metaInfoSource.FetchEpisodeInfo(ti.Id, ti.Season, episode)
+	})
+	if IsNotFound(err) && ti.IsSpecial() && !ff.conf.DryRun {
+		// specials are often unknown to meta-info sources - the placeholder is not saved, so the special is looked up again later
+		return SpecialPlaceholder(ti.Id, ti.Season, episode), nil
+	}
 	if err != nil {
 		return nil, err
 	}
 	return mi.(*EpisodeMetaInfo), nil
 }
 
+func (ff *findOrFetcher) search(kind string, title string, year string) (*SearchMetaInfo, error) {
+	mi, err := ff.doResolve(&SearchMetaInfo{}, SearchFileName(ff.conf.MetaInfoRepo, kind, title, year), func() (metainfo.MetaInfo, error) {
+		results, err := ff.metaInfoSource.SearchVideo(kind, title, year)
+		if err != nil {
+			return nil, err
+		}
+		return &SearchMetaInfo{IdInfo: metainfo.IdInfo{Id: fmt.Sprintf("%s (%s)", title, year)}, Kind: kind, Title: title, Year: year, Results: results}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mi.(*SearchMetaInfo), nil
+}
+
 func (ff *findOrFetcher) image(id string, imageUri string) error {
 	imageFile := metainfo.ImageFileName(ff.conf.MetaInfoRepo, id, files.GetExtension(imageUri))
 	if !ff.needToResolve(imageFile, ff.lazy) {
@@ -78,12 +78,26 @@ func TestFindOrFetchMovie(t *testing.T) {
 			}
 		}
 	}
-	existingMovie := MovieMetaInfo{IdInfo: metainfo.IdInfo{Id: movieTi.Id}, Title: "an earlier awesome adventure of Sepp", Year: "2008", Poster: "aeaaos.jpg"}
+	existingMovie := MovieMetaInfo{IdInfo: metainfo.IdInfo{Id: movieTi.Id}, Details: Details{DetailsResolved: true}, Title: "an earlier awesome adventure of Sepp", Year: "2008", Poster: "aeaaos.jpg"}
+	movieWithoutDetails := existingMovie
+	movieWithoutDetails.Details = Details{}
 
 	t.Run("eager without pre-existing meta-info files", testFindOrFetch(false, nil, false))
 	t.Run("lazy without pre-existing meta-info files", testFindOrFetch(true, nil, false))
 	t.Run("eager with pre-existing meta-info files", testFindOrFetch(false, &existingMovie, false))
 	t.Run("lazy with pre-existing meta-info files", testFindOrFetch(true, &existingMovie, true))
+	t.Run("lazy with pre-existing meta-info files saved before details were resolved", testFindOrFetch(true, &movieWithoutDetails, false))
+
+	t.Run("lazy falls back to pre-existing meta-info if fetching details fails", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir, conf := setupFindOrFetcher(assert, &movieWithoutDetails, nil, nil, nil)
+		defer teardownFindOrFetcher(assert, dir)
+
+		fof := findOrFetch(newVideoMetaInfoSource(nil, nil, nil, nil), conf, true)
+		gotMovie, err := fof.movie(movieTi)
+		assert.NotError(err)
+		assertMoviesEqual(assert, &movieWithoutDetails, gotMovie)
+	})
 }
 
 func TestFindOrFetchImage(t *testing.T) {
@@ -146,12 +160,15 @@ func TestFindOrFetchSeries(t *testing.T) {
 			}
 		}
 	}
-	existingSeries := SeriesMetaInfo{IdInfo: metainfo.IdInfo{episodeTi.Id}, Title: "yet another time waster", Seasons: 7, Year: "2002", Poster: "yatw.png"}
+	existingSeries := SeriesMetaInfo{IdInfo: metainfo.IdInfo{episodeTi.Id}, Details: Details{Genres: []string{"Drama"}, DetailsResolved: true}, Title: "yet another time waster", Seasons: 7, Year: "2002", Poster: "yatw.png"}
 
 	t.Run("eager without pre-existing image", testFindOrFetch(false, nil, false))
 	t.Run("lazy without pre-existing image", testFindOrFetch(true, nil, false))
 	t.Run("eager with pre-existing image", testFindOrFetch(false, &existingSeries, false))
 	t.Run("lazy with pre-existing image", testFindOrFetch(true, &existingSeries, true))
+	seriesWithoutDetails := existingSeries
+	seriesWithoutDetails.Details = Details{}
+	t.Run("lazy with pre-existing meta-info saved before details were resolved", testFindOrFetch(true, &seriesWithoutDetails, false))
 }
 
 func TestFindOrFetchEpisode(t *testing.T) {
@@ -164,7 +181,7 @@ func TestFindOrFetchEpisode(t *testing.T) {
 			miSrc := newVideoMetaInfoSource(&movieMi, &seriesMi, &episodeMi, imageMi)
 			fof := findOrFetch(miSrc, conf, lazy)
 
-			gotEpisode, err := fof.episode(episodeTi)
+			gotEpisode, err := fof.episode(episodeTi, episodeTi.Episode)
 			assert.NotError(err)
 			assert.True("episode meta-info image was fetched")(0 == len(miSrc.imagesFetched))
 			assert.False("series was fetched")(miSrc.seriesFetched)
@@ -180,12 +197,15 @@ func TestFindOrFetchEpisode(t *testing.T) {
 		}
 	}
 
-	existingEpisode := EpisodeMetaInfo{IdInfo: metainfo.IdInfo{Id: episodeTi.Id}, Title: "an earlier attack of the raffgrns", Year: "2008", Episode: episodeTi.Episode, Season: episodeTi.Season}
+	existingEpisode := EpisodeMetaInfo{IdInfo: metainfo.IdInfo{Id: episodeTi.Id}, Details: Details{Runtime: 42, DetailsResolved: true}, Title: "an earlier attack of the raffgrns", Year: "2008", Episode: episodeTi.Episode, Season: episodeTi.Season}
 
 	t.Run("eager without pre-existing image", testFindOrFetch(false, nil, false))
 	t.Run("lazy without pre-existing image", testFindOrFetch(true, nil, false))
 	t.Run("eager with pre-existing image", testFindOrFetch(false, &existingEpisode, false))
 	t.Run("lazy with pre-existing image", testFindOrFetch(true, &existingEpisode, true))
+	episodeWithoutDetails := existingEpisode
+	episodeWithoutDetails.Details = Details{}
+	t.Run("lazy with pre-existing meta-info saved before details were resolved", testFindOrFetch(true, &episodeWithoutDetails, false))
 }
 
 func assertMoviesEqual(assert *test.Assertion, expected *MovieMetaInfo, got *MovieMetaInfo) {
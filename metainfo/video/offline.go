@@ -0,0 +1,38 @@
+package video
+
+import (
+	"fmt"
+
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+)
+
+// offlineVideoMetaInfoSource never fetches anything - used to resolve meta-info from the local repo only (e.g. during dry-runs)
+type offlineVideoMetaInfoSource struct{}
+
+func notAvailableLocally(what string) error {
+	return fmt.Errorf("%s is not available in local meta-info repo", what)
+}
+
+func (src *offlineVideoMetaInfoSource) FetchMovieInfo(id string) (*MovieMetaInfo, error) {
+	return nil, notAvailableLocally(fmt.Sprintf("movie meta-info for %s", id))
+}
+
+func (src *offlineVideoMetaInfoSource) FetchSeriesInfo(id string) (*SeriesMetaInfo, error) {
+	return nil, notAvailableLocally(fmt.Sprintf("series meta-info for %s", id))
+}
+
+func (src *offlineVideoMetaInfoSource) FetchSeasonInfo(id string, season int) (*SeasonMetaInfo, error) {
+	return nil, notAvailableLocally(fmt.Sprintf("season meta-info for %s (season %d)", id, season))
+}
+
+func (src *offlineVideoMetaInfoSource) FetchEpisodeInfo(id string, season int, episode int) (*EpisodeMetaInfo, error) {
+	return nil, notAvailableLocally(fmt.Sprintf("episode meta-info for %s (season %d, episode %d)", id, season, episode))
+}
+
+func (src *offlineVideoMetaInfoSource) FetchImage(location string) (metainfo.Image, error) {
+	return nil, notAvailableLocally(fmt.Sprintf("image %s", location))
+}
+
+func (src *offlineVideoMetaInfoSource) SearchVideo(kind string, title string, year string) ([]*VideoSearchResult, error) {
+	return nil, notAvailableLocally(fmt.Sprintf("search results for %s \"%s\" (%s)", kind, title, year))
+}
@@ -2,8 +2,14 @@ package video
 
 import (
 	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/thomasschoeftner/go-cli/commons"
 
 	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+	"github.com/thomasschoeftner/go-ripper/override"
 	"github.com/thomasschoeftner/go-ripper/ripper"
 	"github.com/thomasschoeftner/go-ripper/targetinfo"
 )
@@ -13,16 +19,21 @@ var NewVideoMetaInfoSource func(conf *ripper.VideoResolveConfig) (VideoMetaInfoS
 
 func ResolveVideo(ctx task.Context) task.HandlerFunc {
 	conf := ctx.Config.(*ripper.AppConf)
-	if nil == NewVideoMetaInfoSource {
-		return ripper.ErrorHandler(errors.New("video meta-info source is undefined"))
-	}
-	metaInfoSrc, err := NewVideoMetaInfoSource(conf.Resolve.Video)
-	if err != nil {
-		return ripper.ErrorHandler(err)
+	var findOrFetcher *findOrFetcher
+	if conf.DryRun {
+		findOrFetcher = findOrFetch(&offlineVideoMetaInfoSource{}, conf, true)
+	} else {
+		if nil == NewVideoMetaInfoSource {
+			return ripper.ErrorHandler(errors.New("video meta-info source is undefined"))
+		}
+		metaInfoSrc, err := NewVideoMetaInfoSource(conf.Resolve.Video)
+		if err != nil {
+			return ripper.ErrorHandler(err)
+		}
+		findOrFetcher = findOrFetch(metaInfoSrc, conf, ctx.RunLazy)
 	}
-	findOrFetcher := findOrFetch(metaInfoSrc, conf, ctx.RunLazy)
 
-	return func(job task.Job) ([]task.Job, error) {
+	return ripper.ForMedia(func(job task.Job) ([]task.Job, error) {
 		target := ripper.GetTargetFileFromJob(job)
 		ctx.Printf("resolve video - target %s\n", target)
 
@@ -34,18 +45,98 @@ func ResolveVideo(ctx task.Context) task.HandlerFunc {
 		printf := ctx.Printf.WithIndent(2)
 		printf("recovered target-info: %s\n", ti.String())
 
+		if video := videoOf(ti); video != nil && len(video.Id) == 0 {
+			found, err := resolveId(findOrFetcher, conf, ti, video, printf)
+			if err != nil && conf.DryRun {
+				printf("dry-run - %s\n", err)
+				return []task.Job{job}, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				return []task.Job{}, nil //skip targets to be reviewed
+			}
+		}
+
 		if targetinfo.IsEpisode(ti) {
-			err = resolveEpisode(findOrFetcher, ti.(*targetinfo.Episode))
+			err = resolveEpisode(findOrFetcher, ti.(*targetinfo.Episode), printf)
 		} else if targetinfo.IsMovie(ti) {
 			err = resolveMovie(findOrFetcher, ti.(*targetinfo.Movie))
 		} else {
 			//ignore other target-info types (e.g audio)
 		}
+		if err != nil && conf.DryRun {
+			printf("dry-run - %s\n", err)
+			err = nil
+		}
 		if err != nil {
 			return nil, err
 		}
 		return []task.Job{job}, nil
+	}, ripper.MEDIA_VIDEO)
+}
+
+func videoOf(ti targetinfo.TargetInfo) *targetinfo.Video {
+	switch v := ti.(type) {
+	case *targetinfo.Movie:
+		return &v.Video
+	case *targetinfo.Episode:
+		return &v.Video
+	default:
+		return nil
+	}
+}
+
+// resolveId looks up the id of a video scanned without id by its title and year and updates its target-info.
+// Videos without an unambiguous and confident match are added to the needs-review list instead - found is false then.
+func resolveId(findOrFetch *findOrFetcher, conf *ripper.AppConf, ti targetinfo.TargetInfo, video *targetinfo.Video, printf commons.FormatPrinter) (bool, error) {
+	if len(video.Title) == 0 {
+		return false, fmt.Errorf("neither id nor title found for %s", ti.GetFullPath())
+	}
+	searchConf := conf.Resolve.Video.Search
+	if searchConf == nil {
+		return false, fmt.Errorf("no id found for %s and looking up ids by title is not configured", ti.GetFullPath())
+	}
+
+	kind := SEARCH_KIND_MOVIE
+	if targetinfo.IsEpisode(ti) {
+		kind = SEARCH_KIND_SERIES
+	}
+	search, err := findOrFetch.search(kind, video.Title, video.Year)
+	if err != nil {
+		return false, err
+	}
+	matches := rateMatches(video.Title, video.Year, search.Results)
+	best := bestMatch(matches, searchConf.MinConfidence)
+	if best == nil {
+		reviewFile := searchConf.NeedsReview
+		if !filepath.IsAbs(reviewFile) {
+			reviewFile = filepath.Join(conf.StateDirectory(), reviewFile)
+		}
+		printf("no unambiguous match found for %s \"%s\" (%s) - needs review in %s\n", kind, video.Title, video.Year, reviewFile)
+		if conf.DryRun {
+			return false, nil
+		}
+		return false, addToReview(reviewFile, ti.GetFullPath(), video.Title, video.Year, matches)
+	}
+
+	printf("found %s \"%s\" (%s) with id %s\n", kind, best.Title, best.Year, best.Id)
+	video.Id = best.Id
+	return true, saveTargetInfo(conf, ti)
+}
+
+// saveTargetInfo writes an updated target-info to the work directory - or keeps it in memory during dry-runs
+func saveTargetInfo(conf *ripper.AppConf, ti targetinfo.TargetInfo) error {
+	if conf.DryRun {
+		targetinfo.Plan(ti)
+		return nil
 	}
+	workDir, err := ripper.GetWorkPathForTargetFolder(conf.WorkDirectory, ti.GetFolder())
+	if err != nil {
+		return err
+	}
+	return targetinfo.Save(workDir, ti)
 }
 
 func resolveMovie(findOrFetch *findOrFetcher, ti *targetinfo.Movie) error {
@@ -53,21 +144,48 @@ func resolveMovie(findOrFetch *findOrFetcher, ti *targetinfo.Movie) error {
 	if err != nil {
 		return err
 	}
-	return findOrFetch.image(movie.Id, movie.Poster)
+	return resolvePoster(findOrFetch, ti, movie.Id, movie.Poster)
 }
 
-func resolveEpisode(findOrFetch *findOrFetcher, ti *targetinfo.Episode) error {
+// resolveEpisode resolves the series, season and all episodes of the target.
+// The total # of episodes of the target is updated with the actual # of episodes of the season, which may differ from the # of files scanned.
+// The poster of the season is preferred over the poster of the series.
+// The season is optional - if it cannot be resolved, the scanned # of episodes and the poster of the series are used.
+func resolveEpisode(findOrFetch *findOrFetcher, ti *targetinfo.Episode, printf commons.FormatPrinter) error {
 	series, err := findOrFetch.series(ti)
 	if err != nil {
 		return err
 	}
+	season, err := findOrFetch.season(ti)
+	if err != nil {
+		printf("unable to resolve season %d of %s - continue without: %s\n", ti.Season, ti.Id, err)
+		season = &SeasonMetaInfo{IdInfo: metainfo.IdInfo{Id: ti.Id}, Season: ti.Season}
+	}
+
+	for _, episode := range ti.Episodes() {
+		if _, err = findOrFetch.episode(ti, episode); err != nil {
+			return err
+		}
+	}
+
+	if count := season.EpisodeCount(); count > 0 && count != ti.ItemsTotal {
+		ti.ItemsTotal = count
+		if err := saveTargetInfo(findOrFetch.conf, ti); err != nil {
+			return err
+		}
+	}
 
-	// TODO - consider adding Season Meta-Info
+	if len(season.Poster) > 0 {
+		return resolvePoster(findOrFetch, ti, SeasonPosterId(series.Id, season.Season), season.Poster)
+	}
+	return resolvePoster(findOrFetch, ti, series.Id, series.Poster)
+}
 
-	_, err = findOrFetch.episode(ti)
+// resolvePoster fetches the poster image - or the poster provided in the target's override files instead
+func resolvePoster(findOrFetch *findOrFetcher, ti targetinfo.TargetInfo, id string, poster string) error {
+	o, err := override.ForFile(ti.GetFullPath())
 	if err != nil {
 		return err
 	}
-
-	return findOrFetch.image(series.Id, series.Poster)
+	return findOrFetch.image(o.PosterId(id), override.Or(o.Poster, poster))
 }
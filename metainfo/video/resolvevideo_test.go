@@ -1,7 +1,9 @@
 package video
 
 import (
+	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/thomasschoeftner/go-cli/commons"
@@ -19,7 +21,9 @@ var episodeTi = targetinfo.NewEpisode("episode1.mp4", "/a/b/c", "tt654321", 3, 2
 
 var movieMi = MovieMetaInfo{IdInfo: metainfo.IdInfo{Id: movieTi.Id}, Title: "The awesome adventures of Sepp", Year: "2018", Poster: "taaos.jpg"}
 var seriesMi = SeriesMetaInfo{IdInfo: metainfo.IdInfo{Id: episodeTi.Id}, Title: "a space oddity", Year: "2017", Seasons: 3, Poster: "aso.png"}
+var seasonMi = SeasonMetaInfo{IdInfo: metainfo.IdInfo{Id: episodeTi.Id}, Season: 3, Episodes: []*SeasonEpisode{{Episode: 1}, {Episode: 2}, {Episode: 3}, {Episode: 4}}}
 var episodeMi = EpisodeMetaInfo{IdInfo: metainfo.IdInfo{Id: episodeTi.Id}, Title: "attack of the raffgrns", Year: "2017", Episode: 2, Season: 3}
+var printf = commons.FormatPrinter(func(string, ...interface{}) {})
 var imageMi = map[string][]byte{movieMi.Poster: []byte{1, 2, 3, 4}, seriesMi.Poster: []byte{5, 6, 7, 8}}
 
 const confJson = `
@@ -81,6 +85,7 @@ func TestResolveVideo(t *testing.T) {
 
 	t.Run("episodeTi", func(t *testing.T) {
 		miSource := newVideoMetaInfoSource(&movieMi, &seriesMi, &episodeMi, imageMi)
+		miSource.season = &seasonMi
 		NewVideoMetaInfoSource = func(conf *ripper.VideoResolveConfig) (VideoMetaInfoSource, error) {
 			return miSource, nil
 		}
@@ -96,6 +101,11 @@ func TestResolveVideo(t *testing.T) {
 		assert.True("series not fetched from meta-info source")(miSource.seriesFetched)
 		assert.True("image not fetched from meta-info source")(1 == len(miSource.imagesFetched))
 		assert.True("episodeTi not fetched from meta-info source")(miSource.episodeFetched)
+		assert.True("season not fetched from meta-info source")(miSource.seasonFetched)
+
+		resolved, err := targetinfo.ForTarget(conf.WorkDirectory, episodeTi.GetFullPath())
+		assert.NotError(err)
+		assert.IntsEqual(seasonMi.EpisodeCount(), resolved.(*targetinfo.Episode).ItemsTotal)
 	})
 }
 
@@ -107,6 +117,7 @@ func setupResolver(t *testing.T, lazy bool) (string, *findOrFetcher) {
 		"repodir": filepath.ToSlash(filepath.Join(dir, "repo")),
 		"workdir": filepath.ToSlash(filepath.Join(dir, "work"))}))
 	miSource := newVideoMetaInfoSource(&movieMi, &seriesMi, &episodeMi, imageMi)
+	miSource.season = &seasonMi
 	testFindOrFetcher := findOrFetch(miSource, conf, false)
 	return dir, testFindOrFetcher
 }
@@ -135,10 +146,140 @@ func TestResolveEpisode(t *testing.T) {
 	assert := test.AssertOn(t)
 	miSource := testFindOrFetcher.metaInfoSource.(*testVideoMetaInfoSource)
 
-	assert.NotError(resolveEpisode(testFindOrFetcher, episodeTi))
+	ti := targetinfo.NewEpisode("episode1.mp4", "/a/b/c", episodeTi.Id, 3, 2, 1) // only 1 episode of the season scanned
+	assert.NotError(resolveEpisode(testFindOrFetcher, ti, printf))
 	assert.True("series meta-info not fetched")(miSource.seriesFetched)
+	assert.True("season meta-info not fetched")(miSource.seasonFetched)
 	assert.True("episode meta-info not invoked")(miSource.episodeFetched)
 	assert.True("image resolve missing")(1 == len(miSource.imagesFetched))
 	assert.StringsEqual(seriesMi.Poster, miSource.imagesFetched[0])
 	assert.False("movie meta-info unnecessarily fetched")(miSource.movieFetched)
+	assert.IntsEqual(4, ti.ItemsTotal)
+
+	saved, err := targetinfo.ForTarget(testFindOrFetcher.conf.WorkDirectory, ti.GetFullPath())
+	assert.NotError(err)
+	assert.IntsEqual(4, saved.(*targetinfo.Episode).ItemsTotal)
+}
+
+func TestResolveSeasonPoster(t *testing.T) {
+	dir, testFindOrFetcher := setupResolver(t, false)
+	defer teardownResolver(t, dir)
+	assert := test.AssertOn(t)
+	miSource := testFindOrFetcher.metaInfoSource.(*testVideoMetaInfoSource)
+	season := seasonMi
+	season.Poster = "season3.jpg"
+	miSource.season = &season
+	miSource.images = map[string][]byte{seriesMi.Poster: {1}, season.Poster: {2}}
+
+	ti := targetinfo.NewEpisode("episode1.mp4", "/a/b/c", episodeTi.Id, 3, 2, 4)
+	assert.NotError(resolveEpisode(testFindOrFetcher, ti, printf))
+	assert.StringSlicesEqual([]string{season.Poster}, miSource.imagesFetched)
+	exists, err := files.Exists(metainfo.ImageFileName(testFindOrFetcher.conf.MetaInfoRepo, SeasonPosterId(ti.Id, 3), "jpg"))
+	assert.True("expected season poster to be stored separately from series poster")(exists && err == nil)
+}
+
+func TestResolveEpisodeWithoutSeason(t *testing.T) {
+	dir, testFindOrFetcher := setupResolver(t, false)
+	defer teardownResolver(t, dir)
+	assert := test.AssertOn(t)
+	miSource := testFindOrFetcher.metaInfoSource.(*testVideoMetaInfoSource)
+	miSource.season = nil // source fails to provide the season
+
+	ti := targetinfo.NewEpisode("episode1.mp4", "/a/b/c", episodeTi.Id, 3, 2, 1)
+	assert.NotError(resolveEpisode(testFindOrFetcher, ti, printf))
+	assert.True("episode meta-info not invoked")(miSource.episodeFetched)
+	assert.StringSlicesEqual([]string{seriesMi.Poster}, miSource.imagesFetched)
+	assert.IntsEqual(1, ti.ItemsTotal) // the scanned # of episodes is kept
+	exists, _ := files.Exists(SeasonFileName(testFindOrFetcher.conf.MetaInfoRepo, ti.Id, 3))
+	assert.False("expected failed season not to be saved to the repo")(exists)
+}
+
+func TestResolveMultiEpisode(t *testing.T) {
+	dir, testFindOrFetcher := setupResolver(t, false)
+	defer teardownResolver(t, dir)
+	assert := test.AssertOn(t)
+	miSource := testFindOrFetcher.metaInfoSource.(*testVideoMetaInfoSource)
+	nextEpisodeMi := EpisodeMetaInfo{IdInfo: metainfo.IdInfo{Id: episodeTi.Id}, Title: "return of the raffgrns", Year: "2017", Episode: 3, Season: 3}
+	miSource.moreEpisodes = []*EpisodeMetaInfo{&nextEpisodeMi}
+
+	multiEpisodeTi := targetinfo.NewEpisode("episode2-3.mp4", "/a/b/c", episodeTi.Id, 3, 2, 7)
+	multiEpisodeTi.LastEpisode = 3
+	assert.NotError(resolveEpisode(testFindOrFetcher, multiEpisodeTi, printf))
+	assert.IntsEqual(2, len(miSource.episodesFetched))
+	assert.IntsEqual(3, miSource.episodesFetched[1])
+
+	multiEpisodeTi.LastEpisode = 4
+	assert.ExpectError("expected error for unknown episode in range")(resolveEpisode(testFindOrFetcher, multiEpisodeTi, printf))
+}
+
+func TestResolveSpecial(t *testing.T) {
+	dir, testFindOrFetcher := setupResolver(t, false)
+	defer teardownResolver(t, dir)
+	assert := test.AssertOn(t)
+
+	specialTi := targetinfo.NewEpisode("making-of.mp4", "/a/b/c/Specials", episodeTi.Id, 0, 1, 1)
+	assert.NotError(resolveEpisode(testFindOrFetcher, specialTi, printf))
+	special, err := testFindOrFetcher.episode(specialTi, 1)
+	assert.NotError(err)
+	assert.StringsEqual("Special 1", special.Title)
+	assert.StringsEqual(specialTi.Id, special.Id)
+	assert.IntsEqual(1, specialTi.ItemsTotal) // specials unknown to the meta-info source keep the # of scanned files
+	exists, _ := files.Exists(EpisodeFileName(testFindOrFetcher.conf.MetaInfoRepo, specialTi.Id, 0, 1))
+	assert.False("expected placeholder of unknown special not to be saved to the repo")(exists)
+	exists, _ = files.Exists(SeasonFileName(testFindOrFetcher.conf.MetaInfoRepo, specialTi.Id, 0))
+	assert.False("expected empty season of unknown specials not to be saved to the repo")(exists)
+
+	testFindOrFetcher.metaInfoSource.(*testVideoMetaInfoSource).episode = nil // source fails instead of not knowing the special
+	assert.ExpectError("expected failure of meta-info source not to be replaced by placeholder")(resolveEpisode(testFindOrFetcher, specialTi, printf))
+}
+
+func TestResolveId(t *testing.T) {
+	setup := func(t *testing.T, results ...*VideoSearchResult) (*test.Assertion, string, *findOrFetcher, *testVideoMetaInfoSource) {
+		dir, testFindOrFetcher := setupResolver(t, false)
+		testFindOrFetcher.conf.Resolve.Video.Search = &ripper.TitleSearchConfig{MinConfidence: 0.85, NeedsReview: "needs-review.txt"}
+		miSource := testFindOrFetcher.metaInfoSource.(*testVideoMetaInfoSource)
+		miSource.searchResults = results
+		return test.AssertOn(t), dir, testFindOrFetcher, miSource
+	}
+	scanned := func() *targetinfo.Movie {
+		ti := targetinfo.NewMovie("matrix.avi", "/in/The Matrix (1999)", "")
+		ti.Title, ti.Year = "The Matrix", "1999"
+		return ti
+	}
+
+	t.Run("update target-info with id of confident match", func(t *testing.T) {
+		assert, dir, testFindOrFetcher, miSource := setup(t, &VideoSearchResult{Id: "tt0133093", Title: "The Matrix", Year: "1999"})
+		defer teardownResolver(t, dir)
+		ti := scanned()
+
+		found, err := resolveId(testFindOrFetcher, testFindOrFetcher.conf, ti, &ti.Video, printf)
+		assert.TrueNotError("expected id to be found")(found, err)
+		assert.True("expected search")(miSource.searched)
+		assert.StringsEqual("tt0133093", ti.Id)
+		saved, err := targetinfo.ForTarget(testFindOrFetcher.conf.WorkDirectory, ti.GetFullPath())
+		assert.NotError(err)
+		assert.StringsEqual("tt0133093", saved.GetId())
+	})
+
+	t.Run("add ambiguous matches to review list", func(t *testing.T) {
+		assert, dir, testFindOrFetcher, _ := setup(t, &VideoSearchResult{Id: "tt0133093", Title: "The Matrix", Year: "1999"}, &VideoSearchResult{Id: "tt0000002", Title: "The Matrix", Year: "1999"})
+		defer teardownResolver(t, dir)
+		ti := scanned()
+		testFindOrFetcher.conf.StoragePath = filepath.Join(dir, "storage") // the review list is kept across runs with varying work directories
+
+		assert.FalseNotError("expected ambiguous match")(resolveId(testFindOrFetcher, testFindOrFetcher.conf, ti, &ti.Video, printf))
+		assert.StringsEqual("", ti.Id)
+		review, err := ioutil.ReadFile(filepath.Join(dir, "storage", "needs-review.txt"))
+		assert.NotError(err)
+		assert.True("expected target in review list")(strings.Contains(string(review), ti.GetFullPath()))
+	})
+
+	t.Run("expect error if title search is not configured", func(t *testing.T) {
+		assert, dir, testFindOrFetcher, _ := setup(t)
+		defer teardownResolver(t, dir)
+		testFindOrFetcher.conf.Resolve.Video.Search = nil
+		ti := scanned()
+		_, err := resolveId(testFindOrFetcher, testFindOrFetcher.conf, ti, &ti.Video, printf)
+		assert.ExpectError("expected error without title search config")(err)
+	})
 }
@@ -0,0 +1,163 @@
+package video
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/thomasschoeftner/go-ripper/files"
+)
+
+// match is a search result rated by its confidence
+type match struct {
+	result     *VideoSearchResult
+	confidence float64
+}
+
+// rateMatches rates all search results by their confidence - best matches first
+func rateMatches(title string, year string, results []*VideoSearchResult) []match {
+	matches := []match{}
+	for _, r := range results {
+		matches = append(matches, match{result: r, confidence: confidence(title, year, r)})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].confidence > matches[j].confidence })
+	return matches
+}
+
+// confidence rates how well a search result matches the scanned title and year - from 0 (no match) to 1 (perfect match)
+func confidence(title string, year string, r *VideoSearchResult) float64 {
+	c := similarity(normalizedTitle(title), normalizedTitle(r.Title))
+	if wanted := yearOf(year); wanted > 0 {
+		switch diff := wanted - yearOf(r.Year); {
+		case diff == 0:
+		case diff == 1 || diff == -1: //release dates often differ by a year between countries
+			c *= 0.9
+		default:
+			c *= 0.5
+		}
+	}
+	return c
+}
+
+// bestMatch returns the only match reaching the min. confidence - nil if there is none, or if the match is ambiguous
+func bestMatch(matches []match, minConfidence float64) *VideoSearchResult {
+	if len(matches) == 0 || matches[0].confidence < minConfidence {
+		return nil
+	}
+	if len(matches) > 1 && matches[1].confidence >= minConfidence {
+		return nil
+	}
+	return matches[0].result
+}
+
+var nonAlphaNumeric = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+func normalizedTitle(title string) string {
+	return strings.TrimSpace(nonAlphaNumeric.ReplaceAllString(strings.ToLower(title), " "))
+}
+
+// yearOf returns the first year of e.g. "1999" or "2008–2013", or 0 if unknown
+func yearOf(year string) int {
+	if len(year) < 4 {
+		return 0
+	}
+	y, err := strconv.Atoi(year[:4])
+	if err != nil {
+		return 0
+	}
+	return y
+}
+
+// similarity of two strings based on their Levenshtein distance - from 0 (different) to 1 (equal)
+func similarity(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	if maxLen == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minOf(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return 1 - float64(prev[len(rb)])/float64(maxLen)
+}
+
+func minOf(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+var needsReviewLock = sync.Mutex{}
+
+// addToReview adds a target without confident match to the needs-review list, including the best candidates.
+// Each target is listed once - the entry of a target listed already is replaced.
+// Once reviewed, the correct id can be provided in the target's override file.
+func addToReview(reviewFile string, target string, title string, year string, matches []match) error {
+	candidates := []string{}
+	for i, m := range matches {
+		if i == 3 {
+			break
+		}
+		candidates = append(candidates, fmt.Sprintf("%s \"%s\" (%s) %.2f", m.result.Id, m.result.Title, m.result.Year, m.confidence))
+	}
+	if len(candidates) == 0 {
+		candidates = append(candidates, "none")
+	}
+	entry := fmt.Sprintf("%s\t\"%s\" (%s)\tcandidates: %s", target, title, year, strings.Join(candidates, ", "))
+
+	needsReviewLock.Lock()
+	defer needsReviewLock.Unlock()
+	lines := []string{}
+	raw, err := ioutil.ReadFile(reviewFile)
+	if err == nil {
+		lines = strings.Split(strings.TrimRight(string(raw), "\n"), "\n")
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	replaced := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, target+"\t") {
+			lines[i], replaced = entry, true
+		}
+	}
+	if !replaced {
+		lines = append(lines, entry)
+	}
+
+	if err := files.CreateFolderStructure(filepath.Dir(reviewFile)); err != nil {
+		return err
+	}
+	tmp := reviewFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, reviewFile)
+}
@@ -0,0 +1,74 @@
+package video
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+)
+
+func TestConfidence(t *testing.T) {
+	matrix := &VideoSearchResult{Id: "tt0133093", Title: "The Matrix", Year: "1999"}
+	t.Run("exact match", func(t *testing.T) {
+		test.AssertOn(t).True("expected full confidence")(confidence("the.matrix", "1999", matrix) == 1)
+	})
+	t.Run("unknown year is ignored", func(t *testing.T) {
+		test.AssertOn(t).True("expected full confidence")(confidence("The Matrix", "", matrix) == 1)
+	})
+	t.Run("year differs", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		assert.True("expected slightly lower confidence for adjacent year")(confidence("The Matrix", "2000", matrix) == 0.9)
+		assert.True("expected low confidence for other year")(confidence("The Matrix", "2003", matrix) == 0.5)
+	})
+	t.Run("title differs", func(t *testing.T) {
+		c := confidence("The Matrix", "", &VideoSearchResult{Title: "The Matrix Reloaded", Year: "2003"})
+		test.AssertOn(t).True("expected low confidence for different title")(c < 0.6)
+	})
+	t.Run("year ranges of series", func(t *testing.T) {
+		c := confidence("Breaking Bad", "2008", &VideoSearchResult{Title: "Breaking Bad", Year: "2008–2013"})
+		test.AssertOn(t).True("expected full confidence")(c == 1)
+	})
+}
+
+func TestBestMatch(t *testing.T) {
+	dune1984 := &VideoSearchResult{Id: "tt0087182", Title: "Dune", Year: "1984"}
+	dune2021 := &VideoSearchResult{Id: "tt1160419", Title: "Dune", Year: "2021"}
+	duneWorld := &VideoSearchResult{Id: "tt0000001", Title: "Dune World", Year: "2021"}
+
+	t.Run("single confident match", func(t *testing.T) {
+		best := bestMatch(rateMatches("Dune", "2021", []*VideoSearchResult{dune1984, dune2021, duneWorld}), 0.85)
+		test.AssertOn(t).StringsEqual(dune2021.Id, best.Id)
+	})
+	t.Run("ambiguous matches", func(t *testing.T) {
+		best := bestMatch(rateMatches("Dune", "", []*VideoSearchResult{dune1984, dune2021}), 0.85)
+		test.AssertOn(t).True("expected no match for ambiguous title")(best == nil)
+	})
+	t.Run("no confident match", func(t *testing.T) {
+		best := bestMatch(rateMatches("Dune", "2021", []*VideoSearchResult{duneWorld}), 0.85)
+		test.AssertOn(t).True("expected no match below min. confidence")(best == nil)
+	})
+	t.Run("no results", func(t *testing.T) {
+		test.AssertOn(t).True("expected no match")(bestMatch(rateMatches("Dune", "2021", nil), 0.85) == nil)
+	})
+}
+
+func TestAddToReview(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	reviewFile := filepath.Join(dir, "work", "needs-review.txt")
+
+	candidates := rateMatches("Dune", "", []*VideoSearchResult{{Id: "tt0087182", Title: "Dune", Year: "1984"}})
+	assert.NotError(addToReview(reviewFile, "/in/Dune/dune.avi", "Dune", "", candidates))
+	assert.NotError(addToReview(reviewFile, "/in/Other/other.avi", "Other", "2001", nil))
+	assert.NotError(addToReview(reviewFile, "/in/Dune/dune.avi", "Dune", "", candidates)) // re-runs do not list targets again
+
+	raw, err := ioutil.ReadFile(reviewFile)
+	assert.NotError(err)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	assert.IntsEqual(2, len(lines))
+	assert.True("expected candidate in review list")(strings.Contains(lines[0], "tt0087182"))
+	assert.True("expected no candidates in review list")(strings.HasSuffix(lines[1], "candidates: none"))
+}
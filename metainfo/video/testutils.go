@@ -9,11 +9,17 @@ import (
 type testVideoMetaInfoSource struct {
 	movie *MovieMetaInfo
 	series *SeriesMetaInfo
+	season *SeasonMetaInfo
 	episode *EpisodeMetaInfo
 	images map[string][]byte
 	movieFetched bool
 	seriesFetched bool
+	seasonFetched bool
 	episodeFetched bool
+	moreEpisodes []*EpisodeMetaInfo // episodes other than episode, e.g. of multi-episode files
+	episodesFetched []int
+	searchResults []*VideoSearchResult
+	searched bool
 	imagesFetched []string
 }
 
@@ -23,7 +29,7 @@ func (f *testVideoMetaInfoSource) FetchMovieInfo(id string) (*MovieMetaInfo, err
 	if f.movie == nil {
 		err = errors.New("test error - no movies defined")
 	} else if f.movie.Id != id {
-		err = errors.New("test error - movieTi not found")
+		err = &NotFound{"test error - movieTi not found"}
 	} else {
 		m = f.movie
 		f.movieFetched = true
@@ -37,7 +43,7 @@ func (f *testVideoMetaInfoSource) FetchSeriesInfo(id string) (*SeriesMetaInfo, e
 	if f.series == nil {
 		err = errors.New("test error - no series defined")
 	} else if f.series.Id != id {
-		err = errors.New("test error - series not found")
+		err = &NotFound{"test error - series not found"}
 	} else {
 		s = f.series
 		f.seriesFetched = true
@@ -45,19 +51,29 @@ func (f *testVideoMetaInfoSource) FetchSeriesInfo(id string) (*SeriesMetaInfo, e
 	return s, err
 }
 
+func (f *testVideoMetaInfoSource) FetchSeasonInfo(id string, season int) (*SeasonMetaInfo, error) {
+	if f.season == nil {
+		return nil, errors.New("test error - no season defined")
+	}
+	if f.season.Id != id || f.season.Season != season {
+		return nil, &NotFound{"test error - season not found"}
+	}
+	f.seasonFetched = true
+	return f.season, nil
+}
+
 func (f *testVideoMetaInfoSource) FetchEpisodeInfo(id string, season int, episode int) (*EpisodeMetaInfo, error) {
-	var e *EpisodeMetaInfo
-	var err error
 	if f.episode == nil {
-		err = errors.New("test error - no episodeTi defined")
-	} else if f.episode.Id != id || f.episode.Season != season || f.episode.Episode != episode {
-		err = errors.New("test error - episodeTi not found")
-	} else {
-		e = f.episode
-		f.episodeFetched = true
+		return nil, errors.New("test error - no episodeTi defined")
 	}
-	return e, err
-
+	for _, e := range append([]*EpisodeMetaInfo{f.episode}, f.moreEpisodes...) {
+		if e.Id == id && e.Season == season && e.Episode == episode {
+			f.episodeFetched = true
+			f.episodesFetched = append(f.episodesFetched, episode)
+			return e, nil
+		}
+	}
+	return nil, &NotFound{"test error - episodeTi not found"}
 }
 
 func (f *testVideoMetaInfoSource) FetchImage(location string) (metainfo.Image, error) {
@@ -79,6 +95,11 @@ func (f *testVideoMetaInfoSource) FetchImage(location string) (metainfo.Image, e
 
 }
 
+func (f *testVideoMetaInfoSource) SearchVideo(kind string, title string, year string) ([]*VideoSearchResult, error) {
+	f.searched = true
+	return f.searchResults, nil
+}
+
 func newVideoMetaInfoSource(movie *MovieMetaInfo, series *SeriesMetaInfo, episode *EpisodeMetaInfo, images map[string][]byte) *testVideoMetaInfoSource {
 	return &testVideoMetaInfoSource{movie: movie, series: series, episode: episode, images: images}
 }
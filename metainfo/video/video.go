@@ -1,32 +1,111 @@
 package video
 
 import (
+	"errors"
 	"path/filepath"
 	"fmt"
+	"strings"
+	"github.com/thomasschoeftner/go-cli/commons"
 	"github.com/thomasschoeftner/go-ripper/metainfo"
 )
 
 const (
 	SUBDIR_MOVIES = "movies"
 	SUBDIR_SERIES = "series"
+	SUBDIR_SEARCH = "search"
+)
+
+// kinds of videos to search for by title
+const (
+	SEARCH_KIND_MOVIE = "movie"
+	SEARCH_KIND_SERIES = "series"
 )
 
 var (
 	META_INFO_TYPE_MOVIE = "movieTi"
 	META_INFO_TYPE_SERIES = "series"
+	META_INFO_TYPE_SEASON = "season"
 	META_INFO_TYPE_EPISODE = "episodeTi"
+	META_INFO_TYPE_SEARCH = "search"
 )
 
+// NotFound is returned by meta-info sources if they do not know the requested video - in contrast to failures of the source itself
+type NotFound struct {
+	Msg string
+}
+
+func (nf *NotFound) Error() string {
+	return nf.Msg
+}
+
+// IsNotFound checks if an error denotes that the meta-info source does not know the requested video
+func IsNotFound(err error) bool {
+	var notFound *NotFound
+	return errors.As(err, &notFound)
+}
+
 type VideoMetaInfoSource interface {
 	FetchMovieInfo(id string) (*MovieMetaInfo, error)
 	FetchSeriesInfo(id string) (*SeriesMetaInfo, error)
+	FetchSeasonInfo(id string, season int) (*SeasonMetaInfo, error)
 	FetchEpisodeInfo(id string, season int, episode int) (*EpisodeMetaInfo, error)
 	FetchImage(location string) (metainfo.Image, error)
+	SearchVideo(kind string, title string, year string) ([]*VideoSearchResult, error)
 }
 
+// Details are the descriptive meta-info of movies, series and episodes - all fields are optional
+type Details struct {
+	Plot string
+	Genres []string
+	Actors []string
+	Directors []string
+	Writers []string
+	Rated string // age rating or certification, e.g. "PG-13"
+	Runtime int // in minutes
+	Languages []string
+	Countries []string
+	ImdbRating float64
+	DetailsResolved bool // set for all meta-info fetched with details, even if the source knows none - meta-info saved before details were resolved lacks it
+}
+
+// Or returns the details with all missing fields taken from other details (e.g. from the series of an episode)
+func (d Details) Or(other Details) Details {
+	if len(d.Plot) == 0 {
+		d.Plot = other.Plot
+	}
+	if len(d.Genres) == 0 {
+		d.Genres = other.Genres
+	}
+	if len(d.Actors) == 0 {
+		d.Actors = other.Actors
+	}
+	if len(d.Directors) == 0 {
+		d.Directors = other.Directors
+	}
+	if len(d.Writers) == 0 {
+		d.Writers = other.Writers
+	}
+	if len(d.Rated) == 0 {
+		d.Rated = other.Rated
+	}
+	if d.Runtime == 0 {
+		d.Runtime = other.Runtime
+	}
+	if len(d.Languages) == 0 {
+		d.Languages = other.Languages
+	}
+	if len(d.Countries) == 0 {
+		d.Countries = other.Countries
+	}
+	if d.ImdbRating == 0 {
+		d.ImdbRating = other.ImdbRating
+	}
+	return d
+}
 
 type MovieMetaInfo struct {
 	metainfo.IdInfo
+	Details
 	Title string
 	Year string
 	Poster string
@@ -37,6 +116,7 @@ func (m *MovieMetaInfo) GetType() string {
 
 type SeriesMetaInfo struct {
 	metainfo.IdInfo
+	Details
 	Title string
 	Seasons int
 	Year string
@@ -46,8 +126,37 @@ func (s *SeriesMetaInfo) GetType() string {
 	return META_INFO_TYPE_SERIES
 }
 
+// SeasonMetaInfo lists the episodes of a season of the series with the same id
+type SeasonMetaInfo struct {
+	metainfo.IdInfo
+	Season int
+	Poster string // empty if the season has no poster of its own
+	Episodes []*SeasonEpisode
+}
+func (s *SeasonMetaInfo) GetType() string {
+	return META_INFO_TYPE_SEASON
+}
+
+// EpisodeCount returns the total # of episodes of the season - episodes missing in the list (e.g. unknown to the source) are counted too
+func (s *SeasonMetaInfo) EpisodeCount() int {
+	count := len(s.Episodes)
+	for _, e := range s.Episodes {
+		if e.Episode > count {
+			count = e.Episode
+		}
+	}
+	return count
+}
+
+type SeasonEpisode struct {
+	Id string
+	Episode int
+	Title string
+}
+
 type EpisodeMetaInfo struct {
 	metainfo.IdInfo
+	Details
 	Title string
 	Episode int
 	Season int
@@ -57,6 +166,30 @@ func (e *EpisodeMetaInfo) GetType() string {
 	return META_INFO_TYPE_EPISODE
 }
 
+// SpecialPlaceholder is used for specials unknown to the meta-info source - its generic title can be replaced by overrides
+func SpecialPlaceholder(id string, season int, episode int) *EpisodeMetaInfo {
+	return &EpisodeMetaInfo{IdInfo: metainfo.IdInfo{Id: id}, Title: fmt.Sprintf("Special %d", episode), Season: season, Episode: episode}
+}
+
+// VideoSearchResult is a candidate found when looking up the id of a video by title
+type VideoSearchResult struct {
+	Id string
+	Title string
+	Year string
+}
+
+// SearchMetaInfo contains all candidates found for a title and year
+type SearchMetaInfo struct {
+	metainfo.IdInfo
+	Kind string
+	Title string
+	Year string
+	Results []*VideoSearchResult
+}
+func (s *SearchMetaInfo) GetType() string {
+	return META_INFO_TYPE_SEARCH
+}
+
 
 func MovieFileName(repoPath string, id string) string {
 	return filepath.ToSlash(filepath.Join(repoPath, SUBDIR_MOVIES, fmt.Sprintf("%s.%s", id, metainfo.METAINF_FILE_EXT)))
@@ -66,6 +199,20 @@ func SeriesFileName(repoPath string, id string) string {
 	return filepath.ToSlash(filepath.Join(repoPath, SUBDIR_SERIES, fmt.Sprintf("%s.%s", id, metainfo.METAINF_FILE_EXT)))
 }
 
+func SearchFileName(repoPath string, kind string, title string, year string) string {
+	query := fmt.Sprintf("%s|%s", strings.ToLower(title), year)
+	return filepath.ToSlash(filepath.Join(repoPath, SUBDIR_SEARCH, fmt.Sprintf("%s-%08x.%s", kind, commons.Hash32(query), metainfo.METAINF_FILE_EXT)))
+}
+
+func SeasonFileName(repoPath string, id string, season int) string {
+	return filepath.ToSlash(filepath.Join(repoPath, SUBDIR_SERIES, fmt.Sprintf("%s.%d.%s", id, season, metainfo.METAINF_FILE_EXT)))
+}
+
+// SeasonPosterId returns the id under which the poster of a season is stored in the meta-info repo
+func SeasonPosterId(id string, season int) string {
+	return fmt.Sprintf("%s.%d", id, season)
+}
+
 func EpisodeFileName(repoPath string, id string, season int, episode int) string {
 	return filepath.ToSlash(filepath.Join(repoPath, SUBDIR_SERIES, fmt.Sprintf("%s.%d.%d.%s", id, season, episode, metainfo.METAINF_FILE_EXT)))
 }
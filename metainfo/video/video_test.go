@@ -11,9 +11,17 @@ func TestGetType(t *testing.T) {
 	assert := test.AssertOn(t)
 	assert.StringsEqual(META_INFO_TYPE_MOVIE, (&MovieMetaInfo{}).GetType())
 	assert.StringsEqual(META_INFO_TYPE_SERIES, (&SeriesMetaInfo{}).GetType())
+	assert.StringsEqual(META_INFO_TYPE_SEASON, (&SeasonMetaInfo{}).GetType())
 	assert.StringsEqual(META_INFO_TYPE_EPISODE, (&EpisodeMetaInfo{}).GetType())
 }
 
+func TestEpisodeCount(t *testing.T) {
+	assert := test.AssertOn(t)
+	assert.IntsEqual(0, (&SeasonMetaInfo{}).EpisodeCount())
+	assert.IntsEqual(2, (&SeasonMetaInfo{Episodes: []*SeasonEpisode{{Episode: 1}, {Episode: 2}}}).EpisodeCount())
+	assert.IntsEqual(5, (&SeasonMetaInfo{Episodes: []*SeasonEpisode{{Episode: 1}, {Episode: 5}}}).EpisodeCount()) // unknown episodes in between
+}
+
 func TestGetFileNames(t *testing.T) {
 	dir := "a/b/c"
 	id := "tt678"
@@ -30,6 +38,11 @@ func TestGetFileNames(t *testing.T) {
 		test.AssertOn(t).StringsEqual(expected, fName)
 	})
 
+	t.Run("season file name", func(t *testing.T) {
+		expected := fmt.Sprintf("%s/%s/%s.%d.%s", dir, SUBDIR_SERIES, id, 2, metainfo.METAINF_FILE_EXT)
+		test.AssertOn(t).StringsEqual(expected, SeasonFileName(dir, id, 2))
+	})
+
 	t.Run("episode file name", func(t *testing.T) {
 		season := 7
 		episode := 21
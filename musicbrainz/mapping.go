@@ -0,0 +1,88 @@
+package musicbrainz
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+	"github.com/thomasschoeftner/go-ripper/metainfo/audio"
+)
+
+type artistCredit struct {
+	Name       string `json:"name"`
+	JoinPhrase string `json:"joinphrase"`
+}
+
+type artistCredits []artistCredit
+
+func (ac artistCredits) String() string {
+	sb := strings.Builder{}
+	for _, credit := range ac {
+		sb.WriteString(credit.Name)
+		sb.WriteString(credit.JoinPhrase)
+	}
+	return sb.String()
+}
+
+type mbTrack struct {
+	Id           string        `json:"id"`
+	Position     int           `json:"position"`
+	Title        string        `json:"title"`
+	ArtistCredit artistCredits `json:"artist-credit"`
+}
+
+type mbMedium struct {
+	Position int       `json:"position"`
+	Tracks   []mbTrack `json:"tracks"`
+}
+
+type mbRelease struct {
+	Id              string        `json:"id"`
+	Title           string        `json:"title"`
+	Date            string        `json:"date"`
+	ArtistCredit    artistCredits `json:"artist-credit"`
+	Media           []mbMedium    `json:"media"`
+	CoverArtArchive struct {
+		Front bool `json:"front"`
+	} `json:"cover-art-archive"`
+}
+
+func toAlbumMetaInfo(raw []byte, coverLocation string) (*audio.AlbumMetaInfo, error) {
+	release := mbRelease{}
+	if err := json.Unmarshal(raw, &release); err != nil {
+		return nil, err
+	}
+	if len(release.Id) == 0 {
+		return nil, fmt.Errorf("mapping musicbrainz-response to album meta-info failed: release id is missing")
+	}
+
+	album := &audio.AlbumMetaInfo{
+		IdInfo: metainfo.IdInfo{Id: release.Id},
+		Title:  release.Title,
+		Artist: release.ArtistCredit.String(),
+		Discs:  len(release.Media),
+	}
+	if len(release.Date) >= 4 {
+		album.Year = release.Date[:4]
+	}
+	if release.CoverArtArchive.Front {
+		album.Poster = coverLocation
+	}
+
+	for _, medium := range release.Media {
+		for _, t := range medium.Tracks {
+			artist := t.ArtistCredit.String()
+			if len(artist) == 0 {
+				artist = album.Artist
+			}
+			album.Tracks = append(album.Tracks, &audio.TrackMetaInfo{
+				IdInfo: metainfo.IdInfo{Id: t.Id},
+				Title:  t.Title,
+				Artist: artist,
+				Disc:   medium.Position,
+				Track:  t.Position})
+		}
+	}
+	return album, nil
+}
@@ -0,0 +1,55 @@
+package musicbrainz
+
+import (
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+)
+
+const releaseJson = `
+{
+  "id" : "b1392450-e666-3926-a536-22c65f834433",
+  "title" : "OK Computer",
+  "date" : "1997-05-21",
+  "artist-credit" : [{"name" : "Radiohead", "joinphrase" : ""}],
+  "cover-art-archive" : {"front" : true},
+  "media" : [
+    {"position" : 1, "tracks" : [
+      {"id" : "t1", "position" : 1, "title" : "Airbag"},
+      {"id" : "t2", "position" : 2, "title" : "Paranoid Android", "artist-credit" : [{"name" : "Thom", "joinphrase" : " & "}, {"name" : "Jonny", "joinphrase" : ""}]}]},
+    {"position" : 2, "tracks" : [
+      {"id" : "t3", "position" : 1, "title" : "Polyethylene"}]}]
+}`
+
+func TestAlbumMapping(t *testing.T) {
+	t.Run("valid release", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		album, err := toAlbumMetaInfo([]byte(releaseJson), "http://cover/front")
+		assert.NotError(err)
+		assert.StringsEqual("b1392450-e666-3926-a536-22c65f834433", album.Id)
+		assert.StringsEqual("OK Computer", album.Title)
+		assert.StringsEqual("Radiohead", album.Artist)
+		assert.StringsEqual("1997", album.Year)
+		assert.StringsEqual("http://cover/front", album.Poster)
+		assert.IntsEqual(2, album.Discs)
+		assert.IntsEqual(3, len(album.Tracks))
+		assert.IntsEqual(2, album.TracksOnDisc(1))
+
+		track := album.Track(1, 2)
+		assert.StringsEqual("Paranoid Android", track.Title)
+		assert.StringsEqual("Thom & Jonny", track.Artist)
+		assert.StringsEqual("Radiohead", album.Track(2, 1).Artist)
+	})
+
+	t.Run("no cover art", func(t *testing.T) {
+		album, err := toAlbumMetaInfo([]byte(`{"id" : "x", "cover-art-archive" : {"front" : false}}`), "http://cover/front")
+		assert := test.AssertOn(t)
+		assert.NotError(err)
+		assert.StringsEqual("", album.Poster)
+	})
+
+	t.Run("missing id", func(t *testing.T) {
+		_, err := toAlbumMetaInfo([]byte(`{"title" : "x"}`), "")
+		test.AssertOn(t).ExpectError("expected error when mapping release without id, but got none")(err)
+	})
+}
@@ -0,0 +1,94 @@
+package musicbrainz
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+	"github.com/thomasschoeftner/go-ripper/metainfo/audio"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+const (
+	urlpattern_mbid = "mbid"
+)
+
+const CONF_MUSICBRAINZ_RESOLVER = "musicbrainz"
+
+func NewMusicBrainzAudioMetaInfoSource(conf *ripper.AudioResolveConfig) (audio.AudioMetaInfoSource, error) {
+	if conf == nil || conf.MusicBrainz == nil {
+		return nil, errors.New("cannot initialize musicbrainz meta-info source without MusicBrainzConfig")
+	}
+	if len(strings.TrimSpace(conf.MusicBrainz.UserAgent)) == 0 {
+		return nil, errors.New("cannot initialize musicbrainz meta-info source without user-agent (required by musicbrainz API)")
+	}
+	httpClient := &http.Client{Timeout: time.Second * time.Duration(conf.MusicBrainz.Timeout)}
+	return &musicBrainzAudioMetaInfoSource{conf: conf.MusicBrainz, httpClient: httpClient}, nil
+}
+
+type musicBrainzAudioMetaInfoSource struct {
+	conf       *ripper.MusicBrainzConfig
+	httpClient *http.Client
+}
+
+func (mb *musicBrainzAudioMetaInfoSource) FetchAlbumInfo(id string) (*audio.AlbumMetaInfo, error) {
+	raw, err := mb.getWithRetries(replaceUrlVars(mb.conf.AlbumQuery, map[string]string{urlpattern_mbid: id}))
+	if err != nil {
+		return nil, err
+	}
+	return toAlbumMetaInfo(raw, replaceUrlVars(mb.conf.CoverQuery, map[string]string{urlpattern_mbid: id}))
+}
+
+func (mb *musicBrainzAudioMetaInfoSource) FetchImage(location string) (metainfo.Image, error) {
+	return mb.getWithRetries(location)
+}
+
+func (mb *musicBrainzAudioMetaInfoSource) getWithRetries(url string) ([]byte, error) {
+	var errs []error
+	for i := 0; i <= mb.conf.Retries; i++ {
+		raw, err := mb.get(url)
+		if err == nil {
+			return raw, nil
+		}
+		errs = append(errs, err)
+	}
+
+	errMsg := fmt.Sprintf("unable to resolve meta-info after %d tries due to: \n", mb.conf.Retries+1)
+	for _, err := range errs {
+		errMsg = fmt.Sprintf("%s   -%s\n", errMsg, err.Error())
+	}
+	return nil, errors.New(errMsg)
+}
+
+func (mb *musicBrainzAudioMetaInfoSource) get(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", mb.conf.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	httpRsp, err := mb.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRsp.Body.Close()
+
+	if httpRsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received unexpected response code %d when getting %s", httpRsp.StatusCode, url)
+	}
+	return ioutil.ReadAll(httpRsp.Body)
+}
+
+func replaceUrlVars(template string, keyVals map[string]string) string {
+	result := template
+	for variable, value := range keyVals {
+		varPlaceholder := fmt.Sprintf("{%s}", variable)
+		result = strings.Replace(result, varPlaceholder, value, -1)
+	}
+	return result
+}
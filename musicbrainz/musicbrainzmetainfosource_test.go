@@ -0,0 +1,71 @@
+package musicbrainz
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+func conf(baseUrl string) *ripper.AudioResolveConfig {
+	return &ripper.AudioResolveConfig{
+		Resolver: CONF_MUSICBRAINZ_RESOLVER,
+		MusicBrainz: &ripper.MusicBrainzConfig{
+			Timeout:    1,
+			Retries:    1,
+			AlbumQuery: baseUrl + "/release/{mbid}",
+			CoverQuery: baseUrl + "/cover/{mbid}",
+			UserAgent:  "go-ripper-test"},
+	}
+}
+
+func TestNewMusicBrainzAudioMetaInfoSource(t *testing.T) {
+	t.Run("nil config", func(t *testing.T) {
+		_, err := NewMusicBrainzAudioMetaInfoSource(nil)
+		test.AssertOn(t).ExpectError("expected error for nil config, but got none")(err)
+	})
+
+	t.Run("missing user-agent", func(t *testing.T) {
+		c := conf("")
+		c.MusicBrainz.UserAgent = ""
+		_, err := NewMusicBrainzAudioMetaInfoSource(c)
+		test.AssertOn(t).ExpectError("expected error for missing user-agent, but got none")(err)
+	})
+}
+
+func TestFetchAlbumInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "go-ripper-test" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/release/b1392450-e666-3926-a536-22c65f834433":
+			fmt.Fprint(w, releaseJson)
+		case "/cover/b1392450-e666-3926-a536-22c65f834433":
+			w.Write([]byte{1, 2, 3})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	assert := test.AssertOn(t)
+	src, err := NewMusicBrainzAudioMetaInfoSource(conf(server.URL))
+	assert.NotError(err)
+
+	album, err := src.FetchAlbumInfo("b1392450-e666-3926-a536-22c65f834433")
+	assert.NotError(err)
+	assert.StringsEqual("OK Computer", album.Title)
+	assert.StringsEqual(server.URL+"/cover/b1392450-e666-3926-a536-22c65f834433", album.Poster)
+
+	img, err := src.FetchImage(album.Poster)
+	assert.NotError(err)
+	assert.IntsEqual(3, len(img))
+
+	_, err = src.FetchAlbumInfo("unknown")
+	assert.ExpectError("expected error for unknown release, but got none")(err)
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"fmt"
 	"strconv"
+	"regexp"
+	"github.com/thomasschoeftner/go-cli/commons"
 	"github.com/thomasschoeftner/go-ripper/metainfo/video"
 )
 
@@ -17,8 +19,22 @@ const (
 	omdb_season  = "season"
 	omdb_episode = "episode"
 	omdb_type    = "type"
+
+	omdb_plot       = "plot"
+	omdb_genre      = "genre"
+	omdb_actors     = "actors"
+	omdb_director   = "director"
+	omdb_writer     = "writer"
+	omdb_rated      = "rated"
+	omdb_runtime    = "runtime"
+	omdb_language   = "language"
+	omdb_country    = "country"
+	omdb_imdbrating = "imdbrating"
 )
 
+// omdb's value of unknown fields
+const omdb_notAvailable = "N/A"
+
 const (
 	omdb_type_movie   = "movie"
 	omdb_type_series  = "series"
@@ -64,6 +80,7 @@ func toMovieMetaInfo(raw []byte) (*video.MovieMetaInfo, error) {
 	if err := assignString(&movie.Year, values, omdb_year); err != nil {
 		return nil, err
 	}
+	movie.Details = toDetails(values)
 	return movie, nil
 }
 
@@ -94,6 +111,7 @@ func toSeriesMetaInfo(raw []byte) (*video.SeriesMetaInfo, error) {
 	if err := assignInt(&series.Seasons, values, omdb_seasons); err != nil {
 		return nil, err
 	}
+	series.Details = toDetails(values)
 	return series, nil
 }
 
@@ -124,9 +142,107 @@ func toEpisodeMetaInfo(raw []byte) (*video.EpisodeMetaInfo, error) {
 	if err := assignInt(&episode.Episode, values, omdb_episode); err != nil {
 		return nil, err
 	}
+	episode.Details = toDetails(values)
 	return episode, nil
 }
 
+type omdbSeasonResponse struct {
+	Season   string
+	Episodes []struct {
+		Title   string
+		Episode string
+		ImdbID  string
+	}
+}
+
+// toSeasonMetaInfo maps the episode list of a season - the response does not contain the id of the series
+func toSeasonMetaInfo(raw []byte, id string) (*video.SeasonMetaInfo, error) {
+	rsp := omdbSeasonResponse{}
+	if err := json.Unmarshal(raw, &rsp); err != nil {
+		return nil, err
+	}
+	season, err := strconv.Atoi(rsp.Season)
+	if err != nil {
+		return nil, fmt.Errorf("mapping omdb-response to season meta-info failed: invalid season \"%s\"", rsp.Season)
+	}
+	seasonMi := &video.SeasonMetaInfo{Season: season, Episodes: []*video.SeasonEpisode{}}
+	seasonMi.Id = id
+	for _, e := range rsp.Episodes {
+		episode, err := strconv.Atoi(e.Episode)
+		if err != nil {
+			return nil, fmt.Errorf("mapping omdb-response to season meta-info failed: invalid episode \"%s\"", e.Episode)
+		}
+		seasonMi.Episodes = append(seasonMi.Episodes, &video.SeasonEpisode{Id: e.ImdbID, Episode: episode, Title: e.Title})
+	}
+	return seasonMi, nil
+}
+
+// toDetails maps the optional descriptive fields - missing, unavailable and malformed values are left empty
+func toDetails(values map[string]string) video.Details {
+	details := video.Details{
+		Plot:      optionalString(values, omdb_plot),
+		Genres:    optionalList(values, omdb_genre),
+		Actors:    optionalList(values, omdb_actors),
+		Directors: optionalList(values, omdb_director),
+		Writers:   optionalList(values, omdb_writer),
+		Rated:     optionalString(values, omdb_rated),
+		Languages: optionalList(values, omdb_language),
+		Countries: optionalList(values, omdb_country),
+	}
+	if runtime := strings.Fields(optionalString(values, omdb_runtime)); len(runtime) > 0 { // e.g. "142 min"
+		details.Runtime, _ = strconv.Atoi(runtime[0])
+	}
+	details.ImdbRating, _ = strconv.ParseFloat(optionalString(values, omdb_imdbrating), 64)
+	return details
+}
+
+func optionalString(values map[string]string, key string) string {
+	val := strings.TrimSpace(values[key])
+	if val == omdb_notAvailable {
+		return ""
+	}
+	return val
+}
+
+// role of people in lists, e.g. "Jonathan Nolan (screenplay)"
+var omdbRole = regexp.MustCompile(`\s*\([^)]*\)$`)
+
+// optionalList splits comma-separated values and removes roles and duplicates, e.g. "J. Nolan (screenplay), J. Nolan (story)"
+func optionalList(values map[string]string, key string) []string {
+	val := optionalString(values, key)
+	if len(val) == 0 {
+		return nil
+	}
+	list := []string{}
+	for _, elem := range strings.Split(val, ",") {
+		elem = omdbRole.ReplaceAllString(strings.TrimSpace(elem), "")
+		if len(elem) > 0 && !commons.IsStringAmong(elem, list) {
+			list = append(list, elem)
+		}
+	}
+	return list
+}
+
+type omdbSearchResponse struct {
+	Search []struct {
+		Title  string
+		Year   string
+		ImdbID string
+	}
+}
+
+func toSearchResults(raw []byte) ([]*video.VideoSearchResult, error) {
+	rsp := omdbSearchResponse{}
+	if err := json.Unmarshal(raw, &rsp); err != nil {
+		return nil, err
+	}
+	results := []*video.VideoSearchResult{}
+	for _, r := range rsp.Search {
+		results = append(results, &video.VideoSearchResult{Id: r.ImdbID, Title: r.Title, Year: r.Year})
+	}
+	return results, nil
+}
+
 func assignString(target *string, values map[string]string, key string) error {
 	if val, defined := values[key]; defined {
 		*target = val
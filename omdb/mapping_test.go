@@ -82,6 +82,55 @@ func TestMovieMapping(t *testing.T) {
 	})
 }
 
+func TestDetailsMapping(t *testing.T) {
+	assert := test.AssertOn(t)
+	raw := []byte(`{
+		"Title": "The Dark Knight", "Year": "2008", "Poster": "N/A", "imdbID": "tt0468569", "Type": "movie",
+		"Plot": "Batman faces the Joker.", "Genre": "Action, Crime, Drama", "Actors": "Christian Bale, Heath Ledger",
+		"Director": "Christopher Nolan", "Writer": "Jonathan Nolan (screenplay), Christopher Nolan (screenplay), Christopher Nolan (story)",
+		"Rated": "PG-13", "Runtime": "152 min", "Language": "English, Mandarin", "Country": "N/A", "imdbRating": "9.0"
+	}`)
+	got, err := toMovieMetaInfo(raw)
+	assert.NotError(err)
+	assert.StringsEqual("Batman faces the Joker.", got.Plot)
+	assert.StringSlicesEqual([]string{"Action", "Crime", "Drama"}, got.Genres)
+	assert.StringSlicesEqual([]string{"Christian Bale", "Heath Ledger"}, got.Actors)
+	assert.StringSlicesEqual([]string{"Christopher Nolan"}, got.Directors)
+	assert.StringSlicesEqual([]string{"Jonathan Nolan", "Christopher Nolan"}, got.Writers)
+	assert.StringsEqual("PG-13", got.Rated)
+	assert.IntsEqual(152, got.Runtime)
+	assert.StringSlicesEqual([]string{"English", "Mandarin"}, got.Languages)
+	assert.IntsEqual(0, len(got.Countries))
+	assert.True("expected imdb rating 9.0")(got.ImdbRating == 9.0)
+
+	t.Run("missing and unavailable details are left empty", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		got, err := toMovieMetaInfo([]byte(`{"Title": "t", "Year": "2012", "Poster": "p", "imdbID": "tt1", "Type": "movie", "Runtime": "N/A", "imdbRating": "N/A"}`))
+		assert.NotError(err)
+		assert.StringsEqual("", got.Plot)
+		assert.IntsEqual(0, len(got.Genres))
+		assert.IntsEqual(0, got.Runtime)
+		assert.True("expected no imdb rating")(got.ImdbRating == 0)
+	})
+}
+
+func TestSeasonMapping(t *testing.T) {
+	assert := test.AssertOn(t)
+	raw := []byte(`{"Title": "Game of Thrones", "Season": "1", "totalSeasons": "8", "Response": "True", "Episodes": [
+		{"Title": "Winter Is Coming", "Released": "2011-04-17", "Episode": "1", "imdbRating": "8.9", "imdbID": "tt1480055"},
+		{"Title": "The Kingsroad", "Released": "2011-04-24", "Episode": "2", "imdbRating": "8.6", "imdbID": "tt1668746"}]}`)
+	got, err := toSeasonMetaInfo(raw, "tt0944947")
+	assert.NotError(err)
+	assert.StringsEqual("tt0944947", got.Id)
+	assert.IntsEqual(1, got.Season)
+	assert.IntsEqual(2, got.EpisodeCount())
+	assert.StringsEqual("The Kingsroad", got.Episodes[1].Title)
+	assert.StringsEqual("tt1668746", got.Episodes[1].Id)
+
+	_, err = toSeasonMetaInfo([]byte(`{"Season": "one"}`), "tt0944947")
+	assert.ExpectError("expected error when mapping invalid season")(err)
+}
+
 func TestSeriesMapping(t *testing.T) {
 	vals := map[string]string {
 		"title" : "another story",
@@ -206,3 +255,21 @@ func TestEpisodeMapping(t *testing.T) {
 		}
 	})
 }
+
+func TestSearchMapping(t *testing.T) {
+	assert := test.AssertOn(t)
+	raw := []byte(`{"Search":[{"Title":"The Matrix","Year":"1999","imdbID":"tt0133093","Type":"movie","Poster":"N/A"},{"Title":"The Matrix Reloaded","Year":"2003","imdbID":"tt0234215","Type":"movie"}],"totalResults":"2","Response":"True"}`)
+	got, err := toSearchResults(raw)
+	assert.NotError(err)
+	assert.IntsEqual(2, len(got))
+	assert.StringsEqual("tt0133093", got[0].Id)
+	assert.StringsEqual("The Matrix", got[0].Title)
+	assert.StringsEqual("1999", got[0].Year)
+
+	notFound := []byte(`{"Response":"False","Error":"Movie not found!"}`)
+	assert.NotError(validateOmdbSearchResponse(notFound))
+	got, err = toSearchResults(notFound)
+	assert.NotError(err)
+	assert.IntsEqual(0, len(got))
+	assert.ExpectError("expected error for failed search")(validateOmdbSearchResponse([]byte(`{"Response":"False","Error":"Too many results."}`)))
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"strconv"
 	"net/http"
+	"net/url"
 	"io/ioutil"
 	"time"
 	"encoding/json"
@@ -19,6 +20,9 @@ const (
 	urlpattern_imdbid    = "imdbid"
 	urlpattern_season    = "seasonNo"
 	urlpattern_episode   = "episodeNo"
+	urlpattern_title     = "title"
+	urlpattern_year      = "year"
+	urlpattern_type      = "type"
 )
 
 const CONF_OMDB_RESOLVER = "omdb"
@@ -71,6 +75,23 @@ func (omdb *omdbVideoMetaInfoSource) FetchSeriesInfo(id string) (*video.SeriesMe
 	return toSeriesMetaInfo(raw)
 }
 
+// FetchSeasonInfo fetches the episode list of a season - omdb does not provide posters of seasons
+func (omdb *omdbVideoMetaInfoSource) FetchSeasonInfo(id string, season int) (*video.SeasonMetaInfo, error) {
+	if len(omdb.conf.SeasonQuery) == 0 {
+		return nil, errors.New("omdb season query is not configured")
+	}
+	raw, err := httpGet(omdb.httpClient).WithValidation(validateOmdbResponse).WithRetries(omdb.conf.Retries)(func() string {
+		return replaceUrlVars(omdb.conf.SeasonQuery, map[string]string{
+			urlpattern_omdbtoken: omdb.nextToken(),
+			urlpattern_imdbid:    id,
+			urlpattern_season:    strconv.Itoa(season)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toSeasonMetaInfo(raw, id)
+}
+
 func (omdb *omdbVideoMetaInfoSource) FetchEpisodeInfo(id string, season int, episode int) (*video.EpisodeMetaInfo, error) {
 	raw, err := httpGet(omdb.httpClient).WithValidation(validateOmdbResponse).WithRetries(omdb.conf.Retries)(func() string {
 		return replaceUrlVars(omdb.conf.EpisodeQuery, map[string]string{
@@ -85,6 +106,24 @@ func (omdb *omdbVideoMetaInfoSource) FetchEpisodeInfo(id string, season int, epi
 	return toEpisodeMetaInfo(raw)
 }
 
+// SearchVideo looks up movies or series by title (and year, if known) - no results are no error
+func (omdb *omdbVideoMetaInfoSource) SearchVideo(kind string, title string, year string) ([]*video.VideoSearchResult, error) {
+	if len(omdb.conf.SearchQuery) == 0 {
+		return nil, errors.New("omdb search query is not configured")
+	}
+	raw, err := httpGet(omdb.httpClient).WithValidation(validateOmdbSearchResponse).WithRetries(omdb.conf.Retries)(func() string {
+		return replaceUrlVars(omdb.conf.SearchQuery, map[string]string{
+			urlpattern_omdbtoken: omdb.nextToken(),
+			urlpattern_title:     url.QueryEscape(title),
+			urlpattern_year:      year,
+			urlpattern_type:      kind})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toSearchResults(raw)
+}
+
 func (omdb *omdbVideoMetaInfoSource) FetchImage(location string) (metainfo.Image, error) {
 	return httpGet(omdb.httpClient).WithRetries(omdb.conf.Retries)(func() string {
 		return location
@@ -138,12 +177,18 @@ func (hgf httpGetFunc) WithRetries(retries int) httpGetFunc {
 		if e == nil {
 			return v, nil
 		}
+		if video.IsNotFound(e) {
+			return nil, e // retrying does not help
+		}
 		errs = append(errs, e)
 		for i:=0; i<retries; i++ {
 			v, e = hgf(url)
 			if e == nil {
 				return v, nil
 			}
+			if video.IsNotFound(e) {
+				return nil, e
+			}
 			errs = append(errs, e)
 		}
 		errMsg := fmt.Sprintf("unable to resolve meta-info after %d tries due to: \n", retries + 1)
@@ -176,11 +221,23 @@ func validateOmdbResponse(raw []byte) error {
 	}
 
 	if strings.ToLower(status.Response) == "false" {
+		if strings.Contains(strings.ToLower(status.Error), "not found") {
+			return &video.NotFound{Msg: status.Error}
+		}
 		return fmt.Errorf("%s", status.Error)
 	}
 	return nil
 }
 
+// searches without results are valid responses
+func validateOmdbSearchResponse(raw []byte) error {
+	err := validateOmdbResponse(raw)
+	if video.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
 type basicOmdbResponse struct {
 	Response string
 	Error string
@@ -0,0 +1,97 @@
+package override
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-ripper/files"
+)
+
+// name of the folder-level override file - applies to all items in the same folder
+const FolderOverrideFileName = "ripper.json"
+
+const overrideFileExtension = "json"
+
+// keys which only apply to a single item - they are rejected in the folder-level override file
+var itemKeys = []string{"episode", "lastEpisode", "title", "fileName"}
+
+// Overrides contains user-provided values which replace scanned and resolved meta-info of an item.
+// Empty fields do not override anything.
+type Overrides struct {
+	Id          string `json:"id,omitempty"`
+	Season      *int   `json:"season,omitempty"`
+	Episode     *int   `json:"episode,omitempty"`
+	LastEpisode *int   `json:"lastEpisode,omitempty"` // last episode of multi-episode files
+	Title       string `json:"title,omitempty"`       // movie or episode title
+	Series      string `json:"series,omitempty"`      // series title of episodes
+	Year        string `json:"year,omitempty"`
+	Poster      string `json:"poster,omitempty"`   // poster url of movie or series
+	FileName    string `json:"fileName,omitempty"` // output file name without extension
+}
+
+// ForFile reads the overrides for an input file from <item>.json and ripper.json in the same folder.
+// Values in <item>.json take precedence over the folder-level ripper.json, which must not contain item-specific values (e.g. fileName).
+// If neither of them exists, empty overrides are returned.
+func ForFile(path string) (*Overrides, error) {
+	folder, file := filepath.Split(path)
+	name, _ := files.SplitExtension(file)
+
+	o := &Overrides{}
+	if err := o.readFrom(filepath.Join(folder, FolderOverrideFileName), false); err != nil {
+		return nil, err
+	}
+	if err := o.readFrom(filepath.Join(folder, files.WithExtension(name, overrideFileExtension)), true); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// readFrom merges the values of an override file over the current values
+func (o *Overrides) readFrom(file string, isItemFile bool) error {
+	raw, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !isItemFile {
+		keys := map[string]json.RawMessage{}
+		if err := json.Unmarshal(raw, &keys); err != nil {
+			return fmt.Errorf("invalid override file %s: %v", file, err)
+		}
+		for key := range keys {
+			for _, itemKey := range itemKeys {
+				if strings.EqualFold(key, itemKey) { // keys are matched case-insensitively when decoding
+					return fmt.Errorf("invalid override file %s: \"%s\" applies to single items only - move it to <item>.%s", file, key, overrideFileExtension)
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(raw, o); err != nil {
+		return fmt.Errorf("invalid override file %s: %v", file, err)
+	}
+	return nil
+}
+
+// Or returns the override value if set, or the original value otherwise
+func Or(override string, original string) string {
+	if len(override) > 0 {
+		return override
+	}
+	return original
+}
+
+// PosterId returns the id under which the poster image is stored in the meta-info repo.
+// Overridden posters are stored separately, so they neither replace, nor get replaced by the resolved poster.
+func (o *Overrides) PosterId(id string) string {
+	if len(o.Poster) == 0 {
+		return id
+	}
+	return fmt.Sprintf("%s-%08x", id, commons.Hash32(o.Poster))
+}
@@ -0,0 +1,86 @@
+package override
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	test.CheckError(t, ioutil.WriteFile(path, []byte(content), os.ModePerm))
+}
+
+func TestForFile(t *testing.T) {
+	t.Run("no override files", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+
+		o, err := ForFile(filepath.Join(dir, "movie.avi"))
+		assert.NotError(err)
+		assert.StringsEqual("", o.Id)
+		assert.True("expected no season override")(o.Season == nil)
+		assert.StringsEqual("id", o.PosterId("id"))
+	})
+
+	t.Run("item overrides take precedence over folder overrides", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		writeFile(t, filepath.Join(dir, FolderOverrideFileName), `{"id": "tt0001", "series": "Series", "season": 2}`)
+		writeFile(t, filepath.Join(dir, "ep.json"), `{"id": "tt0002", "episode": 5, "title": "Pilot"}`)
+
+		o, err := ForFile(filepath.Join(dir, "ep.avi"))
+		assert.NotError(err)
+		assert.StringsEqual("tt0002", o.Id)
+		assert.StringsEqual("Series", o.Series)
+		assert.StringsEqual("Pilot", o.Title)
+		assert.IntsEqual(2, *o.Season)
+		assert.IntsEqual(5, *o.Episode)
+
+		other, err := ForFile(filepath.Join(dir, "other.avi"))
+		assert.NotError(err)
+		assert.StringsEqual("tt0001", other.Id)
+		assert.StringsEqual("", other.Title)
+		assert.True("expected no episode override")(other.Episode == nil)
+	})
+
+	t.Run("reject item-specific values in folder overrides", func(t *testing.T) {
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		for _, content := range []string{`{"season": 2, "fileName": "same for all"}`, `{"episode": 1}`, `{"title": "Pilot"}`, `{"Episode": 1}`, `{"FILENAME": "same for all"}`} {
+			writeFile(t, filepath.Join(dir, FolderOverrideFileName), content)
+			_, err := ForFile(filepath.Join(dir, "ep.avi"))
+			test.AssertOn(t).ExpectError("expected error for item-specific value in " + content)(err)
+		}
+	})
+
+	t.Run("invalid override file", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		writeFile(t, filepath.Join(dir, "movie.json"), `{"title": `)
+
+		_, err := ForFile(filepath.Join(dir, "movie.avi"))
+		assert.ExpectError("expected error for invalid override file")(err)
+	})
+}
+
+func TestPosterId(t *testing.T) {
+	assert := test.AssertOn(t)
+	o := &Overrides{Poster: "http://a/poster.jpg"}
+	id := o.PosterId("tt0001")
+	assert.False("expected overridden poster to be stored under different id")(id == "tt0001")
+	assert.StringsEqual(id, o.PosterId("tt0001"))
+	other := &Overrides{Poster: "http://a/other.jpg"}
+	assert.False("expected different posters to be stored under different ids")(id == other.PosterId("tt0001"))
+}
+
+func TestOr(t *testing.T) {
+	assert := test.AssertOn(t)
+	assert.StringsEqual("original", Or("", "original"))
+	assert.StringsEqual("override", Or("override", "original"))
+}
@@ -2,11 +2,14 @@ package processor
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/thomasschoeftner/go-cli/commons"
 	"github.com/thomasschoeftner/go-cli/task"
 	"github.com/thomasschoeftner/go-ripper/files"
 	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/shutdown"
 	"github.com/thomasschoeftner/go-ripper/targetinfo"
 )
 
@@ -15,10 +18,41 @@ type Processor func(ti targetinfo.TargetInfo, inFile string, outFile string) err
 type InputFile func(targetInfo targetinfo.TargetInfo, workDir string) (string, error)
 type OutputFile func(targetInfo targetinfo.TargetInfo, workDir string) (string, error)
 
+var (
+	plannedLock   = sync.Mutex{}
+	plannedOutput = map[string]bool{}
+)
+
+// plan remembers outputs which would have been created during a dry-run, so subsequent processors can use them as input
+func plan(outFile string) {
+	plannedLock.Lock()
+	defer plannedLock.Unlock()
+	plannedOutput[outFile] = true
+}
+
+func isPlanned(file string) bool {
+	plannedLock.Lock()
+	defer plannedLock.Unlock()
+	return plannedOutput[file]
+}
+
+// Skipped is returned by processors which deliberately leave a target unprocessed (e.g. because its output exists already).
+// Skipped targets are dropped from further processing - no result is recorded for them.
+type Skipped struct {
+	Reason string
+}
+
+func (s *Skipped) Error() string {
+	return s.Reason
+}
+
 func initError(processorName string, reason string) error {
 	return fmt.Errorf("failed to initialize %s processor because %s", processorName, reason)
 }
-func Process(ctx task.Context, process Processor, processorName string, checkLazy CheckLazy, inputFile InputFile, outputFile OutputFile) task.HandlerFunc {
+
+// Process runs a processor on all targets - every successful run is recorded in the target's history as a result of the stage.
+// The recorded output is evaluated after processing, as processors may choose the final location of their output (e.g. on collisions).
+func Process(ctx task.Context, process Processor, stage string, processorName string, checkLazy CheckLazy, inputFile InputFile, outputFile OutputFile) task.HandlerFunc {
 	conf := ctx.Config.(*ripper.AppConf)
 	workDir := conf.WorkDirectory
 
@@ -37,6 +71,9 @@ func Process(ctx task.Context, process Processor, processorName string, checkLaz
 
 	return func(job task.Job) ([]task.Job, error) {
 		target := ripper.GetTargetFileFromJob(job)
+		if shutdown.IsAborting() {
+			return nil, shutdown.ErrAborted(fmt.Sprintf("%s of %s", processorName, target))
+		}
 		ti, err := targetinfo.ForTarget(workDir, target)
 		if err != nil {
 			return nil, err
@@ -51,14 +88,34 @@ func Process(ctx task.Context, process Processor, processorName string, checkLaz
 			return nil, err
 		}
 
+		tool := processorName
 		if checkLazy(ti) {
+			tool = lazyCopy
 			ctx.Printf("input file appears just right -> reuse %s\n", target)
-			_, err = files.Copy(in, out, false)
+			if conf.DryRun {
+				ctx.Printf("dry-run - would copy %s to %s\n", in, out)
+			} else {
+				_, err = files.Copy(in, out, false)
+			}
 		} else {
 			ctx.Printf("use %s to process file %s\n", processorName, target)
 			err = process(ti, in, out)
 		}
 
+		if skipped, isSkipped := err.(*Skipped); isSkipped {
+			ctx.Printf("%s\n", skipped)
+			return []task.Job{}, nil
+		}
+		if conf.DryRun {
+			if err != nil {
+				ctx.Printf("dry-run - %s of %s would fail: %s\n", processorName, target, err)
+			}
+			plan(out)
+			return []task.Job{job}, nil
+		}
+		if err == nil {
+			err = recordResult(ti, workDir, stage, tool, outputFile)
+		}
 		if err != nil {
 			return []task.Job{}, err
 		} else {
@@ -67,6 +124,20 @@ func Process(ctx task.Context, process Processor, processorName string, checkLaz
 	}
 }
 
+// recordResult appends the result of a stage to the history of the target
+func recordResult(ti targetinfo.TargetInfo, workDir string, stage string, tool string, outputFile OutputFile) error {
+	out, err := outputFile(ti, workDir)
+	if err != nil {
+		return err
+	}
+	ti.AddStageResult(targetinfo.StageResult{Stage: stage, Tool: tool, Version: toolVersion(stage, tool), Output: out, At: time.Now()})
+	targetWorkDir, err := ripper.GetWorkPathForTargetFolder(workDir, ti.GetFolder())
+	if err != nil {
+		return err
+	}
+	return targetinfo.Save(targetWorkDir, ti)
+}
+
 func DefaultCheckLazy(lazyEnabled bool, expectedExtension string) CheckLazy {
 	return func(targetInfo targetinfo.TargetInfo) bool {
 		return lazyEnabled && files.GetExtension(targetInfo.GetFile()) == expectedExtension
@@ -97,7 +168,7 @@ func DefaultInputFileFor(allowedInputExtensions []string) InputFile {
 			}
 			if exists, err := files.Exists(fName); err != nil {
 				return "", err
-			} else if exists {
+			} else if exists || isPlanned(fName) {
 				return fName, nil
 			}
 		}
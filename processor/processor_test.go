@@ -10,6 +10,8 @@ import (
 	"github.com/thomasschoeftner/go-ripper/targetinfo"
 	"github.com/thomasschoeftner/go-cli/test"
 	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-cli/task"
+	"errors"
 )
 
 
@@ -124,4 +126,78 @@ func TestGetDefaultOutputFileFor(t *testing.T) {
 	outputFile, err := DefaultOutputFileFor(expectedOutputExtension)(ti, workDirBase)
 	assert.NotError(err)
 	assert.StringsEqual(filepath.Join(workDir, files.WithExtension(sourceFile, expectedOutputExtension)), outputFile)
-}
\ No newline at end of file
+}
+func TestProcessDryRun(t *testing.T) {
+	assert := test.AssertOn(t)
+	workDir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, workDir)
+
+	planned := targetinfo.NewMovie("planned.avi", "/dry/run", "planned")
+	targetinfo.Plan(planned)
+	conf := &ripper.AppConf{WorkDirectory: workDir, DryRun: true}
+	ctx := task.Context{Config: conf, Printf: commons.DevNullPrintf}
+	failing := func(ti targetinfo.TargetInfo, inFile string, outFile string) error {
+		return errors.New("meta-info is not available locally")
+	}
+
+	job := task.Job{ripper.JobField_Path: planned.GetFullPath()}
+	jobs, err := Process(ctx, failing, "tested", "test", nil, DefaultInputFileFor([]string{"avi"}), DefaultOutputFileFor("mp4"))(job)
+	assert.NotError(err)
+	assert.IntsEqual(1, len(jobs))
+
+	// output of the dry-run is used as input of subsequent processors without actually existing
+	out := assert.StringNotError(DefaultOutputFileFor("mp4")(planned, workDir))
+	assert.FalseNotError("expected no output to be created during dry-run")(files.Exists(out))
+	assert.StringsEqual(out, assert.StringNotError(DefaultInputFileFor([]string{"mp4"})(planned, workDir)))
+}
+
+func TestProcessRecordsHistory(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	workDir := filepath.Join(dir, "work")
+	inDir := filepath.Join(dir, "in")
+	test.CheckError(t, os.MkdirAll(inDir, os.ModePerm))
+	movie := targetinfo.NewMovie("movie.avi", inDir, "tt1")
+	test.CheckError(t, ioutil.WriteFile(movie.GetFullPath(), []byte{}, os.ModePerm))
+	targetWorkDir, err := ripper.GetWorkPathForTargetFolder(workDir, inDir)
+	test.CheckError(t, err)
+	test.CheckError(t, targetinfo.Save(targetWorkDir, movie))
+
+	conf := &ripper.AppConf{WorkDirectory: workDir}
+	ctx := task.Context{Config: conf, Printf: commons.DevNullPrintf}
+	succeeding := func(ti targetinfo.TargetInfo, inFile string, outFile string) error {
+		return nil
+	}
+	job := task.Job{ripper.JobField_Path: movie.GetFullPath()}
+	_, err = Process(ctx, succeeding, "ripped", "test", nil, DefaultInputFileFor([]string{"avi"}), DefaultOutputFileFor("mp4"))(job)
+	assert.NotError(err)
+
+	recorded, err := targetinfo.ForTarget(workDir, movie.GetFullPath())
+	assert.NotError(err)
+	result := targetinfo.LastResultOf(recorded, "ripped")
+	assert.True("expected result of stage in history")(result != nil)
+	assert.StringsEqual("test", result.Tool)
+	assert.StringsEqual(assert.StringNotError(DefaultOutputFileFor("mp4")(movie, workDir)), result.Output)
+
+	skipping := func(ti targetinfo.TargetInfo, inFile string, outFile string) error {
+		return &Skipped{Reason: "output exists"}
+	}
+	jobs, err := Process(ctx, skipping, "tagged", "test", nil, DefaultInputFileFor([]string{"avi"}), DefaultOutputFileFor("mp4"))(job)
+	assert.NotError(err)
+	assert.IntsEqual(0, len(jobs))
+	recorded, err = targetinfo.ForTarget(workDir, movie.GetFullPath())
+	assert.NotError(err)
+	assert.True("expected no result of skipped stage in history")(targetinfo.LastResultOf(recorded, "tagged") == nil)
+}
+
+func TestDetectToolVersion(t *testing.T) {
+	assert := test.AssertOn(t)
+	assert.StringsEqual("tool 1.2.3", DetectToolVersion("ripped", "echoing", "echo", "tool 1.2.3"))
+	assert.StringsEqual("tool 1.2.3", toolVersion("ripped", "echoing"))
+	assert.StringsEqual("", DetectToolVersion("tagged", "echoing", "/does/not/exist", "--version"))
+	assert.StringsEqual("tool 1.2.3", toolVersion("ripped", "echoing")) // same processor name, but different tool in other stage
+	assert.StringsEqual("", toolVersion("tagged", "echoing"))
+
+	assert.StringsEqual("tool 1.2.3", DetectToolVersion("ripped", "echoing", "echo", "changed")) // each tool is queried only once
+}
@@ -0,0 +1,55 @@
+package processor
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tool recorded for targets, whose input was re-used instead of processing it
+const lazyCopy = "copy"
+
+const versionTimeout = 10 * time.Second
+
+var (
+	toolVersionsLock = sync.Mutex{}
+	toolVersions     = map[string]string{} // versions by path of the tool - each tool is queried only once
+	toolPaths        = map[string]string{} // paths of the tools used by processors by stage and processor name
+)
+
+// DetectToolVersion queries the version of the tool used by a processor, which is recorded in the history of all processed targets.
+// The first line printed by the tool is taken as version - tools which cannot be run have no version.
+// Processors of different stages may share the same name (e.g. "ffmpeg"), but use different tools.
+func DetectToolVersion(stage string, processorName string, path string, versionArgs ...string) string {
+	toolVersionsLock.Lock()
+	toolPaths[toolKey(stage, processorName)] = path
+	version, known := toolVersions[path]
+	toolVersionsLock.Unlock()
+	if known {
+		return version
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, versionArgs...).Output()
+	if err == nil {
+		version = strings.TrimSpace(strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0])
+	}
+
+	toolVersionsLock.Lock()
+	defer toolVersionsLock.Unlock()
+	toolVersions[path] = version
+	return version
+}
+
+func toolVersion(stage string, processorName string) string {
+	toolVersionsLock.Lock()
+	defer toolVersionsLock.Unlock()
+	return toolVersions[toolPaths[toolKey(stage, processorName)]]
+}
+
+func toolKey(stage string, processorName string) string {
+	return stage + "/" + processorName
+}
@@ -0,0 +1,81 @@
+package progress
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Status describes the progress of the external tool currently running
+type Status struct {
+	Tool      string    `json:"tool"`
+	Target    string    `json:"target"`
+	Percent   float64   `json:"percent"`
+	Eta       string    `json:"eta,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+var (
+	lock    = sync.Mutex{}
+	current *Status
+)
+
+// e.g. HandBrakeCLI: "Encoding: task 1 of 1, 45.67 % (97.21 fps, avg 102.45 fps, ETA 00h10m12s)"
+var (
+	percentPattern = regexp.MustCompile(`(\d+(?:\.\d+)?) %`)
+	etaPattern     = regexp.MustCompile(`ETA (\w+)\)`)
+)
+
+// Writer tracks progress printed by an external tool to its output - call Finish once the tool has exited
+type Writer struct {
+	status *Status
+}
+
+func Track(tool string, target string) *Writer {
+	lock.Lock()
+	defer lock.Unlock()
+	now := time.Now()
+	current = &Status{Tool: tool, Target: target, StartedAt: now, UpdatedAt: now}
+	return &Writer{status: current}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	percentages := percentPattern.FindAllSubmatch(p, -1)
+	if len(percentages) == 0 {
+		return len(p), nil
+	}
+	percent, err := strconv.ParseFloat(string(percentages[len(percentages)-1][1]), 64)
+	if err != nil {
+		return len(p), nil
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	w.status.Percent = percent
+	w.status.UpdatedAt = time.Now()
+	if etas := etaPattern.FindAllSubmatch(p, -1); len(etas) > 0 {
+		w.status.Eta = string(etas[len(etas)-1][1])
+	}
+	return len(p), nil
+}
+
+func (w *Writer) Finish() {
+	lock.Lock()
+	defer lock.Unlock()
+	if current == w.status {
+		current = nil
+	}
+}
+
+// Current returns a copy of the current progress, or nil if no tool is running
+func Current() *Status {
+	lock.Lock()
+	defer lock.Unlock()
+	if current == nil {
+		return nil
+	}
+	status := *current
+	return &status
+}
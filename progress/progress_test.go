@@ -0,0 +1,26 @@
+package progress
+
+import (
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+)
+
+func TestWriter(t *testing.T) {
+	assert := test.AssertOn(t)
+	assert.True("expected no progress without running tool")(Current() == nil)
+
+	w := Track("handbrake", "/in/movie.mkv")
+	w.Write([]byte("\rEncoding: task 1 of 1, 12.50 % (97.21 fps, avg 102.45 fps, ETA 00h10m12s)\rEncoding: task 1 of 1, 13.75 % (97.21 fps, avg 102.45 fps, ETA 00h10m02s)"))
+	status := Current()
+	assert.True("expected progress of running tool")(status != nil)
+	assert.StringsEqual("handbrake", status.Tool)
+	assert.True("expected latest percentage")(status.Percent == 13.75)
+	assert.StringsEqual("00h10m02s", status.Eta)
+
+	w.Write([]byte("no progress info"))
+	assert.True("expected percentage to be kept")(Current().Percent == 13.75)
+
+	w.Finish()
+	assert.True("expected no progress after tool finished")(Current() == nil)
+}
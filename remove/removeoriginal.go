@@ -0,0 +1,225 @@
+package remove
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/shutdown"
+	"github.com/thomasschoeftner/go-ripper/tag"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+const (
+	CONF_REMOVE_DELETE = "delete"
+	CONF_REMOVE_TRASH  = "trash"
+)
+
+type remover func(original string) error
+
+var (
+	purgedLock = sync.Mutex{}
+	purged     = map[string]bool{} // trash folders purged during this run
+)
+
+func RemoveOriginal(ctx task.Context) task.HandlerFunc {
+	conf := ctx.Config.(*ripper.AppConf)
+	rmConf := conf.RemoveOriginal
+	if rmConf == nil {
+		return ripper.ErrorHandler(errors.New("removal of original input files is not configured"))
+	}
+
+	var remove remover
+	switch rmConf.Mode {
+	case CONF_REMOVE_DELETE:
+		remove = deleting
+	case CONF_REMOVE_TRASH:
+		trash, err := newTrash(rmConf.Trash)
+		if err != nil {
+			return ripper.ErrorHandler(err)
+		}
+		if !conf.DryRun {
+			if err := trash.purgeOnce(time.Now(), ctx.Printf); err != nil {
+				return ripper.ErrorHandler(err)
+			}
+		}
+		remove = trash.moveToTrash
+	default:
+		return ripper.ErrorHandler(fmt.Errorf("unknown mode for removal of original input files configured: \"%s\"", rmConf.Mode))
+	}
+
+	return ripper.ForMedia(func(job task.Job) ([]task.Job, error) {
+		target := ripper.GetTargetFileFromJob(job)
+		if shutdown.IsAborting() {
+			return nil, shutdown.ErrAborted(fmt.Sprintf("removal of %s", target))
+		}
+		ti, err := targetinfo.ForTarget(conf.WorkDirectory, target)
+		if err != nil {
+			return nil, err
+		}
+		if existing, err := tag.SkippedDestination(conf, ti); err != nil {
+			return nil, err
+		} else if len(existing) > 0 {
+			return nil, fmt.Errorf("refuse to remove original \"%s\" - it was not tagged, as %s existed already", ti.GetFullPath(), existing)
+		}
+
+		originals := append(targetinfo.InputFiles(ti), targetinfo.SidecarFiles(ti)...) // sidecars are muxed into the tagged output
+		if conf.DryRun {
+			for _, original := range originals {
+				ctx.Printf("dry-run - would remove original %s (%s)\n", original, rmConf.Mode)
+			}
+			return []task.Job{job}, nil
+		}
+		output, err := tag.RecordedDestination(conf, ti)
+		if err != nil {
+			return nil, err
+		}
+		if len(output) == 0 {
+			return nil, fmt.Errorf("refuse to remove original \"%s\" - no tagged output was recorded for it", ti.GetFullPath())
+		}
+		if err := checkOutputPlausible(originals, output, rmConf.MinSizeRatio); err != nil {
+			return nil, fmt.Errorf("refuse to remove original \"%s\" - %s", ti.GetFullPath(), err)
+		}
+
+		for _, original := range originals {
+			ctx.Printf("remove original %s (%s)\n", original, rmConf.Mode)
+			if err := remove(original); err != nil {
+				return nil, err
+			}
+		}
+		return []task.Job{job}, nil
+	})
+}
+
+// checkOutputPlausible compares the size of the tagged output with the total size of all original input files.
+// Only the size is checked - the duration of output and originals is not compared.
+func checkOutputPlausible(originals []string, output string, minSizeRatio float64) error {
+	outputInfo, err := os.Stat(output)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("tagged output \"%s\" does not exist", output)
+		}
+		return err
+	}
+	if !outputInfo.Mode().IsRegular() {
+		return fmt.Errorf("tagged output \"%s\" is not a regular file", output)
+	}
+	if outputInfo.Size() == 0 {
+		return fmt.Errorf("tagged output \"%s\" is empty", output)
+	}
+
+	var originalSize int64
+	for _, original := range originals {
+		size, err := files.Size(original) // disc folders are compared by their total size
+		if err != nil {
+			return err
+		}
+		originalSize += size
+	}
+	ratio := float64(outputInfo.Size()) / float64(originalSize)
+	if ratio < minSizeRatio {
+		return fmt.Errorf("tagged output \"%s\" is implausibly small (%d bytes = %.3f of original size, expected at least %.3f)", output, outputInfo.Size(), ratio, minSizeRatio)
+	}
+	return nil
+}
+
+func deleting(original string) error {
+	if files.IsDiscFolder(original) {
+		return os.RemoveAll(original)
+	}
+	return os.Remove(original)
+}
+
+type trash struct {
+	folder    string
+	retention time.Duration
+}
+
+func newTrash(conf *ripper.TrashConfig) (*trash, error) {
+	if conf == nil || commons.IsStringEmptyWithSpaces(conf.Folder) {
+		return nil, errors.New("trash folder for removed original input files is undefined")
+	}
+	var retention time.Duration
+	if !commons.IsStringEmptyWithSpaces(conf.Retention) {
+		var err error
+		if retention, err = time.ParseDuration(conf.Retention); err != nil {
+			return nil, err
+		}
+	}
+	return &trash{folder: conf.Folder, retention: retention}, nil
+}
+
+// moveToTrash keeps the folder structure of the original within the trash folder
+func (t *trash) moveToTrash(original string) error {
+	folder, file := filepath.Split(original)
+	trashFolder, err := ripper.GetWorkPathForTargetFolder(t.folder, folder)
+	if err != nil {
+		return err
+	}
+	if err := files.CreateFolderStructure(trashFolder); err != nil {
+		return err
+	}
+
+	trashed := filepath.Join(trashFolder, file)
+	if exists, err := files.Exists(trashed); err != nil {
+		return err
+	} else if exists {
+		name, ext := files.SplitExtension(file)
+		trashed = filepath.Join(trashFolder, files.WithExtension(fmt.Sprintf("%s.%s", name, time.Now().Format("20060102-150405")), ext))
+	}
+
+	if err := files.Move(original, trashed); err != nil {
+		return err
+	}
+	// modification time marks the time of removal - required for purging after the retention period
+	now := time.Now()
+	return filepath.Walk(trashed, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(path, now, now)
+	})
+}
+
+// purgeOnce purges the trash folder only once per run - handlers are constructed for every job
+func (t *trash) purgeOnce(now time.Time, printf commons.FormatPrinter) error {
+	purgedLock.Lock()
+	defer purgedLock.Unlock()
+	if purged[t.folder] {
+		return nil
+	}
+	if err := t.purge(now, printf); err != nil {
+		return err
+	}
+	purged[t.folder] = true
+	return nil
+}
+
+// purge deletes all trashed files which were removed before the retention period
+func (t *trash) purge(now time.Time, printf commons.FormatPrinter) error {
+	if t.retention <= 0 {
+		return nil
+	}
+	if exists, err := files.Exists(t.folder); err != nil || !exists {
+		return err
+	}
+
+	expiredBefore := now.Add(-t.retention)
+	return filepath.Walk(t.folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() && info.ModTime().Before(expiredBefore) {
+			printf("purge expired file from trash: %s\n", path)
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,195 @@
+package remove
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ledger"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+func createFile(t *testing.T, path string, size int) string {
+	test.CheckError(t, files.CreateFolderStructure(filepath.Dir(path)))
+	test.CheckError(t, ioutil.WriteFile(path, make([]byte, size), os.ModePerm))
+	return path
+}
+
+func TestCheckOutputPlausible(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	original := createFile(t, filepath.Join(dir, "in", "movie.avi"), 1000)
+
+	t.Run("missing output", func(t *testing.T) {
+		err := checkOutputPlausible([]string{original}, filepath.Join(dir, "out", "missing.mp4"), 0.1)
+		test.AssertOn(t).ExpectError("expected error for missing output, but got none")(err)
+	})
+
+	t.Run("empty output", func(t *testing.T) {
+		output := createFile(t, filepath.Join(dir, "out", "empty.mp4"), 0)
+		err := checkOutputPlausible([]string{original}, output, 0)
+		test.AssertOn(t).ExpectError("expected error for empty output, but got none")(err)
+	})
+
+	t.Run("output too small", func(t *testing.T) {
+		output := createFile(t, filepath.Join(dir, "out", "small.mp4"), 50)
+		err := checkOutputPlausible([]string{original}, output, 0.1)
+		test.AssertOn(t).ExpectError("expected error for implausibly small output, but got none")(err)
+	})
+
+	t.Run("plausible output", func(t *testing.T) {
+		output := createFile(t, filepath.Join(dir, "out", "ok.mp4"), 200)
+		test.AssertOn(t).NotError(checkOutputPlausible([]string{original}, output, 0.1))
+	})
+
+	t.Run("compare with total size of all parts", func(t *testing.T) {
+		part2 := createFile(t, filepath.Join(dir, "in", "movie-cd2.avi"), 1000)
+		output := createFile(t, filepath.Join(dir, "out", "parts.mp4"), 150)
+		assert := test.AssertOn(t)
+		assert.NotError(checkOutputPlausible([]string{original}, output, 0.1))
+		assert.ExpectError("expected error for output which is implausibly small compared to all parts, but got none")(checkOutputPlausible([]string{original, part2}, output, 0.1))
+	})
+
+	t.Run("compare with total size of disc folder", func(t *testing.T) {
+		disc := filepath.Join(dir, "in", "VIDEO_TS")
+		createFile(t, filepath.Join(disc, "VTS_01_1.VOB"), 1000)
+		createFile(t, filepath.Join(disc, "VTS_01_2.VOB"), 1000)
+		output := createFile(t, filepath.Join(dir, "out", "disc.mp4"), 150)
+		test.AssertOn(t).ExpectError("expected error for output which is implausibly small compared to disc, but got none")(checkOutputPlausible([]string{disc}, output, 0.1))
+	})
+}
+
+func TestTrash(t *testing.T) {
+	t.Run("expect error for undefined trash folder", func(t *testing.T) {
+		_, err := newTrash(&ripper.TrashConfig{Folder: " ", Retention: "1h"})
+		test.AssertOn(t).ExpectError("expected error for undefined trash folder, but got none")(err)
+	})
+
+	t.Run("move original to trash and keep folder structure", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		original := createFile(t, filepath.Join(dir, "in", "movie.avi"), 10)
+		trashDir := filepath.Join(dir, "trash")
+
+		trash, err := newTrash(&ripper.TrashConfig{Folder: trashDir, Retention: "1h"})
+		assert.NotError(err)
+		assert.NotError(trash.moveToTrash(original))
+
+		trashFolder := assert.StringNotError(ripper.GetWorkPathForTargetFolder(trashDir, filepath.Dir(original)))
+		assert.FalseNotError("original file was not removed")(files.Exists(original))
+		assert.TrueNotError("original file was not moved to trash")(files.Exists(filepath.Join(trashFolder, "movie.avi")))
+
+		//trash same file again
+		createFile(t, original, 10)
+		assert.NotError(trash.moveToTrash(original))
+		trashed, err := files.GetDirectoryContents(trashFolder)
+		assert.NotError(err)
+		assert.IntsEqual(2, len(trashed))
+	})
+
+	t.Run("move disc folder to trash", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		original := filepath.Join(dir, "in", "VIDEO_TS")
+		vob := createFile(t, filepath.Join(original, "VTS_01_1.VOB"), 10)
+		old := time.Now().Add(-2 * time.Hour)
+		assert.NotError(os.Chtimes(vob, old, old))
+		trashDir := filepath.Join(dir, "trash")
+
+		trash, err := newTrash(&ripper.TrashConfig{Folder: trashDir, Retention: "1h"})
+		assert.NotError(err)
+		assert.NotError(trash.moveToTrash(original))
+		assert.FalseNotError("original disc folder was not removed")(files.Exists(original))
+
+		// contents of trashed disc folders must not expire before the retention period
+		assert.NotError(trash.purge(time.Now(), commons.Printf))
+		trashFolder := assert.StringNotError(ripper.GetWorkPathForTargetFolder(trashDir, filepath.Dir(original)))
+		assert.TrueNotError("disc contents were purged too early")(files.Exists(filepath.Join(trashFolder, "VIDEO_TS", "VTS_01_1.VOB")))
+	})
+
+	t.Run("purge expired files only", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		expired := createFile(t, filepath.Join(dir, "a", "expired.avi"), 10)
+		recent := createFile(t, filepath.Join(dir, "b", "recent.avi"), 10)
+		twoHoursAgo := time.Now().Add(-2 * time.Hour)
+		assert.NotError(os.Chtimes(expired, twoHoursAgo, twoHoursAgo))
+
+		trash, err := newTrash(&ripper.TrashConfig{Folder: dir, Retention: "1h"})
+		assert.NotError(err)
+		assert.NotError(trash.purge(time.Now(), commons.DevNullPrintf))
+		assert.FalseNotError("expired file was not purged")(files.Exists(expired))
+		assert.TrueNotError("recent file was purged")(files.Exists(recent))
+	})
+
+	t.Run("purge only once per run", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		trashed := createFile(t, filepath.Join(dir, "trashed.avi"), 10)
+		twoHoursAgo := time.Now().Add(-2 * time.Hour)
+
+		trash, err := newTrash(&ripper.TrashConfig{Folder: dir, Retention: "1h"})
+		assert.NotError(err)
+		assert.NotError(trash.purgeOnce(time.Now(), commons.DevNullPrintf))
+		assert.TrueNotError("recent file was purged")(files.Exists(trashed))
+
+		assert.NotError(os.Chtimes(trashed, twoHoursAgo, twoHoursAgo))
+		assert.NotError(trash.purgeOnce(time.Now(), commons.DevNullPrintf))
+		assert.TrueNotError("trash was purged more than once")(files.Exists(trashed))
+	})
+
+	t.Run("keep files forever without retention", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		old := createFile(t, filepath.Join(dir, "old.avi"), 10)
+		longAgo := time.Now().Add(-1000 * time.Hour)
+		assert.NotError(os.Chtimes(old, longAgo, longAgo))
+
+		trash, err := newTrash(&ripper.TrashConfig{Folder: dir})
+		assert.NotError(err)
+		assert.NotError(trash.purge(time.Now(), commons.DevNullPrintf))
+		assert.TrueNotError("file was purged despite undefined retention")(files.Exists(old))
+	})
+}
+
+func TestRemoveOriginalRequiresRecordedDestination(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	original := createFile(t, filepath.Join(dir, "in", "dune.avi"), 1000)
+	output := createFile(t, filepath.Join(dir, "out", "Dune.mp4"), 1000) // may as well be the output of another title at the same calculated destination
+
+	conf := &ripper.AppConf{
+		StoragePath:    filepath.Join(dir, "storage"),
+		WorkDirectory:  filepath.Join(dir, "work"),
+		RemoveOriginal: &ripper.RemoveOriginalConfig{Mode: CONF_REMOVE_DELETE, MinSizeRatio: 0.1},
+	}
+	ti := targetinfo.NewMovie("dune.avi", filepath.Join(dir, "in"), "tt0087182")
+	workFolder := assert.StringNotError(ripper.GetWorkPathForTargetFolder(conf.WorkDirectory, ti.GetFolder()))
+	assert.NotError(targetinfo.Save(workFolder, ti))
+	ctx := task.Context{Config: conf, Printf: commons.DevNullPrintf}
+	job := task.Job{ripper.JobField_Path: original, ripper.JobField_Media: ripper.MEDIA_VIDEO}
+
+	_, err := RemoveOriginal(ctx)(job)
+	assert.ExpectError("expected error for target without recorded destination")(err)
+	assert.TrueNotError("original was removed without recorded destination")(files.Exists(original))
+
+	l, err := ledger.Open(conf.StateDirectory())
+	assert.NotError(err)
+	assert.NotError(l.RecordDestination(original, output))
+	_, err = RemoveOriginal(ctx)(job)
+	assert.NotError(err)
+	assert.FalseNotError("original was not removed")(files.Exists(original))
+}
@@ -0,0 +1,162 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/tag"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+type StageReport struct {
+	Stage      string    `json:"stage"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	ExitStatus *int      `json:"exitStatus,omitempty"` //exit status of the external tool - only available if it failed
+	Error      string    `json:"error,omitempty"`
+}
+
+type JobReport struct {
+	Target string         `json:"target"`
+	Media  string         `json:"media,omitempty"`
+	Id     string         `json:"id,omitempty"`
+	Title  string         `json:"title,omitempty"`
+	Output string         `json:"output,omitempty"`
+	Stages []*StageReport `json:"stages"`
+	Error  string         `json:"error,omitempty"`
+}
+
+type Report struct {
+	LaunchedAt time.Time    `json:"launchedAt"`
+	FinishedAt time.Time    `json:"finishedAt"`
+	Jobs       []*JobReport `json:"jobs"`
+}
+
+// Recorder collects a report entry per target - the entry is streamed as JSON line after every stage (if configured)
+type Recorder struct {
+	conf   *ripper.ReportConfig
+	lock   sync.Mutex
+	jobs   map[string]*JobReport
+	report Report
+	stream *os.File
+}
+
+func NewRecorder(conf *ripper.ReportConfig) (*Recorder, error) {
+	if conf == nil || commons.IsStringEmptyWithSpaces(conf.File) {
+		return nil, errors.New("report file is undefined")
+	}
+	rec := &Recorder{conf: conf, jobs: map[string]*JobReport{}, report: Report{Jobs: []*JobReport{}}}
+	if !commons.IsStringEmptyWithSpaces(conf.Stream) {
+		if err := files.CreateFolderStructure(filepath.Dir(conf.Stream)); err != nil {
+			return nil, err
+		}
+		stream, err := os.OpenFile(conf.Stream, os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.ModePerm)
+		if err != nil {
+			return nil, err
+		}
+		rec.stream = stream
+	}
+	return rec, nil
+}
+
+// job returns the report entry for a target - must be called while holding the lock
+func (rec *Recorder) job(target string, media string) *JobReport {
+	jr := rec.jobs[target]
+	if jr == nil {
+		jr = &JobReport{Target: target, Stages: []*StageReport{}}
+		rec.jobs[target] = jr
+		rec.report.Jobs = append(rec.report.Jobs, jr)
+	}
+	if len(media) > 0 {
+		jr.Media = media
+	}
+	return jr
+}
+
+func (rec *Recorder) add(target string, media string) error {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	return rec.streamLine(rec.job(target, media))
+}
+
+func (rec *Recorder) recordStage(conf *ripper.AppConf, target string, media string, stage string, startedAt time.Time, stageErr error) error {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	jr := rec.job(target, media)
+	sr := &StageReport{Stage: stage, StartedAt: startedAt, DurationMs: time.Since(startedAt).Milliseconds()}
+	if stageErr != nil {
+		sr.Error = stageErr.Error()
+		jr.Error = sr.Error
+		var exitErr *exec.ExitError
+		if errors.As(stageErr, &exitErr) {
+			exitStatus := exitErr.ExitCode()
+			sr.ExitStatus = &exitStatus
+		}
+	}
+	jr.Stages = append(jr.Stages, sr)
+	describe(conf, jr)
+	return rec.streamLine(jr)
+}
+
+// describe adds id, title, and output path to the report entry as far as they are known yet - the output is known once it was tagged
+func describe(conf *ripper.AppConf, jr *JobReport) {
+	ti, err := targetinfo.ForTarget(conf.WorkDirectory, jr.Target)
+	if err != nil {
+		return
+	}
+	jr.Id = ti.GetId()
+	if title, err := tag.TitleFor(conf, ti); err == nil {
+		jr.Title = title
+	}
+	if output, err := tag.RecordedDestination(conf, ti); err == nil && len(output) > 0 {
+		if exists, _ := files.Exists(output); exists {
+			jr.Output = output
+		}
+	}
+}
+
+func (rec *Recorder) streamLine(jr *JobReport) error {
+	if rec.stream == nil {
+		return nil
+	}
+	line, err := json.Marshal(jr)
+	if err != nil {
+		return err
+	}
+	_, err = rec.stream.Write(append(line, '\n'))
+	return err
+}
+
+func (rec *Recorder) File() string {
+	return rec.conf.File
+}
+
+// Finish writes the report file and closes the stream
+func (rec *Recorder) Finish(launchedAt time.Time, finishedAt time.Time) error {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	if rec.stream != nil {
+		defer rec.stream.Close()
+	}
+	rec.report.LaunchedAt = launchedAt
+	rec.report.FinishedAt = finishedAt
+	raw, err := json.MarshalIndent(rec.report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := files.CreateFolderStructure(filepath.Dir(rec.conf.File)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(rec.conf.File, raw, os.ModePerm)
+}
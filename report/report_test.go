@@ -0,0 +1,83 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+func handlerReturning(err error) task.Handler {
+	return func(ctx task.Context) task.HandlerFunc {
+		return func(job task.Job) ([]task.Job, error) {
+			return []task.Job{job}, err
+		}
+	}
+}
+
+func TestTimed(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+
+	conf := &ripper.ReportConfig{File: filepath.Join(dir, "report.json"), Stream: filepath.Join(dir, "report.jsonl")}
+	rec, err := NewRecorder(conf)
+	assert.NotError(err)
+
+	ctx := task.Context{Config: &ripper.AppConf{WorkDirectory: filepath.Join(dir, "work")}, Printf: commons.DevNullPrintf}
+	okJob := task.Job{ripper.JobField_Path: filepath.Join(dir, "ok.avi"), ripper.JobField_Media: ripper.MEDIA_VIDEO}
+	failedJob := task.Job{ripper.JobField_Path: filepath.Join(dir, "failed.avi"), ripper.JobField_Media: ripper.MEDIA_VIDEO}
+	audioJob := task.Job{ripper.JobField_Path: filepath.Join(dir, "track.wav"), ripper.JobField_Media: ripper.MEDIA_AUDIO}
+
+	toolErr := exec.Command("sh", "-c", "exit 3").Run()
+	_, err = Timed(rec, "ripped", handlerReturning(nil), ripper.MEDIA_VIDEO)(ctx)(okJob)
+	assert.NotError(err)
+	_, err = Timed(rec, "ripped", handlerReturning(toolErr), ripper.MEDIA_VIDEO)(ctx)(failedJob)
+	assert.ExpectError("expected error of handler to be returned")(err)
+	_, err = Timed(rec, "ripped", handlerReturning(nil), ripper.MEDIA_VIDEO)(ctx)(audioJob)
+	assert.NotError(err)
+
+	launchedAt := time.Now().Add(-time.Minute)
+	assert.NotError(rec.Finish(launchedAt, time.Now()))
+
+	t.Run("write report file", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		raw, err := ioutil.ReadFile(conf.File)
+		assert.NotError(err)
+		report := Report{}
+		assert.NotError(json.Unmarshal(raw, &report))
+
+		assert.IntsEqual(2, len(report.Jobs))
+		ok, failed := report.Jobs[0], report.Jobs[1]
+		assert.StringsEqual(okJob[ripper.JobField_Path], ok.Target)
+		assert.IntsEqual(1, len(ok.Stages))
+		assert.StringsEqual("ripped", ok.Stages[0].Stage)
+		assert.True("expected no exit status for successful stage")(ok.Stages[0].ExitStatus == nil)
+
+		assert.StringsEqual(toolErr.Error(), failed.Error)
+		assert.True("expected exit status of failed tool")(failed.Stages[0].ExitStatus != nil)
+		assert.IntsEqual(3, *failed.Stages[0].ExitStatus)
+	})
+
+	t.Run("stream JSON lines", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		f, err := os.Open(conf.Stream)
+		assert.NotError(err)
+		defer f.Close()
+		lines := 0
+		for scanner := bufio.NewScanner(f); scanner.Scan(); lines++ {
+			jr := JobReport{}
+			assert.NotError(json.Unmarshal(scanner.Bytes(), &jr))
+		}
+		assert.IntsEqual(2, lines)
+	})
+}
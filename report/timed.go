@@ -0,0 +1,55 @@
+package report
+
+import (
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+// Timed reports duration and outcome of a task handler for each target of the given media types (or any media type if none is given).
+// Folders are reported only if they cannot be scanned - otherwise a report entry is added for each target found.
+// Without recorder, the handler is returned untouched.
+func Timed(rec *Recorder, stage string, handler task.Handler, media ...string) task.Handler {
+	if rec == nil {
+		return handler
+	}
+	return func(ctx task.Context) task.HandlerFunc {
+		conf := ctx.Config.(*ripper.AppConf)
+		handle := handler(ctx)
+		return func(job task.Job) ([]task.Job, error) {
+			target := ripper.GetTargetFileFromJob(job)
+			startedAt := time.Now()
+
+			if ripper.IsTargetJobFor(job, media...) {
+				jobs, err := handle(job)
+				if err == nil && len(jobs) == 0 {
+					return jobs, nil // dropped without error (e.g. skipped) - the stage is not done
+				}
+				if recErr := rec.recordStage(conf, target, ripper.GetMediaFromJob(job), stage, startedAt, err); recErr != nil && err == nil {
+					err = recErr
+				}
+				return jobs, err
+			}
+
+			if len(ripper.GetMediaFromJob(job)) > 0 {
+				return handle(job) // target of other media type
+			}
+			jobs, err := handle(job)
+			if err != nil {
+				if recErr := rec.recordStage(conf, target, "", stage, startedAt, err); recErr != nil {
+					ctx.Printf("unable to report failure of %s - %s\n", target, recErr)
+				}
+				return jobs, err
+			}
+			for _, j := range jobs {
+				if media := ripper.GetMediaFromJob(j); len(media) > 0 {
+					if err := rec.add(ripper.GetTargetFileFromJob(j), media); err != nil {
+						return jobs, err
+					}
+				}
+			}
+			return jobs, nil
+		}
+	}
+}
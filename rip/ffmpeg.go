@@ -0,0 +1,77 @@
+package rip
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/cli"
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-ripper/ledger"
+	"github.com/thomasschoeftner/go-ripper/processor"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/shutdown"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+const CONF_RIPPER_FFMPEG = "ffmpeg"
+const (
+	// ffmpeg -i <input-file>.flac -vn -c:a <codec> -b:a <bitrate> <output>.mp3
+	ffmpeg_paramInput        = "-i"
+	ffmpeg_paramAudioCodec   = "-c:a"
+	ffmpeg_paramAudioBitrate = "-b:a"
+	ffmpeg_argNoVideo        = "-vn"
+)
+
+func createFFMPEGTranscoder(conf *ripper.AppConf, printf commons.FormatPrinter, workDir string) (processor.Processor, error) {
+	ffConf := conf.Rip.Audio.FFMPEG
+	if ffConf == nil {
+		return nil, fmt.Errorf("ffmpeg audio ripper is not configured")
+	}
+	timeout, err := time.ParseDuration(ffConf.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var errOut io.Writer
+	if ffConf.ShowErrorOutput {
+		errOut = os.Stderr
+	}
+	var stdOut io.Writer
+	if ffConf.ShowStandardOutput {
+		stdOut = os.Stdout
+	}
+
+	if !conf.DryRun {
+		processor.DetectToolVersion(ledger.STAGE_RIPPED, CONF_RIPPER_FFMPEG, ffConf.Path, "-version")
+	}
+
+	command := func(inFile string, outFile string) shutdown.Executable {
+		return cli.Command(ffConf.Path, timeout).
+			WithParam(ffmpeg_paramInput, inFile, "").
+			WithArgument(ffmpeg_argNoVideo).
+			WithParam(ffmpeg_paramAudioCodec, ffConf.Codec, "").
+			WithParam(ffmpeg_paramAudioBitrate, ffConf.Bitrate, "").
+			WithArgument(outFile)
+	}
+
+	return func(ti targetinfo.TargetInfo, inFile string, outFile string) error {
+		if conf.DryRun {
+			printf("dry-run - would run: %s\n", command(inFile, outFile))
+			return nil
+		}
+		tmpOut := fmt.Sprintf("%s.transcoded.%s", outFile, conf.Output.Audio)
+		removePartial := shutdown.OnAbort(fmt.Sprintf("remove partial output %s", tmpOut), func() error {
+			return os.Remove(tmpOut)
+		})
+		defer removePartial.Release()
+
+		err := shutdown.ExecuteSync(command(inFile, tmpOut), stdOut, errOut)
+		if err != nil {
+			os.Remove(tmpOut)
+			return err
+		}
+		return os.Rename(tmpOut, outFile)
+	}, nil
+}
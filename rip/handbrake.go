@@ -1,6 +1,7 @@
 package rip
 
 import (
+	"fmt"
 	"github.com/thomasschoeftner/go-cli/commons"
 	"github.com/thomasschoeftner/go-ripper/ripper"
 	"io"
@@ -8,9 +9,13 @@ import (
 	"github.com/thomasschoeftner/go-cli/cli"
 	"time"
 	"path/filepath"
+	"strconv"
 	"github.com/thomasschoeftner/go-ripper/files"
 	"github.com/thomasschoeftner/go-ripper/targetinfo"
+	"github.com/thomasschoeftner/go-ripper/ledger"
 	"github.com/thomasschoeftner/go-ripper/processor"
+	"github.com/thomasschoeftner/go-ripper/shutdown"
+	"github.com/thomasschoeftner/go-ripper/progress"
 )
 
 const CONF_RIPPER_HANDBRAKE = "handbrake"
@@ -19,6 +24,8 @@ const (
 	paramOutput             = "--output"             //mp4 output file as param
 	paramImportPreset       = "--preset-import-file" //json file as param
 	paramUsePreset          = "--preset"             //selected preset name
+	paramTitle              = "--title"              //title of disc as param
+	argMainFeature          = "--main-feature"       //select main feature title of disc
 	argOptizizeForStreaming = "--optimize"
 	argLogToJson            = "--json"
 )
@@ -39,21 +46,59 @@ func createHandbrakeRipper(conf *ripper.AppConf, printf commons.FormatPrinter, w
 		stdOut = os.Stdout
 	}
 
-	return func (ti targetinfo.TargetInfo, inFile string, outFile string) error {
-		evacuated, err := files.PrepareEvacuation(filepath.Join(workDir, files.TEMP_DIR_NAME)).Of(inFile).By(files.Moving)
-		if err != nil {
-			return err
-		}
-		defer evacuated.Restore()
+	if !conf.DryRun {
+		processor.DetectToolVersion(ledger.STAGE_RIPPED, CONF_RIPPER_HANDBRAKE, hbConf.Path, "--version")
+	}
 
-		tmpOut := evacuated.WithSuffix(".ripped")
+	command := func(inFile string, outFile string) shutdown.Executable {
 		cmd := cli.Command(hbConf.Path, timeout).WithQuotes(" ", '\'').
 		WithParam(paramImportPreset, filepath.ToSlash(hbConf.PresetsFile), "").
 		WithParam(paramUsePreset, hbConf.PresetName, "").
-		WithParam(paramInput, filepath.ToSlash(evacuated.Path()), "").
-		WithParam(paramOutput, filepath.ToSlash(tmpOut), "")
-		//printf(">>>> %s\n", cmd.String())
-		err = cmd.ExecuteSync(stdOut, errOut)
+		WithParam(paramInput, filepath.ToSlash(inFile), "").
+		WithParam(paramOutput, filepath.ToSlash(outFile), "")
+		if files.IsDisc(inFile) {
+			if hbConf.Title > 0 {
+				cmd = cmd.WithParam(paramTitle, strconv.Itoa(hbConf.Title), "")
+			} else {
+				cmd = cmd.WithArgument(argMainFeature)
+			}
+		}
+		return cmd
+	}
+
+	return func (ti targetinfo.TargetInfo, inFile string, outFile string) error {
+		if conf.DryRun {
+			printf("dry-run - would run: %s\n", command(inFile, outFile))
+			return nil
+		}
+		input := inFile
+		var tmpOut string
+		if files.IsDisc(inFile) {
+			// disc structures and images are ripped in place, as moving them could break their structure
+			name, ext := files.SplitExtension(outFile)
+			tmpOut = files.WithExtension(name+".ripped", ext)
+		} else {
+			evacuated, err := files.PrepareEvacuation(filepath.Join(workDir, files.TEMP_DIR_NAME)).Of(inFile).By(files.Moving)
+			if err != nil {
+				return err
+			}
+			restore := shutdown.OnAbort(fmt.Sprintf("restore evacuated file %s", inFile), evacuated.Restore)
+			defer restore.Run()
+			input = evacuated.Path()
+			tmpOut = evacuated.WithSuffix(".ripped")
+		}
+		removePartial := shutdown.OnAbort(fmt.Sprintf("remove partial output %s", tmpOut), func() error {
+			return os.Remove(tmpOut)
+		})
+		defer removePartial.Release()
+		// progress is reported on standard output
+		tracker := progress.Track(CONF_RIPPER_HANDBRAKE, ti.GetFullPath())
+		defer tracker.Finish()
+		var out io.Writer = tracker
+		if stdOut != nil {
+			out = io.MultiWriter(stdOut, tracker)
+		}
+		err := shutdown.ExecuteSync(command(input, tmpOut), out, errOut)
 		if err != nil {
 			return err
 		}
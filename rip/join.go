@@ -0,0 +1,121 @@
+package rip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/cli"
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/processor"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/shutdown"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+const (
+	// ffmpeg -f concat -safe 0 -i <list-of-parts>.ffmpeg -map 0 -c copy <output>.mkv
+	join_paramFormat   = "-f"
+	join_formatConcat  = "concat"
+	join_paramSafe     = "-safe"
+	join_paramInput    = "-i"
+	join_paramMap      = "-map"
+	join_paramCodec    = "-c"
+	join_argOverwrite  = "-y"
+	join_listExtension = "ffmpeg"
+	join_joinedInfix   = "joined"
+)
+
+// joiningParts joins all parts of multi-part movies into a single file in the work directory, which is then ripped as a whole
+func joiningParts(conf *ripper.AppConf, printf commons.FormatPrinter, rip processor.Processor) processor.Processor {
+	return func(ti targetinfo.TargetInfo, inFile string, outFile string) error {
+		movie, isMovie := ti.(*targetinfo.Movie)
+		if !isMovie || !movie.IsMultiPart() {
+			return rip(ti, inFile, outFile)
+		}
+
+		joinConf := conf.Rip.Video.Join
+		if joinConf == nil {
+			return errors.New("joining parts of multi-part movies is not configured")
+		}
+		timeout, err := time.ParseDuration(joinConf.Timeout)
+		if err != nil {
+			return err
+		}
+		if commons.IsStringEmptyWithSpaces(joinConf.Path) || timeout <= 0 {
+			return errors.New("path and timeout of ffmpeg for joining multi-part movies are required")
+		}
+
+		joined, err := ripper.GetProcessingArtifactPathFor(conf.WorkDirectory, movie.GetFolder(), movie.GetFile(), fmt.Sprintf("%s.%s", join_joinedInfix, files.GetExtension(movie.GetFile())))
+		if err != nil {
+			return err
+		}
+		list := files.WithExtension(joined, join_listExtension)
+		cmd := cli.Command(joinConf.Path, timeout).
+			WithParam(join_paramFormat, join_formatConcat, "").
+			WithParam(join_paramSafe, "0", "").
+			WithParam(join_paramInput, list, "").
+			WithParam(join_paramMap, "0", "").
+			WithParam(join_paramCodec, "copy", "").
+			WithArgument(join_argOverwrite).
+			WithArgument(joined)
+
+		parts := targetinfo.InputFiles(movie)
+		if conf.DryRun {
+			printf("dry-run - would join %d parts %v\n", len(parts), parts)
+			printf("dry-run - would run: %s\n", cmd)
+			return rip(ti, joined, outFile)
+		}
+
+		printf("join %d parts of %s\n", len(parts), movie.GetFullPath())
+		if err := files.CreateFolderStructure(filepath.Dir(joined)); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(list, []byte(concatList(parts)), os.ModePerm); err != nil {
+			return err
+		}
+		defer os.Remove(list)
+		defer os.Remove(joined)
+		removePartial := shutdown.OnAbort(fmt.Sprintf("remove partially joined %s", joined), func() error {
+			return os.Remove(joined)
+		})
+		defer removePartial.Release()
+
+		var errOut, stdOut io.Writer
+		if joinConf.ShowErrorOutput {
+			errOut = os.Stderr
+		}
+		if joinConf.ShowStandardOutput {
+			stdOut = os.Stdout
+		}
+		if err := shutdown.ExecuteSync(cmd, stdOut, errOut); err != nil {
+			return err
+		}
+		return rip(ti, joined, outFile)
+	}
+}
+
+// concatList builds the input of the ffmpeg concat demuxer
+func concatList(parts []string) string {
+	lines := []string{}
+	for _, part := range parts {
+		lines = append(lines, fmt.Sprintf("file '%s'", strings.Replace(part, "'", `'\''`, -1)))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// lazy re-use of input files is impossible for multi-part movies, as they need to be joined first
+func neverLazyForMultiPart(checkLazy processor.CheckLazy) processor.CheckLazy {
+	return func(ti targetinfo.TargetInfo) bool {
+		if movie, isMovie := ti.(*targetinfo.Movie); isMovie && movie.IsMultiPart() {
+			return false
+		}
+		return checkLazy(ti)
+	}
+}
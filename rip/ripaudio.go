@@ -0,0 +1,45 @@
+package rip
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/ledger"
+	"github.com/thomasschoeftner/go-ripper/processor"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+var AudioRipperFactories map[string]RipperFactory
+
+func init() {
+	AudioRipperFactories = make(map[string]RipperFactory)
+	AudioRipperFactories[CONF_RIPPER_FFMPEG] = createFFMPEGTranscoder
+}
+
+func RipAudio(ctx task.Context) task.HandlerFunc {
+	conf := ctx.Config.(*ripper.AppConf)
+	if conf.Rip.Audio == nil {
+		return ripper.ErrorHandler(errors.New("audio ripping is not configured"))
+	}
+	ripperType := conf.Rip.Audio.Ripper
+
+	var rip processor.Processor
+	var err error
+
+	rf := AudioRipperFactories[ripperType]
+	if rf == nil {
+		err = fmt.Errorf("unknown audio ripper configured: \"%s\"", ripperType)
+	} else {
+		rip, err = rf(conf, ctx.Printf, conf.WorkDirectory)
+	}
+
+	if err != nil {
+		return ripper.ErrorHandler(err)
+	} else {
+		return ripper.ForMedia(processor.Process(ctx, rip, ledger.STAGE_RIPPED, ripperType,
+			processor.DefaultCheckLazy(ctx.RunLazy, conf.Output.Audio),
+			processor.DefaultInputFileFor(conf.Rip.Audio.AllowedInputExtensions),
+			processor.DefaultOutputFileFor(conf.Output.Audio)), ripper.MEDIA_AUDIO)
+	}
+}
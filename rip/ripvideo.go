@@ -5,7 +5,10 @@ import (
 	"github.com/thomasschoeftner/go-ripper/ripper"
 	"fmt"
 	"github.com/thomasschoeftner/go-ripper/processor"
+	"github.com/thomasschoeftner/go-ripper/ledger"
 	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
 )
 
 type RipperFactory func(conf *ripper.AppConf, printf commons.FormatPrinter, workDir string) (processor.Processor, error)
@@ -34,9 +37,20 @@ func RipVideo(ctx task.Context) task.HandlerFunc {
 	if err != nil {
 		return ripper.ErrorHandler(err)
 	} else {
-		return processor.Process(ctx, rip, ripperType,
-			processor.DefaultCheckLazy(ctx.RunLazy, conf.Output.Video),
-			processor.DefaultInputFileFor(conf.Rip.Video.AllowedInputExtensions),
-			processor.DefaultOutputFileFor(conf.Output.Video))
+		return ripper.ForMedia(processor.Process(ctx, joiningParts(conf, ctx.Printf.WithIndent(2), rip), ledger.STAGE_RIPPED, ripperType,
+			neverLazyForMultiPart(processor.DefaultCheckLazy(ctx.RunLazy, conf.Output.Video)),
+			discOrDefaultInputFileFor(conf.Rip.Video.AllowedInputExtensions),
+			processor.DefaultOutputFileFor(conf.Output.Video)), ripper.MEDIA_VIDEO)
+	}
+}
+
+// disc structures and images are ripped directly from the original input
+func discOrDefaultInputFileFor(allowedInputExtensions []string) processor.InputFile {
+	defaultInputFile := processor.DefaultInputFileFor(allowedInputExtensions)
+	return func(ti targetinfo.TargetInfo, workDir string) (string, error) {
+		if ti != nil && files.IsDisc(ti.GetFullPath()) {
+			return ti.GetFullPath(), nil
+		}
+		return defaultInputFile(ti, workDir)
 	}
 }
@@ -2,6 +2,8 @@ package ripper
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/thomasschoeftner/go-cli/config"
@@ -9,9 +11,13 @@ import (
 	"github.com/thomasschoeftner/go-cli/task"
 )
 
-func GetConfig(configFile string) *AppConf {
+// GetConfig reads the main config file and merges the profile file and environment variables on top of it
+// (precedence: environment variables > profile file > main config file)
+func GetConfig(configFile string, profileFile string) *AppConf {
 	conf := AppConf{}
-	require.NotFailed(config.FromFile(&conf, configFile, map[string]string{}))
+	merged, err := mergeProfile(configFile, profileFile, os.LookupEnv)
+	require.NotFailed(err)
+	require.NotFailed(config.FromString(&conf, merged, map[string]string{}))
 	require.NotFailed(validateConfig(&conf))
 	return &conf
 }
@@ -31,6 +37,7 @@ func validateConfig(c *AppConf) error {
 		return nil
 	}
 
+	c.StoragePath = strings.Trim(c.StoragePath, " ")
 	c.WorkDirectory = strings.Trim(c.WorkDirectory, " ")
 	if err := validatePath(c.WorkDirectory, "workDirectory"); err != nil {
 		return err
@@ -48,10 +55,77 @@ func validateConfig(c *AppConf) error {
 		return err
 	}
 
+	//validate that workDirectory, metaInfoRepo, and outputDirectory do not overlap
+	dirs := c.storageDirectories()
+	for i := 0; i < len(dirs); i++ {
+		for j := i + 1; j < len(dirs); j++ {
+			overlapping, err := overlap(dirs[i].path, dirs[j].path)
+			if err != nil {
+				return err
+			}
+			if overlapping {
+				return fmt.Errorf("[config error] \"%s\" and \"%s\" must not overlap", dirs[i].fieldName, dirs[j].fieldName)
+			}
+		}
+	}
 	return nil
 }
 
+type storageDirectory struct {
+	fieldName string
+	path      string
+}
+
+func (c *AppConf) storageDirectories() []storageDirectory {
+	return []storageDirectory{
+		{"workDirectory", c.WorkDirectory},
+		{"metaInfoRepo", c.MetaInfoRepo},
+		{"outputDirectory", c.OutputDirectory}}
+}
+
+// StateDirectory is the location of state which has to survive runs with varying work directories (e.g. the ledger).
+// Without storagePath, state is kept in the work directory - which then has to be the same for all runs.
+func (c *AppConf) StateDirectory() string {
+	if len(c.StoragePath) > 0 {
+		return c.StoragePath
+	}
+	return c.WorkDirectory
+}
+
+// ValidateTarget rejects targets which equal, contain, or are located inside workDirectory, metaInfoRepo, or outputDirectory
+func ValidateTarget(c *AppConf, target string) error {
+	for _, dir := range c.storageDirectories() {
+		overlapping, err := overlap(target, dir.path)
+		if err != nil {
+			return err
+		}
+		if overlapping {
+			return fmt.Errorf("target \"%s\" must neither be, contain, nor be located inside %s \"%s\"", target, dir.fieldName, dir.path)
+		}
+	}
+	return nil
+}
+
+// overlap checks if two paths are equal, or if one of them contains the other
+func overlap(a string, b string) (bool, error) {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false, err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false, err
+	}
+	return isWithin(absA, absB) || isWithin(absB, absA), nil
+}
+
+func isWithin(path string, folder string) bool {
+	rel, err := filepath.Rel(folder, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 type AppConf struct {
+	StoragePath     string // keeps state across runs (e.g. the ledger) - optional
 	IgnorePrefix    string
 	WorkDirectory   string
 	MetaInfoRepo    string
@@ -62,33 +136,71 @@ type AppConf struct {
 	Resolve         *ResolveConfig
 	Rip             *RipConfig
 	Tag             *TagConfig
+	RemoveOriginal  *RemoveOriginalConfig
+	Report          *ReportConfig
+	Watch           *WatchConfig
+	Api             *ApiConfig
+	DryRun          bool `json:"-"` //set from command line - plan processing without modifying any files
 }
 
 type OutputConfig struct {
 	InvalidCharactersInFileName string
 	Video                       string
+	Audio                       string
+	Naming                      *NamingConfig
+	Collision                   string // policy for existing files at the destination
+}
+
+// NamingConfig defines the location of tagged videos relative to the output directory.
+// Movie and Episode are text/template patterns (without file extension) which take precedence over the preset.
+type NamingConfig struct {
+	Preset  string
+	Movie   string
+	Episode string
 }
 
 type ScanConfigGroup struct {
 	Video *ScanConfig
+	Audio *ScanConfig
 }
 
 type ScanConfig struct {
 	IdPattern         string
 	CollectionPattern string
 	ItemNoPattern     string
+	TitlePattern      string // title of targets without id - used to look up the id (e.g. "The Matrix (1999)")
+	YearPattern       string
 	Patterns          []string
 	AllowSpaces       bool
 	AllowedExtensions []string
+	Discs             bool   // recognise DVD/Blu-ray folder structures and ISO images as single targets
+	PartPattern       string // detects the part number of multi-part movies in file names (without extension) as 1st sub-match
+	Report            string // JSON file listing all skipped files and the reason why - optional
+	Sidecars          *SidecarConfig
+}
+
+// SidecarConfig defines the extensions of external subtitle and audio files muxed into videos - optional
+type SidecarConfig struct {
+	Subtitles []string
+	Audio     []string
 }
 
 type ResolveConfig struct {
 	Video *VideoResolveConfig
+	Audio *AudioResolveConfig
 }
 
 type VideoResolveConfig struct {
 	Resolver string
 	Omdb     *OmdbConfig
+	Tmdb     *TmdbConfig
+	Search   *TitleSearchConfig
+}
+
+// TitleSearchConfig defines how the ids of targets without id in their path are looked up by title and year
+type TitleSearchConfig struct {
+	MinConfidence float64 // min. confidence (0..1) of the best match - ambiguous or less confident matches need to be reviewed
+	NeedsReview   string  // file listing all targets to be reviewed - relative to storagePath (or the work directory without storagePath), if not absolute
 }
 
 type OmdbConfig struct {
@@ -96,28 +208,72 @@ type OmdbConfig struct {
 	Retries      int
 	MovieQuery   string
 	SeriesQuery  string
+	SeasonQuery  string
 	EpisodeQuery string
+	SearchQuery  string
 	OmdbTokens   []string
 }
 
+// TmdbConfig defines access to themoviedb.org - either an API key (v3) or a read access token (v4) is required.
+// Meta-info is kept in the metaInfoRepo by IMDb id only, regardless of its language - after changing the language,
+// clear the metaInfoRepo or configure a separate one per language (e.g. "${storagePath}/metainfo-de-DE"), as lazy runs re-use meta-info fetched before.
+type TmdbConfig struct {
+	Timeout     int
+	Retries     int
+	ApiUrl      string // e.g. "https://api.themoviedb.org/3"
+	ApiKey      string
+	AccessToken string
+	Language    string // language of titles, plots and posters (e.g. "de-DE") - tmdb falls back to the original language for missing translations
+	PosterSize  string // size of fetched posters (e.g. "w500", "w780" or "original") - "original" is used if tmdb does not provide the size
+}
+
+type AudioResolveConfig struct {
+	Resolver    string
+	MusicBrainz *MusicBrainzConfig
+}
+
+type MusicBrainzConfig struct {
+	Timeout    int
+	Retries    int
+	AlbumQuery string
+	CoverQuery string
+	UserAgent  string
+}
+
 type RipConfig struct {
 	Video *VideoRipConfig
+	Audio *AudioRipConfig
 }
 
 type VideoRipConfig struct {
 	Ripper                 string
 	AllowedInputExtensions []string
 	Handbrake              *HandbrakeConfig
+	Join                   *FFMPEGConfig // joins multi-part movies before ripping
 }
 
 type HandbrakeConfig struct {
 	CommandlineToolConfig
 	PresetsFile string
 	PresetName  string
+	Title       int // title to rip from DVD/Blu-ray discs - the main feature is detected if 0
+}
+
+type AudioRipConfig struct {
+	Ripper                 string
+	AllowedInputExtensions []string
+	FFMPEG                 *FFMPEGTranscoderConfig
+}
+
+type FFMPEGTranscoderConfig struct {
+	CommandlineToolConfig
+	Codec   string
+	Bitrate string
 }
 
 type TagConfig struct {
 	Video *VideoTagConfig
+	Audio *AudioTagConfig
 }
 
 type VideoTagConfig struct {
@@ -125,6 +281,11 @@ type VideoTagConfig struct {
 	FFMPEG *FFMPEGConfig
 }
 
+type AudioTagConfig struct {
+	Tagger string
+	FFMPEG *FFMPEGConfig
+}
+
 type FFMPEGConfig struct {
 	CommandlineToolConfig
 }
@@ -135,3 +296,29 @@ type CommandlineToolConfig struct {
 	ShowErrorOutput    bool
 	ShowStandardOutput bool
 }
+
+type RemoveOriginalConfig struct {
+	Mode         string  //"delete", "trash"
+	MinSizeRatio float64 //minimum size of tagged output relative to the original input - only the size is checked, not the duration
+	Trash        *TrashConfig
+}
+
+type TrashConfig struct {
+	Folder    string
+	Retention string //e.g. "720h" - trashed files are kept forever if empty
+}
+
+type ReportConfig struct {
+	File   string //JSON report written at the end of each run
+	Stream string //JSON lines appended while the run progresses - disabled if empty
+}
+
+type WatchConfig struct {
+	StableFor string //e.g. "30s" - files are processed once their size did not change for this period
+	Interval  string //e.g. "5s" - period between polls of the watched folders
+}
+
+type ApiConfig struct {
+	Address   string //e.g. "127.0.0.1:8642" - bind to localhost unless the API should be reachable from other hosts
+	QueueSize int    //max. number of jobs waiting for processing
+}
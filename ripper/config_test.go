@@ -57,4 +57,39 @@ func TestValidateConfig(t *testing.T) {
 		assert.StringsEqual("x/y/z", c.MetaInfoRepo)
 		assert.StringsEqual("/k/l/m", c.OutputDirectory)
 	})
+
+	t.Run("reject overlapping workDir and outputDir", func(t *testing.T) {
+		c := &AppConf{WorkDirectory: "/storage/out/work", MetaInfoRepo: "/storage/repo", OutputDirectory: "/storage/out"}
+		test.AssertOn(t).ExpectError("expected error when workDir is located inside outputDir, but got none")(validateConfig(c))
+	})
+
+	t.Run("reject equal metaInfoRepo and outputDir", func(t *testing.T) {
+		c := &AppConf{WorkDirectory: "/storage/work", MetaInfoRepo: "/storage/out/", OutputDirectory: "/storage/out"}
+		test.AssertOn(t).ExpectError("expected error when metaInfoRepo equals outputDir, but got none")(validateConfig(c))
+	})
+
+	t.Run("accept directories with common prefix", func(t *testing.T) {
+		c := &AppConf{WorkDirectory: "/storage/work", MetaInfoRepo: "/storage/work-repo", OutputDirectory: "/storage/output"}
+		test.AssertOn(t).NotError(validateConfig(c))
+	})
+}
+
+func TestValidateTarget(t *testing.T) {
+	c := &AppConf{WorkDirectory: "/storage/work", MetaInfoRepo: "/storage/repo", OutputDirectory: "/storage/output"}
+
+	t.Run("reject target inside workDir", func(t *testing.T) {
+		test.AssertOn(t).ExpectError("expected error for target inside workDir, but got none")(ValidateTarget(c, "/storage/work/in/movie.avi"))
+	})
+
+	t.Run("reject target equal to metaInfoRepo", func(t *testing.T) {
+		test.AssertOn(t).ExpectError("expected error for target equal to metaInfoRepo, but got none")(ValidateTarget(c, "/storage/repo"))
+	})
+
+	t.Run("reject target containing outputDir", func(t *testing.T) {
+		test.AssertOn(t).ExpectError("expected error for target containing outputDir, but got none")(ValidateTarget(c, "/storage"))
+	})
+
+	t.Run("accept unrelated target", func(t *testing.T) {
+		test.AssertOn(t).NotError(ValidateTarget(c, "/media/input/movie.avi"))
+	})
 }
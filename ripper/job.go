@@ -3,6 +3,8 @@ package ripper
 import (
 	"github.com/thomasschoeftner/go-cli/pipeline"
 	"fmt"
+	"os"
+	"github.com/thomasschoeftner/go-cli/commons"
 	"github.com/thomasschoeftner/go-cli/task"
 	"path/filepath"
 	"strings"
@@ -10,13 +12,48 @@ import (
 )
 
 const (
-	JobField_Path = "path" //location of target file
+	JobField_Path         = "path"         //location of target file
+	JobField_Media        = "media"        //type of media found at path - set by scanners
+	JobField_Unrecognized = "unrecognized" //media types of scanners which did not recognize the file at path - set by scanners
+)
+
+const (
+	MEDIA_AUDIO = "audio"
+	MEDIA_VIDEO = "video"
 )
 
 func GetTargetFileFromJob(job task.Job) string {
 	return job[JobField_Path]
 }
 
+func GetMediaFromJob(job task.Job) string {
+	return job[JobField_Media]
+}
+
+// ForMedia restricts a handler to jobs of specific media types (or any media type if none is given).
+// Jobs of other media types, and jobs for folders which have not been scanned into targets, are passed on untouched.
+func ForMedia(handle task.HandlerFunc, media ...string) task.HandlerFunc {
+	return func(job task.Job) ([]task.Job, error) {
+		if !IsTargetJobFor(job, media...) {
+			return []task.Job{job}, nil
+		}
+		return handle(job)
+	}
+}
+
+// IsTargetJobFor checks if a job refers to a single target of specific media types (or any media type if none is given)
+func IsTargetJobFor(job task.Job, media ...string) bool {
+	jobMedia := GetMediaFromJob(job)
+	if len(jobMedia) == 0 {
+		if len(job[JobField_Unrecognized]) > 0 {
+			return false // scanned, but no target
+		}
+		info, err := os.Stat(GetTargetFileFromJob(job))
+		return !(err == nil && info.IsDir())
+	}
+	return len(media) == 0 || commons.IsStringAmong(jobMedia, media)
+}
+
 func GetWorkPathForJob(workDir string, job task.Job) (string, error) {
 	folder, _ := filepath.Split(GetTargetFileFromJob(job))
 	return GetWorkPathForTargetFolder(workDir, folder)
@@ -50,4 +50,51 @@ func TestGetProcessingArtifactPath(t *testing.T) {
 	assert := test.AssertOn(t)
 	assert.NotError(err)
 	assert.StringsEqual(expectedPath, artifactPath)
-}
\ No newline at end of file
+}
+func TestForMedia(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+
+	handled := 0
+	handler := ForMedia(func(job task.Job) ([]task.Job, error) {
+		handled++
+		return []task.Job{}, nil
+	}, MEDIA_VIDEO)
+
+	t.Run("pass on unscanned folders", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		handled = 0
+		jobs, err := handler(task.Job{JobField_Path: dir})
+		assert.NotError(err)
+		assert.IntsEqual(1, len(jobs))
+		assert.IntsEqual(0, handled)
+	})
+
+	t.Run("pass on jobs of other media", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		handled = 0
+		jobs, err := handler(task.Job{JobField_Path: filepath.Join(dir, "x.flac"), JobField_Media: MEDIA_AUDIO})
+		assert.NotError(err)
+		assert.IntsEqual(1, len(jobs))
+		assert.IntsEqual(0, handled)
+	})
+
+	t.Run("pass on files not recognized by scanners", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		handled = 0
+		jobs, err := handler(task.Job{JobField_Path: filepath.Join(dir, "x.txt"), JobField_Unrecognized: MEDIA_VIDEO})
+		assert.NotError(err)
+		assert.IntsEqual(1, len(jobs))
+		assert.IntsEqual(0, handled)
+	})
+
+	t.Run("handle jobs of matching media and unscanned files", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		handled = 0
+		_, err := handler(task.Job{JobField_Path: filepath.Join(dir, "x.avi"), JobField_Media: MEDIA_VIDEO})
+		assert.NotError(err)
+		_, err = handler(task.Job{JobField_Path: filepath.Join(dir, "y.avi")})
+		assert.NotError(err)
+		assert.IntsEqual(2, handled)
+	})
+}
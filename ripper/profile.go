@@ -0,0 +1,169 @@
+package ripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// environment variables override values of the profile file, which override values of the main config
+type envOverride struct {
+	name   string
+	path   []string
+	isList bool //comma-separated list
+}
+
+var envOverrides = []envOverride{
+	{"GO_RIPPER_OMDB_TOKENS", []string{"resolve", "video", "omdb", "omdbTokens"}, true},
+	{"GO_RIPPER_TMDB_API_KEY", []string{"resolve", "video", "tmdb", "apiKey"}, false},
+	{"GO_RIPPER_TMDB_ACCESS_TOKEN", []string{"resolve", "video", "tmdb", "accessToken"}, false},
+	{"GO_RIPPER_HANDBRAKE_PATH", []string{"profile", "handbrake", "path"}, false},
+	{"GO_RIPPER_HANDBRAKE_PRESETS_FILE", []string{"profile", "handbrake", "presetsFile"}, false},
+	{"GO_RIPPER_HANDBRAKE_PRESET", []string{"profile", "handbrake", "preset"}, false},
+	{"GO_RIPPER_FFMPEG_PATH", []string{"profile", "ffmpeg", "path"}, false},
+}
+
+// config keys containing one of these (case-insensitive) are masked when printing the config
+var secretKeys = []string{"token", "secret", "password", "apikey"}
+
+const secretMask = "********"
+
+// mergeProfile reads the main config and merges the profile file (if it exists) and environment variables on top of it
+func mergeProfile(configFile string, profileFile string, lookupEnv func(string) (string, bool)) (string, error) {
+	conf, err := readJsonObject(configFile)
+	if err != nil {
+		return "", err
+	}
+
+	if len(profileFile) > 0 {
+		if _, err := os.Stat(profileFile); err == nil {
+			profile, err := readJsonObject(profileFile)
+			if err != nil {
+				return "", err
+			}
+			merge(conf, profile)
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+
+	for _, env := range envOverrides {
+		if val, isSet := lookupEnv(env.name); isSet {
+			if env.isList {
+				set(conf, env.path, splitList(val))
+			} else {
+				set(conf, env.path, val)
+			}
+		}
+	}
+
+	// keep special characters (e.g. in invalidCharactersInFileName) readable
+	merged := &bytes.Buffer{}
+	encoder := json.NewEncoder(merged)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(conf); err != nil {
+		return "", err
+	}
+	return merged.String(), nil
+}
+
+func readJsonObject(file string) (map[string]interface{}, error) {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// merge copies all values from src to dst - nested objects are merged, all other values are replaced
+func merge(dst map[string]interface{}, src map[string]interface{}) {
+	for key, srcVal := range src {
+		srcObj, srcIsObj := srcVal.(map[string]interface{})
+		dstObj, dstIsObj := dst[key].(map[string]interface{})
+		if srcIsObj && dstIsObj {
+			merge(dstObj, srcObj)
+		} else {
+			dst[key] = srcVal
+		}
+	}
+}
+
+func set(obj map[string]interface{}, path []string, val interface{}) {
+	for _, key := range path[:len(path)-1] {
+		child, isObj := obj[key].(map[string]interface{})
+		if !isObj {
+			child = map[string]interface{}{}
+			obj[key] = child
+		}
+		obj = child
+	}
+	obj[path[len(path)-1]] = val
+}
+
+func splitList(val string) []interface{} {
+	list := []interface{}{}
+	for _, elem := range strings.Split(val, ",") {
+		if elem = strings.TrimSpace(elem); len(elem) > 0 {
+			list = append(list, elem)
+		}
+	}
+	return list
+}
+
+// Masked returns the effective config as indented JSON with all secrets masked
+func (c *AppConf) Masked() (string, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return "", err
+	}
+	masked := &bytes.Buffer{}
+	encoder := json.NewEncoder(masked)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(mask(generic, false)); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(masked.String()), nil
+}
+
+func mask(val interface{}, isSecret bool) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = mask(child, isSecret || isSecretKey(key))
+		}
+		return v
+	case []interface{}:
+		for idx, child := range v {
+			v[idx] = mask(child, isSecret)
+		}
+		return v
+	case string:
+		if isSecret && len(v) > 0 {
+			return secretMask
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
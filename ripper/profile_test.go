@@ -0,0 +1,92 @@
+package ripper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/config"
+	"github.com/thomasschoeftner/go-cli/test"
+)
+
+const mainConf = `{
+  "profile" : { "handbrake" : { "path" : "HandBrakeCLI", "preset" : "fast" } },
+  "workDirectory" : "/work",
+  "resolve" : { "video" : { "resolver" : "omdb", "omdb" : { "timeout" : 5, "omdbTokens" : [] }, "tmdb" : { "apiKey" : "", "accessToken" : "" } } },
+  "rip" : { "video" : { "handbrake" : { "path" : "${profile.handbrake.path}", "presetName" : "${profile.handbrake.preset}" } } }
+}`
+
+const profileConf = `{
+  "profile" : { "handbrake" : { "path" : "/opt/handbrake/HandBrakeCLI" } },
+  "resolve" : { "video" : { "omdb" : { "omdbTokens" : ["profile-token"] }, "tmdb" : { "apiKey" : "profile-key" } } }
+}`
+
+func writeConf(t *testing.T, dir string, name string, content string) string {
+	file := filepath.Join(dir, name)
+	test.CheckError(t, ioutil.WriteFile(file, []byte(content), os.ModePerm))
+	return file
+}
+
+func readMerged(assert *test.Assertion, configFile string, profileFile string, env map[string]string) *AppConf {
+	merged, err := mergeProfile(configFile, profileFile, func(name string) (string, bool) {
+		val, isSet := env[name]
+		return val, isSet
+	})
+	assert.NotError(err)
+	conf := &AppConf{}
+	assert.NotError(config.FromString(conf, merged, nil))
+	return conf
+}
+
+func TestMergeProfile(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	configFile := writeConf(t, dir, "main.conf", mainConf)
+	profileFile := writeConf(t, dir, "main.profile", profileConf)
+
+	t.Run("main config only if profile is missing", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		conf := readMerged(assert, configFile, filepath.Join(dir, "missing.profile"), nil)
+		assert.StringsEqual("HandBrakeCLI", conf.Rip.Video.Handbrake.Path)
+		assert.IntsEqual(0, len(conf.Resolve.Video.Omdb.OmdbTokens))
+	})
+
+	t.Run("profile overrides main config", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		conf := readMerged(assert, configFile, profileFile, nil)
+		assert.StringsEqual("/opt/handbrake/HandBrakeCLI", conf.Rip.Video.Handbrake.Path)
+		assert.StringsEqual("fast", conf.Rip.Video.Handbrake.PresetName)
+		assert.StringSlicesEqual([]string{"profile-token"}, conf.Resolve.Video.Omdb.OmdbTokens)
+		assert.IntsEqual(5, conf.Resolve.Video.Omdb.Timeout)
+		assert.StringsEqual("profile-key", conf.Resolve.Video.Tmdb.ApiKey)
+		assert.StringsEqual("", conf.Resolve.Video.Tmdb.AccessToken)
+	})
+
+	t.Run("environment overrides profile", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		conf := readMerged(assert, configFile, profileFile, map[string]string{
+			"GO_RIPPER_OMDB_TOKENS":       "env-token-1, env-token-2",
+			"GO_RIPPER_HANDBRAKE_PATH":    "/usr/bin/HandBrakeCLI",
+			"GO_RIPPER_TMDB_API_KEY":      "env-key",
+			"GO_RIPPER_TMDB_ACCESS_TOKEN": "env-access-token"})
+		assert.StringsEqual("/usr/bin/HandBrakeCLI", conf.Rip.Video.Handbrake.Path)
+		assert.StringSlicesEqual([]string{"env-token-1", "env-token-2"}, conf.Resolve.Video.Omdb.OmdbTokens)
+		assert.StringsEqual("env-key", conf.Resolve.Video.Tmdb.ApiKey)
+		assert.StringsEqual("env-access-token", conf.Resolve.Video.Tmdb.AccessToken)
+	})
+}
+
+func TestMasked(t *testing.T) {
+	assert := test.AssertOn(t)
+	conf := &AppConf{
+		WorkDirectory: "/work",
+		Resolve:       &ResolveConfig{Video: &VideoResolveConfig{Resolver: "omdb", Omdb: &OmdbConfig{OmdbTokens: []string{"very-secret"}}}}}
+
+	masked, err := conf.Masked()
+	assert.NotError(err)
+	assert.False("expected token to be masked")(strings.Contains(masked, "very-secret"))
+	assert.True("expected masked token")(strings.Contains(masked, secretMask))
+	assert.True("expected other values to be printed")(strings.Contains(masked, "/work"))
+}
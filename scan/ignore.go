@@ -0,0 +1,209 @@
+package scan
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-ripper/files"
+)
+
+// IGNORE_FILE_NAME is the name of per-folder files listing gitignore-style globs of files and folders to ignore when scanning.
+// The globs apply to the folder containing the file and all its sub-folders.
+const IGNORE_FILE_NAME = ".ripperignore"
+
+// IgnoreRules decide which files and folders are skipped when scanning or watching folders
+type IgnoreRules struct {
+	prefix            string
+	allowedExtensions []string
+	rules             map[string][]*ignoreRule // rules of ignore files by absolute path of their folder
+}
+
+func NewIgnoreRules(prefix string, allowedExtensions []string) *IgnoreRules {
+	return &IgnoreRules{prefix: prefix, allowedExtensions: allowedExtensions, rules: map[string][]*ignoreRule{}}
+}
+
+// Load reads the ignore file of a folder, if there is one
+func (ir *IgnoreRules) Load(folder string) error {
+	f, err := os.Open(filepath.Join(folder, IGNORE_FILE_NAME))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	ir.add(folder, lines...)
+	return nil
+}
+
+// LoadAncestors reads the ignore files of all folders above a scanned path, up to the root of the file system.
+// The ignore files of the scanned path itself and its sub-folders are loaded when visiting them.
+func (ir *IgnoreRules) LoadAncestors(scanPath string) error {
+	folder := filepath.Dir(absPath(scanPath))
+	for {
+		if err := ir.Load(folder); err != nil && !os.IsPermission(err) {
+			return err
+		}
+		parent := filepath.Dir(folder)
+		if parent == folder {
+			return nil
+		}
+		folder = parent
+	}
+}
+
+// add adds rules in the format of ignore files for a folder
+func (ir *IgnoreRules) add(folder string, lines ...string) {
+	folder = absPath(folder)
+	for _, line := range lines {
+		if rule := parseIgnoreRule(line); rule != nil {
+			ir.rules[folder] = append(ir.rules[folder], rule)
+		}
+	}
+}
+
+// FolderReason returns why a folder and all its contents are ignored, or an empty string if it is not
+func (ir *IgnoreRules) FolderReason(path string) string {
+	if ir.hasPrefix(filepath.Base(path)) {
+		return SKIPPED_IGNORE_PREFIX
+	}
+	if ir.matches(path, true) {
+		return SKIPPED_IGNORE_FILE
+	}
+	return ""
+}
+
+// FileReason returns why a file is ignored, or an empty string if it is not.
+// Files in ignored folders are not checked again, as ignored folders are not scanned at all.
+func (ir *IgnoreRules) FileReason(path string) string {
+	file := filepath.Base(path)
+	if ir.hasPrefix(file) {
+		return SKIPPED_IGNORE_PREFIX
+	}
+	if ir.matches(path, false) {
+		return SKIPPED_IGNORE_FILE
+	}
+	if _, ext := files.SplitExtension(file); !commons.IsStringAmong(ext, ir.allowedExtensions) {
+		return SKIPPED_EXTENSION
+	}
+	return ""
+}
+
+func (ir *IgnoreRules) hasPrefix(name string) bool {
+	return len(ir.prefix) > 0 && strings.HasPrefix(name, ir.prefix)
+}
+
+// matches applies the rules of all ignore files above the path - rules of deeper folders take precedence, the last matching rule wins
+func (ir *IgnoreRules) matches(p string, isDir bool) bool {
+	p = absPath(p)
+	folders := []string{}
+	for folder := range ir.rules {
+		if strings.HasPrefix(p, strings.TrimSuffix(folder, string(filepath.Separator))+string(filepath.Separator)) { // the file system root ends with a separator already
+			folders = append(folders, folder)
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool { return len(folders[i]) < len(folders[j]) })
+
+	ignored := false
+	for _, folder := range folders {
+		rel, err := filepath.Rel(folder, p)
+		if err != nil {
+			continue
+		}
+		for _, rule := range ir.rules[folder] {
+			if rule.matches(filepath.ToSlash(rel), isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// absPath makes rules of ignore files apply regardless of whether paths are passed relative or absolute
+func absPath(p string) string {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return filepath.Clean(p)
+	}
+	return abs
+}
+
+// ignoreRule is a single gitignore-style glob, e.g. "@eaDir/", "*.sample.mkv", "/extras", "**/trailers" or "!keep.mkv"
+type ignoreRule struct {
+	pattern  string
+	negate   bool // re-includes matching paths
+	dirOnly  bool // matches folders only
+	anchored bool // matched against the path relative to the ignore file instead of the name only
+}
+
+// parseIgnoreRule returns nil for empty lines and comments
+func parseIgnoreRule(line string) *ignoreRule {
+	line = strings.TrimRight(line, " \t\r")
+	if len(line) == 0 || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	rule := &ignoreRule{}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if len(line) == 0 {
+		return nil
+	}
+	rule.pattern = line
+	return rule
+}
+
+// matches checks a "/"-separated path relative to the folder of the ignore file
+func (r *ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		matched, _ := path.Match(r.pattern, path.Base(rel))
+		return matched
+	}
+	return matchSegments(strings.Split(r.pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches path segments against glob segments - "**" matches any number of segments
+func matchSegments(patterns []string, segments []string) bool {
+	if len(patterns) == 0 {
+		return len(segments) == 0
+	}
+	if patterns[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(patterns[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	matched, _ := path.Match(patterns[0], segments[0])
+	return matched && matchSegments(patterns[1:], segments[1:])
+}
@@ -0,0 +1,116 @@
+package scan
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+func TestIgnorePrefix(t *testing.T) {
+	assert := test.AssertOn(t)
+	ignore := NewIgnoreRules(".", []string{"valid"})
+	assert.StringsEqual("", ignore.FileReason("a/b/c.valid"))
+	assert.StringsEqual(SKIPPED_EXTENSION, ignore.FileReason("a/b/c.invalid"))
+	assert.StringsEqual(SKIPPED_IGNORE_PREFIX, ignore.FileReason("a/b/.c.valid"))
+	assert.StringsEqual(SKIPPED_IGNORE_PREFIX, ignore.FolderReason("a/.b"))
+	assert.StringsEqual("", ignore.FolderReason("a/b"))
+}
+
+func TestNoIgnorePrefix(t *testing.T) {
+	assert := test.AssertOn(t)
+	ignore := NewIgnoreRules("", []string{"valid"})
+	assert.StringsEqual("", ignore.FileReason("a/b/c.valid"))
+	assert.StringsEqual("", ignore.FolderReason("a/b"))
+}
+
+func TestIgnoreRules(t *testing.T) {
+	ignore := NewIgnoreRules(".", []string{"mkv"})
+	ignore.add("in",
+		"# comment",
+		"",
+		"@eaDir/",
+		"*.sample.mkv",
+		"!keep.sample.mkv",
+		"/extras",
+		"docs/**/trailers/")
+	ignore.add("in/tt123", "keep.sample.mkv", "s01/e0?.mkv")
+
+	folders := map[string]string{
+		"in/@eaDir":                    SKIPPED_IGNORE_FILE,
+		"in/tt123/@eaDir":              SKIPPED_IGNORE_FILE,
+		"in/extras":                    SKIPPED_IGNORE_FILE,
+		"in/tt123/extras":              "",
+		"in/docs/trailers":             SKIPPED_IGNORE_FILE,
+		"in/docs/2019/nature/trailers": SKIPPED_IGNORE_FILE,
+		"in/tt123/docs/trailers":       "",
+		"in/.snapshot":                 SKIPPED_IGNORE_PREFIX,
+		"other/@eaDir":                 "",
+	}
+	for folder, expected := range folders {
+		if reason := ignore.FolderReason(folder); reason != expected {
+			t.Errorf("expected folder \"%s\" to be ignored for \"%s\", but found \"%s\"", folder, expected, reason)
+		}
+	}
+
+	fls := map[string]string{
+		"in/movie.mkv":             "",
+		"in/@eaDir":                SKIPPED_EXTENSION, // folder rules do not match files
+		"in/movie.sample.mkv":      SKIPPED_IGNORE_FILE,
+		"in/keep.sample.mkv":       "",
+		"in/tt123/keep.sample.mkv": SKIPPED_IGNORE_FILE,
+		"in/tt123/s01/e01.mkv":     SKIPPED_IGNORE_FILE,
+		"in/tt123/s01/e10.mkv":     "",
+		"in/tt123/s02/e01.mkv":     "",
+		"in/tt123/movie.avi":       SKIPPED_EXTENSION,
+	}
+	for file, expected := range fls {
+		if reason := ignore.FileReason(file); reason != expected {
+			t.Errorf("expected file \"%s\" to be ignored for \"%s\", but found \"%s\"", file, expected, reason)
+		}
+	}
+}
+
+func TestLoadIgnoreFile(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+
+	ignore := NewIgnoreRules(".", []string{"mkv"})
+	assert.NotError(ignore.Load(dir))
+	assert.StringsEqual("", ignore.FolderReason(filepath.Join(dir, "@eaDir")))
+
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, IGNORE_FILE_NAME), []byte("@eaDir/\r\n#recycle/\r\n"), os.ModePerm))
+	assert.NotError(ignore.Load(dir))
+	assert.StringsEqual(SKIPPED_IGNORE_FILE, ignore.FolderReason(filepath.Join(dir, "@eaDir")))
+	assert.StringsEqual("", ignore.FolderReason(filepath.Join(dir, "#recycle")))
+}
+
+func TestLoadIgnoreFilesAboveScannedPath(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	for _, f := range []string{"in/tt1/movie.mkv", "in/tt1/@eaDir/movie.mkv", "in/tt2/movie.sample.mkv"} {
+		path := filepath.Join(dir, f)
+		test.CheckError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		test.CheckError(t, ioutil.WriteFile(path, []byte{}, os.ModePerm))
+	}
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, IGNORE_FILE_NAME), []byte("@eaDir/\n"), os.ModePerm))
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, "in", IGNORE_FILE_NAME), []byte("*.sample.mkv\n"), os.ModePerm))
+	conf := &ripper.ScanConfig{IdPattern: "tt\\d+", Patterns: []string{"<id>.*/.*"}, AllowedExtensions: []string{"mkv"}}
+
+	for _, scanPath := range []string{filepath.Join(dir, "in", "tt1"), filepath.Join(dir, "in", "tt2", "movie.sample.mkv")} {
+		t.Run(scanPath, func(t *testing.T) {
+			assert := test.AssertOn(t)
+			results, skippedFiles, err := scan(scanPath, ".", conf)
+			assert.NotError(err)
+			for _, r := range results {
+				assert.StringsEqual(filepath.Join(dir, "in", "tt1"), filepath.Clean(r.Folder))
+			}
+			assert.IntsEqual(1, len(skippedFiles))
+			assert.StringsEqual(SKIPPED_IGNORE_FILE, skippedFiles[0].Reason)
+		})
+	}
+}
@@ -2,44 +2,91 @@ package scan
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 
-	"github.com/thomasschoeftner/go-cli/commons"
 	"github.com/thomasschoeftner/go-ripper/files"
 	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
 )
 
 type scanResult struct {
 	Folder     string
 	File       string
 	Id         string
+	Title      string // title and year of targets without id
+	Year       string
 	Collection *int
 	ItemNo     *int
+	LastItemNo *int // last item# of files containing a range of items (e.g. s01e01-e02)
+	Part       *int // part number of multi-part movies
+	Sidecars   []targetinfo.Sidecar
+	// sidecars of the whole multi-part movie, sharing the base name of all parts (e.g. movie.de.srt for movie-cd1.avi)
+	MovieSidecars []targetinfo.Sidecar
 }
 
-func scan(rootPath string, ignorePrefix string, conf *ripper.ScanConfig, printf commons.FormatPrinter) ([]*scanResult, error) {
+// scan returns all files matching the scan config - as well as all files skipped and the reason why
+func scan(rootPath string, ignorePrefix string, conf *ripper.ScanConfig) ([]*scanResult, []*skippedFile, error) {
 	results := []*scanResult{}
-	ignoredFolders := []string{}
+	skippedFiles := []*skippedFile{}
+	allowedExtensions := conf.AllowedExtensions
+	if conf.Discs {
+		allowedExtensions = append([]string{files.DISC_IMAGE_EXTENSION}, allowedExtensions...)
+	}
+	ignore := NewIgnoreRules(ignorePrefix, allowedExtensions)
+	if err := ignore.LoadAncestors(rootPath); err != nil {
+		return nil, nil, err
+	}
+	partPattern, err := CompilePartPattern(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	rootPaths, err := withSiblingParts(rootPath, partPattern)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	walk := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if info.IsDir() && filepath.Clean(path) != filepath.Clean(rootPath) {
+			if skipReason := ignore.FolderReason(path); len(skipReason) > 0 {
+				skippedFiles = append(skippedFiles, skipped(path, skipReason))
+				return filepath.SkipDir // do not descend into ignored folders
+			}
+		}
+		if info.IsDir() && conf.Discs && files.IsDiscFolder(path) {
+			result, skipReason, err := scanDiscFolder(path, conf)
+			if result != nil {
+				results = append(results, result)
+			} else if len(skipReason) > 0 {
+				skippedFiles = append(skippedFiles, skipped(path, skipReason))
+			}
+			if err != nil {
+				return err
+			}
+			return filepath.SkipDir // contents of disc folders are no separate targets
+		}
 		if info.IsDir() {
-			return nil
+			return ignore.Load(path)
 		}
 
-		//ignore spaces if required
-		if !conf.AllowSpaces && strings.Contains(path, " ") {
-			printf("WARNING - ignore file \"%s\" due to spaces in path\n", path)
+		if skipReason := ignore.FileReason(path); len(skipReason) > 0 {
+			if skipReason == SKIPPED_EXTENSION && isSidecarExtension(files.GetExtension(path), conf.Sidecars) {
+				skipReason = SKIPPED_SIDECAR
+			}
+			skippedFiles = append(skippedFiles, skipped(path, skipReason))
 			return nil
 		}
 
-		if shouldIgnore(path, ignorePrefix, ignoredFolders, conf.AllowedExtensions) {
+		//ignore spaces if required
+		if !conf.AllowSpaces && strings.Contains(path, " ") {
+			skippedFiles = append(skippedFiles, skipped(path, SKIPPED_SPACES))
 			return nil
 		}
 
@@ -49,60 +96,119 @@ func scan(rootPath string, ignorePrefix string, conf *ripper.ScanConfig, printf
 			return err
 		}
 		if result != nil {
+			result.Part = partOf(result.File, partPattern)
+			if result.Sidecars, err = findSidecars(result.Folder, result.File, conf.Sidecars); err != nil {
+				return err
+			}
+			if result.Part != nil {
+				base, _ := SplitPart(result.File, partPattern)
+				if result.MovieSidecars, err = findSidecars(result.Folder, base+"."+files.GetExtension(result.File), conf.Sidecars); err != nil {
+					return err
+				}
+			}
 			results = append(results, result)
+		} else {
+			skippedFiles = append(skippedFiles, skipped(path, SKIPPED_NO_PATTERN_MATCHED))
 		}
 
 		return nil
-	})
+	}
+
+	for _, path := range rootPaths {
+		if err := filepath.Walk(path, walk); err != nil {
+			return nil, nil, err
+		}
+	}
+	return results, skippedFiles, nil
+}
 
+// withSiblingParts adds all other parts of a multi-part movie, if a single part is scanned - so the parts are grouped
+func withSiblingParts(rootPath string, partPattern *regexp.Regexp) ([]string, error) {
+	info, err := os.Stat(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	base, part := SplitPart(info.Name(), partPattern)
+	if info.IsDir() || part == nil {
+		return []string{rootPath}, nil
+	}
+	paths := []string{rootPath}
+	siblings, err := ioutil.ReadDir(filepath.Dir(rootPath))
 	if err != nil {
 		return nil, err
-	} else {
-		return results, nil
 	}
+	for _, sibling := range siblings {
+		if siblingBase, siblingPart := SplitPart(sibling.Name(), partPattern); !sibling.IsDir() && sibling.Name() != info.Name() && siblingPart != nil && siblingBase == base {
+			paths = append(paths, filepath.Join(filepath.Dir(rootPath), sibling.Name()))
+		}
+	}
+	return paths, nil
 }
 
-func shouldIgnore(path string, ignorePrefix string, ignoredFolders []string, allowedExtensions []string) bool {
-	folder, file := filepath.Split(path)
-	//discard excluded files
-	if strings.HasPrefix(file, ignorePrefix) {
-		return true
+// scanDiscFolder treats a DVD/Blu-ray folder structure as single target - the id is taken from the path of the parent folder.
+// If the disc is skipped, the reason is returned instead.
+func scanDiscFolder(path string, conf *ripper.ScanConfig) (*scanResult, string, error) {
+	parent := filepath.Dir(path)
+	if !conf.AllowSpaces && strings.Contains(path, " ") {
+		return nil, SKIPPED_SPACES, nil
 	}
 
-	//discard non-matching file extensions
-	_, ext := files.SplitExtension(file)
-	if !commons.IsStringAmong(ext, allowedExtensions) {
-		return true
+	result, err := dissectPath(parent, conf)
+	if err != nil {
+		return nil, "", err
 	}
-
-	// discard files in ignore folders, and keep list of ignored folders
-	folderName := filepath.Base(folder)
-	if strings.HasPrefix(folderName, ignorePrefix) {
-		//TODO optimize by storing an ignored folder only once?
-		ignoredFolders = append(ignoredFolders, folder)
-		return true
+	if result == nil {
+		return nil, SKIPPED_NO_PATTERN_MATCHED, nil
 	}
+	result.Folder, result.File = filepath.Split(path)
+	return result, "", nil
+}
 
-	//discard sub-directories of excluded directories
-	for _, ignored := range ignoredFolders {
-		if strings.HasPrefix(folder, ignored) {
-			return true
-		}
+// CompilePartPattern returns the pattern of part numbers of multi-part movies - or nil if none is configured
+func CompilePartPattern(conf *ripper.ScanConfig) (*regexp.Regexp, error) {
+	if conf == nil || len(conf.PartPattern) == 0 {
+		return nil, nil
 	}
+	return regexp.Compile(conf.PartPattern)
+}
 
-	return false
+// partOf extracts the part number from the name of a file - or nil if the file is no part of a multi-part movie
+func partOf(file string, partPattern *regexp.Regexp) *int {
+	_, part := SplitPart(file, partPattern)
+	return part
+}
+
+// SplitPart splits the name of a file into the base name shared by all parts of a multi-part movie and the part number.
+// The part number is nil if the file is no part of a multi-part movie.
+func SplitPart(file string, partPattern *regexp.Regexp) (string, *int) {
+	name, _ := files.SplitExtension(file)
+	if partPattern == nil {
+		return name, nil
+	}
+	loc := partPattern.FindStringSubmatchIndex(name)
+	if len(loc) < 4 || loc[2] < 0 {
+		return name, nil
+	}
+	part, err := strconv.Atoi(name[loc[2]:loc[3]])
+	if err != nil {
+		return name, nil
+	}
+	return name[:loc[0]], &part
 }
 
 const (
 	placeholder_Id         = "id"
 	placeholder_Collection = "collection"
 	placeholder_ItemNo     = "itemno"
+	placeholder_LastItemNo = "lastitemno"
+	placeholder_Title      = "title"
+	placeholder_Year       = "year"
 )
 
 func dissectPath(path string, conf *ripper.ScanConfig) (*scanResult, error) {
 	for _, pattern := range conf.Patterns {
-		expandedPattern := expandPatterns(pattern, conf.IdPattern, conf.CollectionPattern, conf.ItemNoPattern)
-		pathTrail := getLastNPathElements(path, strings.Count(expandedPattern, "/")+1) //folder depth + file nameee
+		expandedPattern := expandPatterns(pattern, conf)
+		pathTrail := getLastNPathElements(path, strings.Count(pattern, "/")+1) //folder depth + file name - sub-patterns may contain "/" (e.g. [^/]+)
 		re, err := regexp.Compile(expandedPattern)
 		if err != nil {
 			return nil, err
@@ -110,7 +216,9 @@ func dissectPath(path string, conf *ripper.ScanConfig) (*scanResult, error) {
 
 		matches := extractParams(re, pathTrail)
 		if matches != nil {
-			if id, isDefined := matches[placeholder_Id]; isDefined {
+			id, isDefined := matches[placeholder_Id]
+			title := cleanTitle(matches[placeholder_Title])
+			if isDefined || len(title) > 0 { //targets without id are looked up by title (and year) when resolving
 				var collection *int
 				if collectionVal, isDefined := matches[placeholder_Collection]; isDefined {
 					i, _ := strconv.Atoi(collectionVal)
@@ -121,8 +229,13 @@ func dissectPath(path string, conf *ripper.ScanConfig) (*scanResult, error) {
 					i, _ := strconv.Atoi(itemVal)
 					itemNo = &i
 				}
+				var lastItemNo *int
+				if lastItemVal, isDefined := matches[placeholder_LastItemNo]; isDefined {
+					i, _ := strconv.Atoi(lastItemVal)
+					lastItemNo = &i
+				}
 				folder, file := filepath.Split(path)
-				return &scanResult{Folder: folder, File: file, Id: id, Collection: collection, ItemNo: itemNo}, nil
+				return &scanResult{Folder: folder, File: file, Id: id, Title: title, Year: matches[placeholder_Year], Collection: collection, ItemNo: itemNo, LastItemNo: lastItemNo}, nil
 
 			}
 		}
@@ -146,14 +259,24 @@ func extractParams(re *regexp.Regexp, path string) map[string]string {
 	return results
 }
 
-func expandPatterns(pattern string, idPattern string, colPattern string, itemNoPattern string) string {
-	expanded := expandPattern(pattern, placeholder_Id, idPattern)
-	expanded = expandPattern(expanded, placeholder_Collection, colPattern)
-	expanded = expandPattern(expanded, placeholder_ItemNo, itemNoPattern)
+func expandPatterns(pattern string, conf *ripper.ScanConfig) string {
+	expanded := expandPattern(pattern, placeholder_Id, conf.IdPattern)
+	expanded = expandPattern(expanded, placeholder_Collection, conf.CollectionPattern)
+	expanded = expandPattern(expanded, placeholder_ItemNo, conf.ItemNoPattern)
+	expanded = expandPattern(expanded, placeholder_LastItemNo, conf.ItemNoPattern)
+	expanded = expandPattern(expanded, placeholder_Title, conf.TitlePattern)
+	expanded = expandPattern(expanded, placeholder_Year, conf.YearPattern)
 	//keep linux file separator!!
 	return expanded
 }
 
+var titleSeparators = regexp.MustCompile(`[._\s]+`)
+
+// cleanTitle replaces separators commonly used instead of spaces in file names (e.g. "The.Matrix")
+func cleanTitle(title string) string {
+	return strings.TrimSpace(titleSeparators.ReplaceAllString(title, " "))
+}
+
 func expandPattern(pattern string, placeholder string, subPattern string) string {
 	replacement := fmt.Sprintf("(?P<%s>%s)", placeholder, subPattern) //e.g. (?P<id>\d*)
 	return strings.Replace(pattern, fmt.Sprintf("<%s>", placeholder), replacement, -1)
@@ -7,7 +7,9 @@ import (
 	"github.com/thomasschoeftner/go-cli/test"
 	"fmt"
 	"path/filepath"
-	"github.com/thomasschoeftner/go-cli/commons"
+	"io/ioutil"
+	"os"
+	"regexp"
 )
 
 func loadConfig(json string) (*ripper.AppConf, error) {
@@ -165,6 +167,94 @@ func TestColAndItemNoInFilename(t *testing.T) {
 	}
 }
 
+func TestItemRangeInFilename(t *testing.T) {
+	conf, err := loadConfig(`
+{
+  "ignorePrefix" : ".",
+  "workDirectory" : "tmp",
+  "scan" : {
+    "video" : {
+      "idPattern" : "tt\\d+",
+      "collectionPattern": "\\d+",
+      "itemNoPattern" : "\\d+",
+      "patterns" : ["<id>.*/s<collection>e<itemno>-?e<lastitemno>.*", "<id>.*/s<collection>e<itemno>.*"]
+    }
+  }
+}`)
+	test.CheckError(t, err)
+	validateLastItemNo := validateNumeric("last itemno")
+	season, first, last := 1, 1, 2
+
+	for _, file := range []string{"s01e01-e02-pilot.avi", "s01e01e02.avi"} {
+		path := fmt.Sprintf("sepp/tt46864-title/%s", file)
+		t.Run(file, func(t *testing.T) {
+			result, err := dissectPath(path, conf.Scan.Video)
+			test.CheckError(t, err)
+			validateCollection(t, &season, result.Collection)
+			validateItemNo(t, &first, result.ItemNo)
+			validateLastItemNo(t, &last, result.LastItemNo)
+		})
+	}
+
+	t.Run("single item", func(t *testing.T) {
+		result, err := dissectPath("sepp/tt46864-title/s01e01-pilot.avi", conf.Scan.Video)
+		test.CheckError(t, err)
+		validateItemNo(t, &first, result.ItemNo)
+		validateLastItemNo(t, nil, result.LastItemNo)
+	})
+}
+
+func TestTitleAndYearWithoutId(t *testing.T) {
+	conf, err := loadConfig(`
+{
+  "ignorePrefix" : ".",
+  "workDirectory" : "tmp",
+  "scan" : {
+    "video" : {
+      "idPattern" : "tt\\d+",
+      "collectionPattern": "\\d+",
+      "itemNoPattern" : "\\d+",
+      "titlePattern" : "[^/]+?",
+      "yearPattern" : "(?:19|20)[0-9]{2}",
+      "patterns" : [
+        "<id>.*/.*",
+        "^<title> \\(<year>\\)/.*/.*[Ss]<collection>[Ee]<itemno>.*",
+        "^<title> \\(<year>\\)/.*"]
+    }
+  }
+}`)
+	test.CheckError(t, err)
+	assert := test.AssertOn(t)
+
+	t.Run("movie", func(t *testing.T) {
+		result, err := dissectPath("/movies/The.Matrix (1999)/matrix.avi", conf.Scan.Video)
+		assert.NotError(err)
+		assert.StringsEqual("", result.Id)
+		assert.StringsEqual("The Matrix", result.Title)
+		assert.StringsEqual("1999", result.Year)
+		validateCollection(t, nil, result.Collection)
+	})
+
+	t.Run("episode", func(t *testing.T) {
+		season, episode := 2, 3
+		result, err := dissectPath("/shows/Some Show (2005)/Season 02/Some Show S02E03.mkv", conf.Scan.Video)
+		assert.NotError(err)
+		assert.StringsEqual("Some Show", result.Title)
+		assert.StringsEqual("2005", result.Year)
+		validateCollection(t, &season, result.Collection)
+		validateItemNo(t, &episode, result.ItemNo)
+	})
+
+	t.Run("id takes precedence", func(t *testing.T) {
+		result, err := dissectPath("/movies/The Matrix (1999) tt0133093/matrix.avi", conf.Scan.Video)
+		assert.NotError(err)
+		assert.StringsEqual("tt0133093", result.Id)
+		assert.StringsEqual("", result.Title)
+	})
+
+	dissectPathAndValidateNoMatch("neither id nor title", t, conf, "/movies/The Matrix/matrix.avi")
+}
+
 func TestEliminateLeadingZeroes(t *testing.T) {
 	conf, err := loadConfig(`
 {
@@ -346,7 +436,7 @@ func testScanVideos(t *testing.T, expectedMovies []string, expectedEpisodes map[
 	test.CheckError(t, err)
 
 	path, _ := filepath.Abs(filepath.Join(".", testDataFolder))
-	results, err := scan(path, conf.IgnorePrefix, conf.Scan.Video, commons.Printf)
+	results, _, err := scan(path, conf.IgnorePrefix, conf.Scan.Video)
 	test.CheckError(t, err)
 
 	for _, result := range results  {
@@ -386,42 +476,155 @@ func testScanVideos(t *testing.T, expectedMovies []string, expectedEpisodes map[
 	}
 }
 
-func TestExclusion(t *testing.T) {
-	const ignorePrefix = "."
-	t.Run("do not ignore ordinary files with valid extension", func(t *testing.T) {
-		path := "a/b/c.valid"
-		if shouldIgnore(path, ignorePrefix, []string{}, []string{"valid"}) {
-			t.Errorf("expected file \"%s\" not to be ignored, but is", path)
-		}
-	})
+func TestScanDiscs(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	for _, f := range []string{"tt111 dune/VIDEO_TS/VTS_01_1.VOB", "tt222/BDMV/index.bdmv", "tt333.iso", "no-id/VIDEO_TS/VTS_01_1.VOB", ".tt444/VIDEO_TS/VTS_01_1.VOB"} {
+		path := filepath.Join(dir, f)
+		test.CheckError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		test.CheckError(t, ioutil.WriteFile(path, []byte{}, os.ModePerm))
+	}
+	confStr := `
+{
+  "ignorePrefix" : ".",
+  "workDirectory" : "tmp",
+  "scan" : {
+    "video" : {
+      "idPattern" : "tt\\d+",
+      "collectionPattern": "\\d+",
+      "itemNoPattern" : "\\d+",
+      "patterns" : ["<id>.*/.*","<id>.*"],
+      "allowSpaces" : true,
+      "allowedExtensions" : ["avi"],
+      "discs" : %t
+    }
+  }
+}`
 
-	t.Run("ignore ordinary files with invalid extension", func(t *testing.T) {
-		path := "a/b/c.invalid"
-		if !shouldIgnore(path, ignorePrefix, []string{}, []string{"valid"}) {
-			t.Errorf("expected file \"%s\" to be ignored, but is not", path)
+	t.Run("scan disc folders and images as single targets", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		conf, err := loadConfig(fmt.Sprintf(confStr, true))
+		assert.NotError(err)
+		results, _, err := scan(dir, conf.IgnorePrefix, conf.Scan.Video)
+		assert.NotError(err)
+		assert.IntsEqual(3, len(results))
+		found := map[string]string{}
+		for _, r := range results {
+			found[r.Id] = filepath.Join(r.Folder, r.File)
 		}
+		assert.StringsEqual(filepath.Join(dir, "tt111 dune", "VIDEO_TS"), found["tt111"])
+		assert.StringsEqual(filepath.Join(dir, "tt222", "BDMV"), found["tt222"])
+		assert.StringsEqual(filepath.Join(dir, "tt333.iso"), found["tt333"])
 	})
 
-	t.Run("ignore file", func(t *testing.T) {
-		path := "a/b/.c.valid"
-		if !shouldIgnore(path, ignorePrefix, []string{}, []string {"valid"}) {
-			t.Errorf("expected file \"%s\" to be ignored, but is not", path)
-		}
+	t.Run("ignore discs unless enabled", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		conf, err := loadConfig(fmt.Sprintf(confStr, false))
+		assert.NotError(err)
+		results, _, err := scan(dir, conf.IgnorePrefix, conf.Scan.Video)
+		assert.NotError(err)
+		assert.IntsEqual(0, len(results))
 	})
+}
 
-	t.Run("ignore folder", func(t *testing.T) {
-		path := "a/.b/c.valid"
-		if !shouldIgnore(path, ignorePrefix, []string{}, []string {"valid"}) {
-			t.Errorf("expected folder content \"%s\" to be ignored, but is not", path)
-		}
-	})
+func TestScanSkippedFiles(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	for _, f := range []string{"tt111/movie.avi", "tt111/movie.nfo", "tt111/.hidden.avi", "tt 222/movie.avi", "typo/movie.avi"} {
+		path := filepath.Join(dir, f)
+		test.CheckError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		test.CheckError(t, ioutil.WriteFile(path, []byte{}, os.ModePerm))
+	}
+	conf, err := loadConfig(`
+{
+  "ignorePrefix" : ".",
+  "workDirectory" : "tmp",
+  "scan" : {
+    "video" : {
+      "idPattern" : "tt\\d+",
+      "patterns" : ["<id>.*/.*"],
+      "allowSpaces" : false,
+      "allowedExtensions" : ["avi"]
+    }
+  }
+}`)
+	test.CheckError(t, err)
+	assert := test.AssertOn(t)
+
+	results, skippedFiles, err := scan(dir, conf.IgnorePrefix, conf.Scan.Video)
+	assert.NotError(err)
+	assert.IntsEqual(1, len(results))
+	reasons := map[string]string{}
+	for _, s := range skippedFiles {
+		rel, _ := filepath.Rel(dir, s.Path)
+		reasons[filepath.ToSlash(rel)] = s.Reason
+	}
+	assert.IntsEqual(4, len(reasons))
+	assert.StringsEqual(SKIPPED_EXTENSION, reasons["tt111/movie.nfo"])
+	assert.StringsEqual(SKIPPED_IGNORE_PREFIX, reasons["tt111/.hidden.avi"])
+	assert.StringsEqual(SKIPPED_SPACES, reasons["tt 222/movie.avi"])
+	assert.StringsEqual(SKIPPED_NO_PATTERN_MATCHED, reasons["typo/movie.avi"])
+}
+
+func TestScanPrunesIgnoredFolders(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	for _, f := range []string{"tt111/movie.avi", "tt111/@eaDir/movie.avi", "tt111/.snapshot/hourly/movie.avi",
+		"tt222/movie.avi", "tt222/extras/trailer.avi", "tt222/sample.avi", "tt333/movie.avi"} {
+		path := filepath.Join(dir, f)
+		test.CheckError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		test.CheckError(t, ioutil.WriteFile(path, []byte{}, os.ModePerm))
+	}
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, IGNORE_FILE_NAME), []byte("# synology thumbnails\n@eaDir/\ntt333/\n"), os.ModePerm))
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, "tt222", IGNORE_FILE_NAME), []byte("/extras\nsample.*\n"), os.ModePerm))
+	conf, err := loadConfig(`
+{
+  "ignorePrefix" : ".",
+  "workDirectory" : "tmp",
+  "scan" : {
+    "video" : {
+      "idPattern" : "tt\\d+",
+      "patterns" : ["<id>.*/.*"],
+      "allowSpaces" : false,
+      "allowedExtensions" : ["avi"]
+    }
+  }
+}`)
+	test.CheckError(t, err)
+	assert := test.AssertOn(t)
+
+	results, skippedFiles, err := scan(dir, conf.IgnorePrefix, conf.Scan.Video)
+	assert.NotError(err)
+	assert.IntsEqual(2, len(results))
+	reasons := map[string]string{}
+	for _, s := range skippedFiles {
+		rel, _ := filepath.Rel(dir, s.Path)
+		reasons[filepath.ToSlash(rel)] = s.Reason
+	}
+	assert.StringsEqual(SKIPPED_IGNORE_FILE, reasons["tt111/@eaDir"])
+	assert.StringsEqual(SKIPPED_IGNORE_PREFIX, reasons["tt111/.snapshot"])
+	assert.StringsEqual(SKIPPED_IGNORE_FILE, reasons["tt222/extras"])
+	assert.StringsEqual(SKIPPED_IGNORE_FILE, reasons["tt222/sample.avi"])
+	assert.StringsEqual(SKIPPED_IGNORE_FILE, reasons["tt333"])
+	_, descended := reasons["tt111/.snapshot/hourly/movie.avi"]
+	assert.False("expected contents of ignored folders not to be visited")(descended)
+}
 
-	t.Run("ignore ignored sub-folder", func(t *testing.T) {
-		path := "a/.b/c/d/e.valid"
-		if !shouldIgnore(path, ignorePrefix, []string{"a/.b"}, []string {"valid"}) {
-			t.Errorf("expected folder content \"%s\" to be ignored, but is not", path)
+func TestPartOf(t *testing.T) {
+	assert := test.AssertOn(t)
+	re := regexp.MustCompile("(?i)[ ._-]*(?:cd|part|pt)[ ._-]*([0-9]+)$")
+	assert.True("expected no part without pattern")(partOf("movie-cd1.avi", nil) == nil)
+	assert.True("expected no part in file name without part marker")(partOf("movie 2.avi", re) == nil)
+	for file, expected := range map[string]int{"movie-cd1.avi": 1, "movie.CD2.avi": 2, "movie part 3.mkv": 3, "movie_pt04.mkv": 4} {
+		part := partOf(file, re)
+		if part == nil {
+			t.Errorf("expected part %d in file %s, but found none", expected, file)
+			continue
 		}
-	})
+		assert.IntsEqual(expected, *part)
+	}
+	base, _ := SplitPart("movie.CD2.avi", re)
+	assert.StringsEqual("movie", base)
 }
 
 func idFoundIn(id string, ids []string) bool {
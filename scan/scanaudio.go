@@ -0,0 +1,49 @@
+package scan
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+const defaultDisc = 1
+
+func ScanAudio(ctx task.Context) task.HandlerFunc {
+	conf := ctx.Config.(*ripper.AppConf)
+	return scanFor(ctx, ripper.MEDIA_AUDIO, conf.Scan.Audio, toTrackTargetInfos)
+}
+
+// collection is interpreted as disc#, item# as track#
+func toTrackTargetInfos(results []*scanResult) ([]targetinfo.TargetInfo, []*skippedFile, error) {
+	var targetInfos []targetinfo.TargetInfo
+	trackCount := map[string]map[int]int{}
+
+	for _, r := range results {
+		if r.ItemNo == nil {
+			return nil, nil, fmt.Errorf("invalid track found - track# is missing in file %s", filepath.Join(r.Folder, r.File))
+		}
+		disc := defaultDisc
+		if r.Collection != nil {
+			disc = *r.Collection
+		}
+
+		discs := trackCount[r.Id]
+		if discs == nil {
+			discs = map[int]int{}
+			trackCount[r.Id] = discs
+		}
+		discs[disc] = discs[disc] + 1
+
+		targetInfos = append(targetInfos, targetinfo.NewTrack(r.File, r.Folder, r.Id, disc, *r.ItemNo, 0))
+	}
+
+	//finally update total # of tracks for all tracks
+	for _, ti := range targetInfos {
+		t := ti.(*targetinfo.Track)
+		t.ItemsTotal = trackCount[t.Id][t.Disc]
+	}
+	return targetInfos, nil, nil
+}
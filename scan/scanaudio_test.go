@@ -0,0 +1,39 @@
+package scan
+
+import (
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+func TestToTrackTargetInfos(t *testing.T) {
+	t.Run("expect error if track# is missing", func(t *testing.T) {
+		sr := []*scanResult{{Folder: "a", File: "x.flac", Id: "album"}}
+		_, _, err := toTrackTargetInfos(sr)
+		test.AssertOn(t).ExpectError("expected error for track without track#, but got none")(err)
+	})
+
+	t.Run("use default disc and count total number of tracks per disc", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		disc2 := 2
+		track1, track2, track3 := 1, 2, 3
+		sr := []*scanResult{
+			{Folder: "a", File: "1.flac", Id: "album", ItemNo: &track1},
+			{Folder: "a", File: "2.flac", Id: "album", ItemNo: &track2},
+			{Folder: "a", File: "3.flac", Id: "album", ItemNo: &track3},
+			{Folder: "a/cd2", File: "1.flac", Id: "album", Collection: &disc2, ItemNo: &track1}}
+		targetInfos, _, err := toTrackTargetInfos(sr)
+		assert.NotError(err)
+		assert.IntsEqual(len(sr), len(targetInfos))
+
+		first := targetInfos[0].(*targetinfo.Track)
+		assert.IntsEqual(defaultDisc, first.Disc)
+		assert.IntsEqual(1, first.Track)
+		assert.IntsEqual(3, first.ItemsTotal)
+
+		last := targetInfos[3].(*targetinfo.Track)
+		assert.IntsEqual(disc2, last.Disc)
+		assert.IntsEqual(1, last.ItemsTotal)
+	})
+}
@@ -0,0 +1,109 @@
+package scan
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/ledger"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+// targetInfoConverter converts scan results to target-infos - scan results which cannot become targets are skipped
+type targetInfoConverter func(results []*scanResult) ([]targetinfo.TargetInfo, []*skippedFile, error)
+
+// scanFor creates a scanner for a specific media type.
+// Jobs which were already scanned by scanners for other media types are passed on untouched,
+// as well as scanned folders - so scanners for other media types can scan them too.
+func scanFor(ctx task.Context, media string, scanConf *ripper.ScanConfig, toTargetInfos targetInfoConverter) task.HandlerFunc {
+	conf := ctx.Config.(*ripper.AppConf)
+	if scanConf == nil {
+		return ripper.ErrorHandler(fmt.Errorf("scanning for %s is not configured", media))
+	}
+
+	return func(job task.Job) ([]task.Job, error) {
+		if len(ripper.GetMediaFromJob(job)) > 0 {
+			return []task.Job{job}, nil
+		}
+		scanPath := ripper.GetTargetFileFromJob(job)
+
+		ctx.Printf("scanning contents of \"%s\" for %s\n", scanPath, media)
+		scanResults, skippedFiles, err := scan(scanPath, conf.IgnorePrefix, scanConf)
+		if err != nil {
+			return nil, err
+		}
+
+		//convert scanResults to TargetInfos
+		targets, skippedResults, err := toTargetInfos(scanResults)
+		if err != nil {
+			return nil, err
+		}
+		skippedFiles = append(skippedFiles, skippedResults...)
+		printSkipped(ctx.Printf.WithIndent(2), skippedFiles)
+		if len(scanConf.Report) > 0 {
+			if conf.DryRun {
+				ctx.Printf("dry-run - would write skipped files to %s\n", scanConf.Report)
+			} else if err := writeSkipped(scanConf.Report, scanPath, skippedFiles); err != nil {
+				return nil, err
+			}
+		}
+
+		jobs := []task.Job{}
+		ctx.Printf("found %d targets:\n", len(targets))
+		for _, target := range targets {
+			if conf.DryRun {
+				targetinfo.Plan(target)
+			} else {
+				//write TargetInfo to work folder
+				rescan, err := targetinfo.SaveScanned(conf.StateDirectory(), conf.WorkDirectory, target)
+				if err != nil {
+					return nil, err
+				}
+				if err := keepLedger(conf.StateDirectory(), target, rescan, ctx.Printf.WithIndent(2)); err != nil {
+					return nil, err
+				}
+			}
+
+			//create new Job
+			newJob := job.WithParam(ripper.JobField_Path, filepath.Join(target.GetFolder(), target.GetFile())).WithParam(ripper.JobField_Media, media)
+			jobs = append(jobs, newJob)
+			ctx.Printf("  %s\n", target)
+		}
+
+		//pass on folders and unrecognized files to scanners for other media types
+		if info, err := os.Stat(scanPath); err == nil && info.IsDir() {
+			jobs = append(jobs, job)
+		} else if err == nil && len(targets) == 0 {
+			jobs = append(jobs, unrecognized(job, media))
+		}
+		return jobs, nil
+	}
+}
+
+// unrecognized marks files which were not recognized as targets - other scanners can still scan them, but no other task processes them
+func unrecognized(job task.Job, media string) task.Job {
+	if scannedBy := job[ripper.JobField_Unrecognized]; len(scannedBy) > 0 {
+		media = scannedBy + "," + media
+	}
+	return job.WithParam(ripper.JobField_Unrecognized, media)
+}
+
+// keepLedger transfers the progress of moved targets and discards the progress of targets whose input changed
+func keepLedger(stateDir string, target targetinfo.TargetInfo, rescan *targetinfo.Rescan, printf commons.FormatPrinter) error {
+	if len(rescan.MovedFrom) == 0 && !rescan.Invalidated {
+		return nil
+	}
+	l, err := ledger.Open(stateDir)
+	if err != nil {
+		return err
+	}
+	if len(rescan.MovedFrom) > 0 {
+		printf("%s was moved from %s - continue with its previous progress\n", target.GetFullPath(), rescan.MovedFrom)
+		return l.Move(rescan.MovedFrom, target.GetFullPath())
+	}
+	printf("%s changed since it was scanned before - discard all previous progress\n", target.GetFullPath())
+	return l.Forget(target.GetFullPath())
+}
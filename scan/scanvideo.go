@@ -1,68 +1,45 @@
 package scan
 
 import (
-	"errors"
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/override"
 	"github.com/thomasschoeftner/go-ripper/ripper"
 	"github.com/thomasschoeftner/go-ripper/targetinfo"
 )
 
 func ScanVideo(ctx task.Context) task.HandlerFunc {
 	conf := ctx.Config.(*ripper.AppConf)
-
-	return func(job task.Job) ([]task.Job, error) {
-		scanPath := ripper.GetTargetFileFromJob(job)
-
-		ctx.Printf("scanning contents of \"%s\"", scanPath)
-		scanResults, err := scan(scanPath, conf.IgnorePrefix, conf.Scan.Video, ctx.Printf)
-		if err != nil {
-			return nil, err
-		}
-
-		//convert scanResults to TargetInfos
-		targets, err := toTargetInfos(scanResults)
-		if err != nil {
-			return nil, err
-		}
-
-		jobs := []task.Job{}
-		ctx.Printf("found %d targets:\n", len(targets))
-		for _, target := range targets {
-			//write TargetInfo to work folder
-			workDir, err := ripper.GetWorkPathForTargetFolder(conf.WorkDirectory, target.GetFolder())
-			if err != nil {
-				return nil, err
-			}
-
-			err = targetinfo.Save(workDir, target)
-			if err != nil {
-				//TODO check if error should be ignored - in a worst case the target file will be missing
-				return nil, err
-			}
-
-			//create new Job
-			newJob := job.WithParam(ripper.JobField_Path, filepath.Join(target.GetFolder(), target.GetFile()))
-			jobs = append(jobs, newJob)
-			ctx.Printf("  %s\n", target)
-		}
-		return jobs, nil
-	}
+	return scanFor(ctx, ripper.MEDIA_VIDEO, conf.Scan.Video, toTargetInfos)
 }
 
-func toTargetInfos(results []*scanResult) ([]targetinfo.TargetInfo, error) {
+func toTargetInfos(results []*scanResult) ([]targetinfo.TargetInfo, []*skippedFile, error) {
 	var targetInfos []targetinfo.TargetInfo
+	var skippedFiles []*skippedFile
 	episodeCount := map[string]map[int]int{}
+	multiPartMovies := map[string][]*scanResult{}
+	multiPartIdx := map[string]int{} //position of multi-part movies among target-infos
 
 	for _, r := range results {
+		if err := applyOverrides(r); err != nil {
+			return nil, nil, err
+		}
+		if r.Collection == nil && r.ItemNo != nil { //episode# without season# (e.g. in a "Specials" folder) denotes a special
+			special := 0
+			r.Collection = &special
+		}
 		if r.Collection != nil {
-			series := r.Id
+			series := groupKey(r.Id, r.Title)
 			path := filepath.Join(r.Folder, r.File)
 			season := *r.Collection
-			if r.ItemNo == nil {
-				return nil, errors.New(fmt.Sprintf("invalid episode found - season# is set (%d), but episode# is missing in file %s", season, path))
+			if r.ItemNo == nil { //season# is set, but episode# is missing
+				skippedFiles = append(skippedFiles, skipped(path, SKIPPED_MISSING_EPISODE_NO))
+				continue
 			}
 			episode := *r.ItemNo
 
@@ -71,13 +48,42 @@ func toTargetInfos(results []*scanResult) ([]targetinfo.TargetInfo, error) {
 				seasons = map[int]int{}
 				episodeCount[series] = seasons
 			}
-			cnt := seasons[season] + 1
-			seasons[season] = cnt
-
 			episodeInfo := targetinfo.NewEpisode(r.File, r.Folder, r.Id, season, episode, 0)
+			withTitle(&episodeInfo.Video, r)
+			episodeInfo.Sidecars = r.Sidecars
+			if r.LastItemNo != nil {
+				if *r.LastItemNo < episode {
+					return nil, nil, fmt.Errorf("invalid episode range found - last episode# (%d) is lower than first episode# (%d) in file %s", *r.LastItemNo, episode, path)
+				}
+				episodeInfo.LastEpisode = *r.LastItemNo
+			}
+			seasons[season] = seasons[season] + len(episodeInfo.Episodes())
 			targetInfos = append(targetInfos, episodeInfo)
+		} else if r.Part != nil { //part of multi-part movie - all parts in the same folder with the same id belong together
+			key := filepath.Join(r.Folder, groupKey(r.Id, r.Title))
+			if multiPartMovies[key] == nil {
+				multiPartIdx[key] = len(targetInfos)
+				targetInfos = append(targetInfos, movieOf(r))
+			}
+			multiPartMovies[key] = append(multiPartMovies[key], r)
 		} else { //single video
-			targetInfos = append(targetInfos, targetinfo.NewMovie(r.File, r.Folder, r.Id))
+			targetInfos = append(targetInfos, movieOf(r))
+		}
+	}
+
+	//replace movies with several parts by multi-part movies with all parts in order
+	for key, parts := range multiPartMovies {
+		if len(parts) > 1 {
+			sort.SliceStable(parts, func(i, j int) bool { return *parts[i].Part < *parts[j].Part })
+			files := []string{}
+			for _, part := range parts {
+				files = append(files, part.File)
+			}
+			movie := targetinfo.NewMultiPartMovie(files, parts[0].Folder, parts[0].Id)
+			withTitle(&movie.Video, parts[0])
+			//sidecars of single parts are dropped, as their timing does not match the joined video - only sidecars of the whole movie are kept
+			movie.Sidecars = movieSidecars(parts)
+			targetInfos[multiPartIdx[key]] = movie
 		}
 	}
 
@@ -85,9 +91,66 @@ func toTargetInfos(results []*scanResult) ([]targetinfo.TargetInfo, error) {
 	for _, ti := range targetInfos {
 		if targetinfo.TARGETINFO_TPYE_EPISODE == ti.GetType() {
 			e := ti.(*targetinfo.Episode)
-			e.ItemsTotal = episodeCount[e.Id][e.Season]
+			e.ItemsTotal = episodeCount[groupKey(e.Id, e.Title)][e.Season]
+		}
+	}
+
+	return targetInfos, skippedFiles, nil
+}
+
+func movieOf(r *scanResult) *targetinfo.Movie {
+	movie := targetinfo.NewMovie(r.File, r.Folder, r.Id)
+	withTitle(&movie.Video, r)
+	movie.Sidecars = r.Sidecars
+	return movie
+}
+
+// movieSidecars returns the sidecars of a multi-part movie, which do not belong to one of its parts (e.g. movie.de.srt, but not movie.cd1.de.srt)
+func movieSidecars(parts []*scanResult) []targetinfo.Sidecar {
+	var sidecars []targetinfo.Sidecar
+	for _, sidecar := range parts[0].MovieSidecars {
+		ofPart := false
+		for _, part := range parts {
+			name, _ := files.SplitExtension(part.File)
+			ofPart = ofPart || strings.HasPrefix(sidecar.File, name+".")
+		}
+		if !ofPart {
+			sidecars = append(sidecars, sidecar)
 		}
 	}
+	return sidecars
+}
 
-	return targetInfos, nil
+// withTitle keeps the scanned title and year of videos without id for looking up the id
+func withTitle(v *targetinfo.Video, r *scanResult) {
+	if len(r.Id) == 0 {
+		v.Title, v.Year = r.Title, r.Year
+	}
+}
+
+// groupKey identifies the series or movie a scan result belongs to - by id or, if unknown, by title
+func groupKey(id string, title string) string {
+	if len(id) > 0 {
+		return id
+	}
+	return title
+}
+
+// applyOverrides replaces the scanned id, season and episode range with values from the item's override files
+func applyOverrides(r *scanResult) error {
+	o, err := override.ForFile(filepath.Join(r.Folder, r.File))
+	if err != nil {
+		return err
+	}
+	r.Id = override.Or(o.Id, r.Id)
+	if o.Season != nil {
+		r.Collection = o.Season
+	}
+	if o.Episode != nil {
+		r.ItemNo = o.Episode
+		r.LastItemNo = o.LastEpisode
+	} else if o.LastEpisode != nil {
+		r.LastItemNo = o.LastEpisode
+	}
+	return nil
 }
@@ -8,6 +8,9 @@ import (
 	"github.com/thomasschoeftner/go-ripper/ripper"
 	"github.com/thomasschoeftner/go-ripper/targetinfo"
 	"path/filepath"
+	"io/ioutil"
+	"os"
+	"github.com/thomasschoeftner/go-ripper/override"
 )
 
 func TestScanVideo(t *testing.T) {
@@ -41,14 +44,52 @@ func TestScanVideo(t *testing.T) {
 	results, err := handler(job)
 	test.CheckError(t, err)
 	expectedNoOfSearchResults := 14
-	if len(results) != expectedNoOfSearchResults {
-		t.Errorf("found %d number of search results, but expected %d", len(results), expectedNoOfSearchResults)
+	targets := 0
+	for _, result := range results {
+		if ripper.GetMediaFromJob(result) == ripper.MEDIA_VIDEO {
+			targets++
+		}
+	}
+	if targets != expectedNoOfSearchResults {
+		t.Errorf("found %d number of search results, but expected %d", targets, expectedNoOfSearchResults)
+	}
+	if len(results) != expectedNoOfSearchResults+1 {
+		t.Errorf("expected scanned folder to be passed on along with %d search results, but got %d jobs", expectedNoOfSearchResults, len(results))
 	}
 }
 
+func TestScanVideoUnrecognizedFile(t *testing.T) {
+	workDir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, workDir)
+	confStr := `
+{
+  "workDirectory" : "` + filepath.ToSlash(workDir) + `",
+  "scan" : {
+    "video" : {
+      "idPattern" : "tt\\d+",
+      "collectionPattern": "\\d+",
+      "itemNoPattern" : "\\d+",
+      "patterns" : ["<id>.*"],
+      "allowedExtensions" : ["avi"]
+    }
+  }
+}`
+	conf, err := loadConfig(confStr)
+	test.CheckError(t, err)
+	file := filepath.Join(workDir, "notes.txt")
+	test.CheckError(t, ioutil.WriteFile(file, []byte("no video"), 0644))
+
+	assert := test.AssertOn(t)
+	jobs, err := ScanVideo(task.Context{Config: conf, Printf: commons.Printf})(task.Job{ripper.JobField_Path: file})
+	assert.NotError(err)
+	assert.IntsEqual(1, len(jobs))
+	assert.StringsEqual(ripper.MEDIA_VIDEO, jobs[0][ripper.JobField_Unrecognized])
+	assert.False("expected unrecognized file to be no target")(ripper.IsTargetJobFor(jobs[0]))
+}
+
 func TestToTargetInfos(t *testing.T) {
 	t.Run("nil scan results", func(t *testing.T) {
-		ti, err := toTargetInfos(nil)
+		ti, _, err := toTargetInfos(nil)
 		test.CheckError(t, err)
 		if len(ti) != 0 {
 			t.Errorf("expected empty target info list, but got %v", ti)
@@ -56,7 +97,7 @@ func TestToTargetInfos(t *testing.T) {
 	})
 
 	t.Run("empty scan results", func(t *testing.T) {
-		ti, err := toTargetInfos([]*scanResult{})
+		ti, _, err := toTargetInfos([]*scanResult{})
 		test.CheckError(t, err)
 		if len(ti) != 0 {
 			t.Errorf("expected empty target info list, but got %v", ti)
@@ -68,7 +109,7 @@ func TestToTargetInfos(t *testing.T) {
 			newScanResult("a/3.avi", "1",  "a", 3, 1),
 			newScanResult("a/3.avi", "2",  "a", 3, 2),
 			newScanResult("a/3.avi", "3.avi",  "a", 3, 3)}
-		targetInfos, err := toTargetInfos(sr)
+		targetInfos, _, err := toTargetInfos(sr)
 		test.CheckError(t, err)
 		if len(targetInfos) != len(sr) {
 			t.Errorf("got %d target infos, but expected %d", len(targetInfos), len(sr))
@@ -79,6 +120,110 @@ func TestToTargetInfos(t *testing.T) {
 		}
 	})
 
+	t.Run("skip episodes without episode#", func(t *testing.T) {
+		season := 1
+		sr := []*scanResult {
+			newScanResult("a", "s01e01.avi", "tt1", 1, 1),
+			{Folder: "a", File: "s01-extras.avi", Id: "tt1", Collection: &season}}
+		targetInfos, skippedFiles, err := toTargetInfos(sr)
+		test.CheckError(t, err)
+		assert := test.AssertOn(t)
+		assert.IntsEqual(1, len(targetInfos))
+		assert.IntsEqual(1, len(skippedFiles))
+		assert.StringsEqual(filepath.Join("a", "s01-extras.avi"), skippedFiles[0].Path)
+		assert.StringsEqual(SKIPPED_MISSING_EPISODE_NO, skippedFiles[0].Reason)
+	})
+
+	t.Run("multi-episode files and specials", func(t *testing.T) {
+		last := 3
+		ranged := newScanResult("a", "s01e02-e03.avi", "tt1", 1, 2)
+		ranged.LastItemNo = &last
+		special := &scanResult{Folder: "a/Specials", File: "e01.avi", Id: "tt1", ItemNo: &last}
+		sr := []*scanResult {
+			newScanResult("a", "s01e01.avi", "tt1", 1, 1),
+			ranged,
+			special}
+		targetInfos, _, err := toTargetInfos(sr)
+		test.CheckError(t, err)
+		assert := test.AssertOn(t)
+		multi := targetInfos[1].(*targetinfo.Episode)
+		assert.True("expected multi-episode file")(multi.IsMultiEpisode())
+		assert.IntsEqual(3, multi.LastEpisode)
+		assert.IntsEqual(3, multi.ItemsTotal)
+		sp := targetInfos[2].(*targetinfo.Episode)
+		assert.True("expected special in season 0")(sp.IsSpecial())
+		assert.IntsEqual(3, sp.Episode)
+		assert.IntsEqual(1, sp.ItemsTotal)
+	})
+
+	t.Run("expect error for invalid episode range", func(t *testing.T) {
+		last := 1
+		ranged := newScanResult("a", "s01e02-e01.avi", "tt1", 1, 2)
+		ranged.LastItemNo = &last
+		_, _, err := toTargetInfos([]*scanResult{ranged})
+		test.AssertOn(t).ExpectError("expected error for episode range ending before its start")(err)
+	})
+
+	t.Run("keep title and year of videos without id", func(t *testing.T) {
+		ep1 := newScanResult("a", "s01e01.avi", "", 1, 1)
+		ep2 := newScanResult("a", "s01e02.avi", "", 1, 2)
+		ep1.Title, ep1.Year, ep2.Title, ep2.Year = "Show", "2005", "Show", "2005"
+		sr := []*scanResult {ep1, ep2, {Folder: "b", File: "movie.avi", Title: "Movie", Year: "1999"}}
+		targetInfos, _, err := toTargetInfos(sr)
+		test.CheckError(t, err)
+		assert := test.AssertOn(t)
+		episode := targetInfos[1].(*targetinfo.Episode)
+		assert.StringsEqual("Show", episode.Title)
+		assert.StringsEqual("2005", episode.Year)
+		assert.IntsEqual(2, episode.ItemsTotal)
+		movie := targetInfos[2].(*targetinfo.Movie)
+		assert.StringsEqual("Movie", movie.Title)
+		assert.StringsEqual("", movie.Id)
+	})
+
+	t.Run("group parts of multi-part movies", func(t *testing.T) {
+		part := func(file string, folder string, id string, no int) *scanResult {
+			return &scanResult{Folder: folder, File: file, Id: id, Part: &no}
+		}
+		sr := []*scanResult {
+			part("m-cd2.avi", "a", "tt1", 2),
+			{Folder: "b", File: "single.avi", Id: "tt2"},
+			part("m-cd1.avi", "a", "tt1", 1),
+			part("only-part1.avi", "c", "tt3", 1)}
+		targetInfos, _, err := toTargetInfos(sr)
+		test.CheckError(t, err)
+		assert := test.AssertOn(t)
+		assert.IntsEqual(3, len(targetInfos))
+		multiPart := targetInfos[0].(*targetinfo.Movie)
+		assert.StringsEqual("m-cd1.avi", multiPart.File)
+		assert.StringSlicesEqual([]string{"m-cd1.avi", "m-cd2.avi"}, multiPart.Parts)
+		assert.StringsEqual("single.avi", targetInfos[1].GetFile())
+		assert.False("expected single part not to be multi-part movie")(targetInfos[2].(*targetinfo.Movie).IsMultiPart())
+	})
+
+	t.Run("apply overrides from sidecar files", func(t *testing.T) {
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, override.FolderOverrideFileName), []byte(`{"id": "tt0002", "season": 4}`), os.ModePerm))
+		test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, "ep1.json"), []byte(`{"episode": 7}`), os.ModePerm))
+
+		sr := []*scanResult {
+			newScanResult(dir, "ep1.avi", "tt0001", 1, 1),
+			newScanResult(dir, "ep2.avi", "tt0001", 1, 2)}
+		targetInfos, _, err := toTargetInfos(sr)
+		test.CheckError(t, err)
+		assert := test.AssertOn(t)
+		ep1 := targetInfos[0].(*targetinfo.Episode)
+		ep2 := targetInfos[1].(*targetinfo.Episode)
+		assert.StringsEqual("tt0002", ep1.Id)
+		assert.IntsEqual(4, ep1.Season)
+		assert.IntsEqual(7, ep1.Episode)
+		assert.StringsEqual("tt0002", ep2.Id)
+		assert.IntsEqual(4, ep2.Season)
+		assert.IntsEqual(2, ep2.Episode)
+		assert.IntsEqual(2, ep2.ItemsTotal)
+	})
+
 }
 
 func newScanResult(folder string, file string, id string, season int, episode int) *scanResult {
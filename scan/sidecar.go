@@ -0,0 +1,108 @@
+package scan
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+const (
+	ext_vobsubIndex = "idx"
+	ext_vobsubData  = "sub"
+	sidecar_forced  = "forced"
+)
+
+// findSidecars returns all subtitle and audio files in the folder of a video, which share the video's base name.
+// The language is taken from suffixes between base name and extension (e.g. movie.de.srt or movie.german.forced.srt).
+func findSidecars(folder string, file string, conf *ripper.SidecarConfig) ([]targetinfo.Sidecar, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	entries, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]bool{}
+	for _, entry := range entries {
+		existing[entry.Name()] = !entry.IsDir()
+	}
+
+	name, _ := files.SplitExtension(file)
+	var sidecars []targetinfo.Sidecar
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == file || !strings.HasPrefix(entry.Name(), name+".") {
+			continue
+		}
+		base, ext := files.SplitExtension(entry.Name())
+		ext = strings.ToLower(ext)
+		sidecar := targetinfo.Sidecar{File: entry.Name()}
+		switch {
+		case ext == ext_vobsubData && (existing[base+"."+ext_vobsubIndex] || existing[base+"."+strings.ToUpper(ext_vobsubIndex)]):
+			continue // data of VobSub subtitles is read via their index file
+		case commons.IsStringAmong(ext, conf.Subtitles):
+			sidecar.Kind = targetinfo.SIDECAR_KIND_SUBTITLE
+		case commons.IsStringAmong(ext, conf.Audio):
+			sidecar.Kind = targetinfo.SIDECAR_KIND_AUDIO
+		default:
+			continue
+		}
+		if ext == ext_vobsubIndex {
+			sidecar.Data = vobsubDataOf(base, existing)
+		}
+		for _, suffix := range strings.Split(strings.TrimPrefix(base, name), ".") {
+			if strings.EqualFold(suffix, sidecar_forced) {
+				sidecar.Forced = true
+			} else if lang := languageOf(suffix); len(lang) > 0 {
+				sidecar.Language = lang
+			}
+		}
+		sidecars = append(sidecars, sidecar)
+	}
+	return sidecars, nil
+}
+
+func vobsubDataOf(base string, existing map[string]bool) string {
+	for _, ext := range []string{ext_vobsubData, strings.ToUpper(ext_vobsubData)} {
+		if existing[base+"."+ext] {
+			return base + "." + ext
+		}
+	}
+	return ""
+}
+
+func isSidecarExtension(ext string, conf *ripper.SidecarConfig) bool {
+	ext = strings.ToLower(ext)
+	return conf != nil && (commons.IsStringAmong(ext, conf.Subtitles) || commons.IsStringAmong(ext, conf.Audio))
+}
+
+// ISO 639-2/B codes by common language codes and names found in file names
+var languages = map[string]string{
+	"en": "eng", "eng": "eng", "english": "eng",
+	"de": "ger", "ger": "ger", "deu": "ger", "german": "ger", "deutsch": "ger",
+	"fr": "fre", "fre": "fre", "fra": "fre", "french": "fre",
+	"es": "spa", "spa": "spa", "spanish": "spa",
+	"it": "ita", "ita": "ita", "italian": "ita",
+	"nl": "dut", "dut": "dut", "nld": "dut", "dutch": "dut",
+	"pt": "por", "por": "por", "portuguese": "por",
+	"sv": "swe", "swe": "swe", "swedish": "swe",
+	"da": "dan", "dan": "dan", "danish": "dan",
+	"no": "nor", "nor": "nor", "norwegian": "nor",
+	"fi": "fin", "fin": "fin", "finnish": "fin",
+	"pl": "pol", "pol": "pol", "polish": "pol",
+	"cs": "cze", "cze": "cze", "ces": "cze", "czech": "cze",
+	"hu": "hun", "hun": "hun", "hungarian": "hun",
+	"ru": "rus", "rus": "rus", "russian": "rus",
+	"tr": "tur", "tur": "tur", "turkish": "tur",
+	"ja": "jpn", "jpn": "jpn", "japanese": "jpn",
+	"zh": "chi", "chi": "chi", "zho": "chi", "chinese": "chi",
+	"ko": "kor", "kor": "kor", "korean": "kor",
+}
+
+// languageOf returns the ISO 639-2 code of a language suffix, or an empty string if it is no known language
+func languageOf(suffix string) string {
+	return languages[strings.ToLower(suffix)]
+}
@@ -0,0 +1,152 @@
+package scan
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+var sidecarConf = &ripper.SidecarConfig{Subtitles: []string{"srt", "ass", "idx", "sub"}, Audio: []string{"ac3"}}
+
+func TestFindSidecars(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	for _, f := range []string{"movie.mkv", "movie.de.srt", "movie.German.Forced.SRT", "movie.en.ac3", "movie.idx", "movie.sub",
+		"movie.nfo", "movie2.srt", "other.de.srt", "notes.sub"} {
+		test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, f), []byte{}, os.ModePerm))
+	}
+
+	sidecars, err := findSidecars(dir, "movie.mkv", sidecarConf)
+	assert.NotError(err)
+	byFile := map[string]targetinfo.Sidecar{}
+	for _, s := range sidecars {
+		byFile[s.File] = s
+	}
+	assert.IntsEqual(4, len(byFile))
+
+	srt := byFile["movie.de.srt"]
+	assert.StringsEqual(targetinfo.SIDECAR_KIND_SUBTITLE, srt.Kind)
+	assert.StringsEqual("ger", srt.Language)
+	assert.False("expected subtitles not to be forced")(srt.Forced)
+
+	forced := byFile["movie.German.Forced.SRT"]
+	assert.StringsEqual("ger", forced.Language)
+	assert.True("expected forced subtitles")(forced.Forced)
+
+	ac3 := byFile["movie.en.ac3"]
+	assert.StringsEqual(targetinfo.SIDECAR_KIND_AUDIO, ac3.Kind)
+	assert.StringsEqual("eng", ac3.Language)
+
+	vobsub := byFile["movie.idx"]
+	assert.StringsEqual("movie.sub", vobsub.Data)
+	assert.StringsEqual("", vobsub.Language)
+}
+
+func TestNoSidecarsWithoutConfig(t *testing.T) {
+	assert := test.AssertOn(t)
+	sidecars, err := findSidecars("/does/not/exist", "movie.mkv", nil)
+	assert.NotError(err)
+	assert.IntsEqual(0, len(sidecars))
+}
+
+func TestLanguageOf(t *testing.T) {
+	assert := test.AssertOn(t)
+	for suffix, expected := range map[string]string{"de": "ger", "DEU": "ger", "german": "ger", "en": "eng", "forced": "", "1080p": ""} {
+		assert.StringsEqual(expected, languageOf(suffix))
+	}
+}
+
+func TestScanSidecars(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	for _, f := range []string{"tt111/movie.avi", "tt111/movie.de.srt", "tt222/orphan.srt"} {
+		path := filepath.Join(dir, f)
+		test.CheckError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		test.CheckError(t, ioutil.WriteFile(path, []byte{}, os.ModePerm))
+	}
+	conf, err := loadConfig(`
+{
+  "ignorePrefix" : ".",
+  "workDirectory" : "tmp",
+  "scan" : {
+    "video" : {
+      "idPattern" : "tt\\d+",
+      "patterns" : ["<id>.*/.*"],
+      "allowSpaces" : false,
+      "allowedExtensions" : ["avi"],
+      "sidecars" : {"subtitles" : ["srt"]}
+    }
+  }
+}`)
+	test.CheckError(t, err)
+	assert := test.AssertOn(t)
+
+	results, skippedFiles, err := scan(dir, conf.IgnorePrefix, conf.Scan.Video)
+	assert.NotError(err)
+	assert.IntsEqual(1, len(results))
+	assert.IntsEqual(1, len(results[0].Sidecars))
+	assert.StringsEqual("movie.de.srt", results[0].Sidecars[0].File)
+	assert.IntsEqual(2, len(skippedFiles))
+	for _, s := range skippedFiles {
+		assert.StringsEqual(SKIPPED_SIDECAR, s.Reason)
+	}
+
+	tis, _, err := toTargetInfos(results)
+	assert.NotError(err)
+	assert.IntsEqual(1, len(tis[0].(*targetinfo.Movie).Sidecars))
+}
+
+func TestScanMultiPartMovie(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	for _, f := range []string{"tt111/movie.cd1.avi", "tt111/movie.cd2.avi", "tt111/movie.de.srt", "tt111/movie.cd1.en.srt"} {
+		path := filepath.Join(dir, f)
+		test.CheckError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		test.CheckError(t, ioutil.WriteFile(path, []byte{}, os.ModePerm))
+	}
+	conf, err := loadConfig(`
+{
+  "ignorePrefix" : ".",
+  "workDirectory" : "tmp",
+  "scan" : {
+    "video" : {
+      "idPattern" : "tt\\d+",
+      "patterns" : ["<id>.*/.*"],
+      "allowSpaces" : false,
+      "allowedExtensions" : ["avi"],
+      "partPattern" : "(?i)[ ._-]*(?:cd|part)[ ._-]*([0-9]+)$",
+      "sidecars" : {"subtitles" : ["srt"]}
+    }
+  }
+}`)
+	test.CheckError(t, err)
+
+	t.Run("keep sidecars of whole movie only", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		results, _, err := scan(dir, conf.IgnorePrefix, conf.Scan.Video)
+		assert.NotError(err)
+		tis, _, err := toTargetInfos(results)
+		assert.NotError(err)
+		assert.IntsEqual(1, len(tis))
+		movie := tis[0].(*targetinfo.Movie)
+		assert.IntsEqual(2, len(movie.Parts))
+		assert.IntsEqual(1, len(movie.Sidecars))
+		assert.StringsEqual("movie.de.srt", movie.Sidecars[0].File)
+	})
+
+	t.Run("include other parts when scanning a single part", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		results, _, err := scan(filepath.Join(dir, "tt111", "movie.cd2.avi"), conf.IgnorePrefix, conf.Scan.Video)
+		assert.NotError(err)
+		tis, _, err := toTargetInfos(results)
+		assert.NotError(err)
+		assert.IntsEqual(1, len(tis))
+		assert.StringSlicesEqual([]string{"movie.cd1.avi", "movie.cd2.avi"}, tis[0].(*targetinfo.Movie).Parts)
+	})
+}
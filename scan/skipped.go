@@ -0,0 +1,101 @@
+package scan
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-ripper/files"
+)
+
+// reasons for skipping files during scan
+const (
+	SKIPPED_IGNORE_PREFIX      = "ignorePrefix"
+	SKIPPED_IGNORE_FILE        = "ignoreFile"
+	SKIPPED_EXTENSION          = "extension"
+	SKIPPED_SIDECAR            = "sidecar" // sidecars are part of their video's target
+	SKIPPED_NO_PATTERN_MATCHED = "noPatternMatched"
+	SKIPPED_SPACES             = "spaces"
+	SKIPPED_MISSING_EPISODE_NO = "missingEpisodeNo"
+)
+
+// reasons for which skipped files are listed individually in the summary - all others are only counted
+var listedSkipReasons = []string{SKIPPED_NO_PATTERN_MATCHED, SKIPPED_SPACES, SKIPPED_MISSING_EPISODE_NO}
+
+// skippedFile is a file visited during scan, which did not become a target
+type skippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+func skipped(path string, reason string) *skippedFile {
+	return &skippedFile{Path: path, Reason: reason}
+}
+
+// printSkipped prints a summary of all skipped files grouped by reason
+func printSkipped(printf commons.FormatPrinter, skippedFiles []*skippedFile) {
+	if len(skippedFiles) == 0 {
+		return
+	}
+	byReason := map[string][]string{}
+	for _, s := range skippedFiles {
+		byReason[s.Reason] = append(byReason[s.Reason], s.Path)
+	}
+	reasons := []string{}
+	for reason := range byReason {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	printf("skipped %d files:\n", len(skippedFiles))
+	for _, reason := range reasons {
+		paths := byReason[reason]
+		printf("  %-18s %d\n", reason, len(paths))
+		if commons.IsStringAmong(reason, listedSkipReasons) {
+			for _, path := range paths {
+				printf("    %s\n", path)
+			}
+		}
+	}
+}
+
+// guards reading and updating the report of skipped files by concurrent scans
+var skippedReportLock = sync.Mutex{}
+
+// writeSkipped records the files skipped when scanning a path in a JSON report.
+// The report keeps the results of the latest scan of every scanned path.
+func writeSkipped(reportFile string, scanPath string, skippedFiles []*skippedFile) error {
+	skippedReportLock.Lock()
+	defer skippedReportLock.Unlock()
+
+	report := map[string][]*skippedFile{}
+	raw, err := ioutil.ReadFile(reportFile)
+	if err == nil {
+		if err := json.Unmarshal(raw, &report); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if skippedFiles == nil {
+		skippedFiles = []*skippedFile{}
+	}
+	report[scanPath] = skippedFiles
+	raw, err = json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := files.CreateFolderStructure(filepath.Dir(reportFile)); err != nil {
+		return err
+	}
+	tmp := reportFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, raw, os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, reportFile)
+}
@@ -0,0 +1,73 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+)
+
+func TestPrintSkipped(t *testing.T) {
+	assert := test.AssertOn(t)
+	out := []string{}
+	printf := func(format string, args ...interface{}) {
+		out = append(out, fmt.Sprintf(format, args...))
+	}
+	printSkipped(printf, []*skippedFile{
+		skipped("/in/a.nfo", SKIPPED_EXTENSION),
+		skipped("/in/b.nfo", SKIPPED_EXTENSION),
+		skipped("/in/Sesaon 1/e01.avi", SKIPPED_NO_PATTERN_MATCHED)})
+	printed := strings.Join(out, "")
+	assert.True("expected total count")(strings.Contains(printed, "skipped 3 files"))
+	assert.True("expected unmatched file to be listed")(strings.Contains(printed, "/in/Sesaon 1/e01.avi"))
+	assert.False("expected files with other extensions only to be counted")(strings.Contains(printed, "/in/a.nfo"))
+}
+
+func TestWriteSkipped(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	reportFile := filepath.Join(dir, "report", "skipped.json")
+
+	assert.NotError(writeSkipped(reportFile, "/in/a", []*skippedFile{skipped("/in/a/x.avi", SKIPPED_SPACES)}))
+	assert.NotError(writeSkipped(reportFile, "/in/b", nil))
+	assert.NotError(writeSkipped(reportFile, "/in/a", []*skippedFile{skipped("/in/a/y.avi", SKIPPED_NO_PATTERN_MATCHED)}))
+
+	raw, err := ioutil.ReadFile(reportFile)
+	assert.NotError(err)
+	report := map[string][]*skippedFile{}
+	assert.NotError(json.Unmarshal(raw, &report))
+	assert.IntsEqual(2, len(report))
+	assert.IntsEqual(0, len(report["/in/b"]))
+	assert.IntsEqual(1, len(report["/in/a"]))
+	assert.StringsEqual("/in/a/y.avi", report["/in/a"][0].Path)
+	assert.StringsEqual(SKIPPED_NO_PATTERN_MATCHED, report["/in/a"][0].Reason)
+}
+
+func TestWriteSkippedConcurrently(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	reportFile := filepath.Join(dir, "skipped.json")
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(scanPath string) {
+			defer wg.Done()
+			assert.NotError(writeSkipped(reportFile, scanPath, []*skippedFile{skipped(scanPath+"/x.avi", SKIPPED_SPACES)}))
+		}(fmt.Sprintf("/in/%d", i))
+	}
+	wg.Wait()
+
+	raw, err := ioutil.ReadFile(reportFile)
+	assert.NotError(err)
+	report := map[string][]*skippedFile{}
+	assert.NotError(json.Unmarshal(raw, &report))
+	assert.IntsEqual(10, len(report))
+}
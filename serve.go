@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/logger"
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-cli/pipeline"
+	"github.com/thomasschoeftner/go-cli/require"
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/api"
+	"github.com/thomasschoeftner/go-ripper/report"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+// serve runs the HTTP control API until the application is terminated.
+// Targets passed on the command line are submitted along with all other tasks passed.
+func serve(conf *ripper.AppConf, allTasks task.TaskSequence, taskMap task.TaskMap, taskNames []string, targets []string, rec *report.Recorder) int {
+	require.True(conf.Api != nil, "HTTP control API is not configured")
+	launchedAt := time.Now()
+
+	queue := api.NewQueue(validateTasks(taskMap), runTasks(conf, allTasks, taskMap), conf.Api.QueueSize)
+	server := &http.Server{Addr: conf.Api.Address, Handler: api.Handler(queue)}
+
+	aborted := make(chan os.Signal, 1)
+	go abortOnSignal(aborted)
+	go queue.Process()
+
+	go func() {
+		fmt.Printf("serving HTTP control API at %s\n", conf.Api.Address)
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			logger.Fatal(err)
+		}
+	}()
+
+	if otherTasks := withoutTasks(taskNames, TaskName_Serve); len(otherTasks) > 0 {
+		for _, target := range targets {
+			_, err := queue.Submit(target, otherTasks)
+			require.NotFailed(err)
+		}
+	}
+
+	sig := <-aborted
+	server.Shutdown(context.Background())
+	queue.Close()
+	queue.Wait()
+	if rec != nil {
+		if err := rec.Finish(launchedAt, time.Now()); err != nil {
+			logger.Error(err)
+		}
+	}
+	return exitCodeAbortedBySignalBase + signalNumber(sig)
+}
+
+func validateTasks(taskMap task.TaskMap) api.Validator {
+	return func(taskNames []string) error {
+		for _, name := range taskNames {
+			if commons.IsStringAmong(name, []string{TaskName_Serve, TaskName_Watch, TaskName_Tasks}) {
+				return fmt.Errorf("task \"%s\" cannot be run via HTTP control API", name)
+			}
+		}
+		_, err := taskMap.CompileTasksForNamesCompact(taskNames...)
+		return err
+	}
+}
+
+// runTasks processes each job in a dedicated pipeline - so every job can run different tasks
+func runTasks(conf *ripper.AppConf, allTasks task.TaskSequence, taskMap task.TaskMap) api.Runner {
+	return func(path string, taskNames []string) error {
+		if err := ripper.ValidateTarget(conf, path); err != nil {
+			return err
+		}
+		tasksToRun, err := taskMap.CompileTasksForNamesCompact(taskNames...)
+		if err != nil {
+			return err
+		}
+		pipe, err := pipeline.Materialize(tasksToRun).WithConfig(conf.Processing, conf, allTasks, *isLazy)
+		if err != nil {
+			return err
+		}
+		go fillPipelineAndClose(pipe, []string{path})
+
+		var jobErr error
+		for event := range pipe.Events {
+			if isError, err, _ := event.IsError(); isError && jobErr == nil {
+				jobErr = err
+			} else if isCanceled, reason := event.IsCanceled(); isCanceled && jobErr == nil {
+				jobErr = fmt.Errorf("processing canceled due to reason: %s", reason)
+			}
+		}
+		return jobErr
+	}
+}
+
+func withoutTasks(taskNames []string, excluded ...string) []string {
+	remaining := []string{}
+	for _, name := range taskNames {
+		if !commons.IsStringAmong(name, excluded) {
+			remaining = append(remaining, name)
+		}
+	}
+	return remaining
+}
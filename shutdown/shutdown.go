@@ -0,0 +1,142 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+type Cleanup func() error
+
+// Hook is a cleanup action which is run at most once - either explicitly or when the application is aborted
+type Hook struct {
+	desc    string
+	cleanup Cleanup
+	once    sync.Once
+	err     error
+}
+
+// Run unregisters the hook and executes its cleanup (unless it has been executed already during an abort)
+func (h *Hook) Run() error {
+	unregister(h)
+	h.once.Do(func() {
+		h.err = h.cleanup()
+	})
+	return h.err
+}
+
+// Release unregisters the hook without executing its cleanup
+func (h *Hook) Release() {
+	unregister(h)
+}
+
+func (h *Hook) String() string {
+	return h.desc
+}
+
+var (
+	lock        = sync.Mutex{}
+	hooks       []*Hook
+	aborting    = false
+	interrupted = false
+)
+
+// OnAbort registers a cleanup action to be executed if the application is aborted before the hook is run or released
+func OnAbort(desc string, cleanup Cleanup) *Hook {
+	h := &Hook{desc: desc, cleanup: cleanup}
+	lock.Lock()
+	defer lock.Unlock()
+	hooks = append(hooks, h)
+	return h
+}
+
+func unregister(h *Hook) {
+	lock.Lock()
+	defer lock.Unlock()
+	for idx, registered := range hooks {
+		if registered == h {
+			hooks = append(hooks[:idx], hooks[idx+1:]...)
+			return
+		}
+	}
+}
+
+// IsAborting checks if the application is aborted, or the current processing is interrupted
+func IsAborting() bool {
+	lock.Lock()
+	defer lock.Unlock()
+	return aborting || interrupted
+}
+
+// Abort runs all pending hooks in reverse order of their registration and returns the errors of failed cleanups
+func Abort() []error {
+	lock.Lock()
+	aborting = true
+	lock.Unlock()
+	return runPending()
+}
+
+// Interrupt stops the current processing like Abort, but the application can continue processing after ClearInterrupt
+func Interrupt() []error {
+	lock.Lock()
+	interrupted = true
+	lock.Unlock()
+	return runPending()
+}
+
+func ClearInterrupt() {
+	lock.Lock()
+	defer lock.Unlock()
+	interrupted = false
+}
+
+func runPending() []error {
+	lock.Lock()
+	pending := hooks
+	hooks = nil
+	lock.Unlock()
+
+	errs := []error{}
+	for i := len(pending) - 1; i >= 0; i-- {
+		h := pending[i]
+		h.once.Do(func() {
+			h.err = h.cleanup()
+		})
+		if h.err != nil {
+			errs = append(errs, fmt.Errorf("cleanup \"%s\" failed due to: %s", h.desc, h.err))
+		}
+	}
+	return errs
+}
+
+func ErrAborted(what string) error {
+	return fmt.Errorf("processing was aborted - skip %s", what)
+}
+
+// Executable is satisfied by commands created via cli.Command(...)
+type Executable interface {
+	fmt.Stringer
+	ExecuteAsync(stdOut io.Writer, errOut io.Writer) (*exec.Cmd, context.CancelFunc, error)
+}
+
+// ExecuteSync runs an external command and waits for its completion - the command is killed if the application is aborted
+func ExecuteSync(cmd Executable, stdOut io.Writer, errOut io.Writer) error {
+	if IsAborting() {
+		return ErrAborted(cmd.String())
+	}
+	proc, cancel, err := cmd.ExecuteAsync(stdOut, errOut)
+	if err != nil {
+		if cancel != nil {
+			cancel()
+		}
+		return err
+	}
+	kill := OnAbort(fmt.Sprintf("kill %s", cmd.String()), func() error {
+		cancel()
+		return nil
+	})
+	defer kill.Run()
+	return proc.Wait()
+}
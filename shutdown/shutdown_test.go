@@ -0,0 +1,74 @@
+package shutdown
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+)
+
+func reset() {
+	lock.Lock()
+	defer lock.Unlock()
+	hooks = nil
+	aborting = false
+	interrupted = false
+}
+
+func TestAbort(t *testing.T) {
+	t.Run("run pending hooks in reverse order", func(t *testing.T) {
+		reset()
+		assert := test.AssertOn(t)
+		executed := []string{}
+		OnAbort("first", func() error { executed = append(executed, "first"); return nil })
+		OnAbort("second", func() error { executed = append(executed, "second"); return nil })
+
+		assert.False("expected not to be aborting before abort")(IsAborting())
+		errs := Abort()
+		assert.IntsEqual(0, len(errs))
+		assert.True("expected to be aborting after abort")(IsAborting())
+		assert.StringSlicesEqual([]string{"second", "first"}, executed)
+	})
+
+	t.Run("do not run released or already run hooks", func(t *testing.T) {
+		reset()
+		assert := test.AssertOn(t)
+		runs := 0
+		released := OnAbort("released", func() error { runs++; return nil })
+		alreadyRun := OnAbort("already run", func() error { runs++; return nil })
+		released.Release()
+		assert.NotError(alreadyRun.Run())
+		assert.IntsEqual(1, runs)
+
+		Abort()
+		assert.IntsEqual(1, runs)
+		assert.NotError(alreadyRun.Run())
+		assert.IntsEqual(1, runs)
+	})
+
+	t.Run("collect errors of failed hooks", func(t *testing.T) {
+		reset()
+		assert := test.AssertOn(t)
+		failing := OnAbort("failing", func() error { return errors.New("test error") })
+		OnAbort("succeeding", func() error { return nil })
+
+		errs := Abort()
+		assert.IntsEqual(1, len(errs))
+		assert.ExpectError("expected error of hook executed during abort")(failing.Run())
+	})
+}
+
+func TestInterrupt(t *testing.T) {
+	reset()
+	assert := test.AssertOn(t)
+	runs := 0
+	OnAbort("interrupted", func() error { runs++; return nil })
+
+	errs := Interrupt()
+	assert.IntsEqual(0, len(errs))
+	assert.IntsEqual(1, runs)
+	assert.True("expected to be aborting while interrupted")(IsAborting())
+
+	ClearInterrupt()
+	assert.False("expected to continue processing after interrupt was cleared")(IsAborting())
+}
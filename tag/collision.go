@@ -0,0 +1,174 @@
+package tag
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ledger"
+	"github.com/thomasschoeftner/go-ripper/processor"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+const (
+	COLLISION_FAIL        = "fail"
+	COLLISION_SKIP        = "skip"
+	COLLISION_OVERWRITE   = "overwrite"
+	COLLISION_APPEND_YEAR = "appendYear"
+	COLLISION_APPEND_ID   = "appendId"
+	COLLISION_NUMBER      = "number"
+)
+
+var collisionPolicies = []string{COLLISION_FAIL, COLLISION_SKIP, COLLISION_OVERWRITE, COLLISION_APPEND_YEAR, COLLISION_APPEND_ID, COLLISION_NUMBER}
+
+func collisionPolicyOf(conf *ripper.OutputConfig) (string, error) {
+	policy := conf.Collision
+	if len(policy) == 0 {
+		return COLLISION_FAIL, nil
+	}
+	for _, p := range collisionPolicies {
+		if p == policy {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown collision policy configured: \"%s\" - valid policies are %v", policy, collisionPolicies)
+}
+
+// claimDestination checks for existing files at the destination before tagging and applies the collision policy.
+// The destination previously recorded for the same target is re-used and overwritten.
+func claimDestination(conf *ripper.AppConf, ti targetinfo.TargetInfo, dst string, year string) (string, error) {
+	if recorded, err := RecordedDestination(conf, ti); err != nil || len(recorded) > 0 {
+		return recorded, err
+	}
+
+	policy, err := collisionPolicyOf(conf.Output)
+	if err != nil {
+		return "", err
+	}
+	exists, err := files.Exists(dst)
+	if err != nil || !exists {
+		return dst, err
+	}
+
+	switch policy {
+	case COLLISION_SKIP:
+		return dst, &processor.Skipped{Reason: fmt.Sprintf("skip tagging of %s - destination %s already exists", ti.GetFullPath(), dst)}
+	case COLLISION_OVERWRITE:
+		return dst, nil
+	case COLLISION_APPEND_YEAR:
+		return withUnusedSuffix(dst, year, "year")
+	case COLLISION_APPEND_ID:
+		return withUnusedSuffix(dst, ti.GetId(), "id")
+	case COLLISION_NUMBER:
+		for no := 2; ; no++ {
+			numbered := withSuffix(dst, fmt.Sprintf("%d", no))
+			if exists, err := files.Exists(numbered); err != nil || !exists {
+				return numbered, err
+			}
+		}
+	default:
+		return "", fmt.Errorf("destination %s already exists", dst)
+	}
+}
+
+// tagAt claims the destination, runs the tagger, and records the final destination of the target
+func tagAt(conf *ripper.AppConf, ti targetinfo.TargetInfo, dst string, year string, tag func(outputFile string) error) error {
+	dst, err := claimDestination(conf, ti, dst, year)
+	if _, isSkipped := err.(*processor.Skipped); isSkipped {
+		if recErr := recordSkipped(conf, ti, dst); recErr != nil {
+			return recErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !conf.DryRun {
+		err = files.CreateFolderStructure(filepath.Dir(dst))
+		if err != nil {
+			return err
+		}
+	}
+	if err := tag(dst); err != nil {
+		return err
+	}
+	return recordDestination(conf, ti, dst)
+}
+
+// taggedOutputFile locates the tagged output at its destination - after tagging, this is the destination actually used
+func taggedOutputFile(conf *ripper.AppConf) processor.OutputFile {
+	return func(ti targetinfo.TargetInfo, workDir string) (string, error) {
+		return DestinationPathFor(conf, ti)
+	}
+}
+
+func withSuffix(dst string, suffix string) string {
+	name, ext := files.SplitExtension(dst)
+	return files.WithExtension(fmt.Sprintf("%s (%s)", name, suffix), ext)
+}
+
+func withUnusedSuffix(dst string, suffix string, suffixName string) (string, error) {
+	if len(suffix) == 0 {
+		return "", fmt.Errorf("destination %s already exists and %s is unknown", dst, suffixName)
+	}
+	suffixed := withSuffix(dst, suffix)
+	if exists, err := files.Exists(suffixed); err != nil {
+		return "", err
+	} else if exists {
+		return "", fmt.Errorf("destination %s already exists", suffixed)
+	}
+	return suffixed, nil
+}
+
+// recordDestination remembers the final destination of a target in the ledger, so it survives runs with varying work directories - nothing is recorded during dry-runs
+func recordDestination(conf *ripper.AppConf, ti targetinfo.TargetInfo, dst string) error {
+	if conf.DryRun || len(conf.StateDirectory()) == 0 {
+		return nil
+	}
+	l, err := ledger.Open(conf.StateDirectory())
+	if err != nil {
+		return err
+	}
+	return l.RecordDestination(ti.GetFullPath(), dst)
+}
+
+// RecordedDestination returns the recorded destination of a target, or an empty string if it was not tagged yet
+func RecordedDestination(conf *ripper.AppConf, ti targetinfo.TargetInfo) (string, error) {
+	entry, err := ledgerEntryOf(conf, ti)
+	if err != nil || entry == nil {
+		return "", err
+	}
+	return entry.Destination, nil
+}
+
+// recordSkipped remembers that tagging of a target was skipped due to an existing file at its destination - nothing is recorded during dry-runs
+func recordSkipped(conf *ripper.AppConf, ti targetinfo.TargetInfo, existing string) error {
+	if conf.DryRun || len(conf.StateDirectory()) == 0 {
+		return nil
+	}
+	l, err := ledger.Open(conf.StateDirectory())
+	if err != nil {
+		return err
+	}
+	return l.RecordSkipped(ti.GetFullPath(), existing)
+}
+
+// SkippedDestination returns the existing file which caused tagging of a target to be skipped, or an empty string if it was not skipped
+func SkippedDestination(conf *ripper.AppConf, ti targetinfo.TargetInfo) (string, error) {
+	entry, err := ledgerEntryOf(conf, ti)
+	if err != nil || entry == nil {
+		return "", err
+	}
+	return entry.SkippedBy, nil
+}
+
+func ledgerEntryOf(conf *ripper.AppConf, ti targetinfo.TargetInfo) (*ledger.Entry, error) {
+	if len(conf.StateDirectory()) == 0 {
+		return nil, nil
+	}
+	l, err := ledger.Open(conf.StateDirectory())
+	if err != nil {
+		return nil, err
+	}
+	return l.Get(ti.GetFullPath()), nil
+}
@@ -0,0 +1,168 @@
+package tag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/ledger"
+	"github.com/thomasschoeftner/go-ripper/processor"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+func TestClaimDestination(t *testing.T) {
+	setup := func(t *testing.T, policy string, existing ...string) (*test.Assertion, *ripper.AppConf, string, func()) {
+		dir := test.MkTempFolder(t)
+		conf := &ripper.AppConf{
+			WorkDirectory: filepath.Join(dir, "work"),
+			Output:        &ripper.OutputConfig{Collision: policy},
+		}
+		for _, f := range existing {
+			test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, f), []byte("content"), os.ModePerm))
+		}
+		return test.AssertOn(t), conf, dir, func() { test.RmTempFolder(t, dir) }
+	}
+	ti := targetinfo.NewMovie("dune.avi", "/in", "tt0001")
+
+	t.Run("free destination is used regardless of policy", func(t *testing.T) {
+		assert, conf, dir, cleanup := setup(t, COLLISION_FAIL)
+		defer cleanup()
+		dst := filepath.Join(dir, "Dune.mp4")
+		assert.StringsEqual(dst, assert.StringNotError(claimDestination(conf, ti, dst, "2021")))
+	})
+
+	t.Run("fail by default", func(t *testing.T) {
+		assert, conf, dir, cleanup := setup(t, "", "Dune.mp4")
+		defer cleanup()
+		_, err := claimDestination(conf, ti, filepath.Join(dir, "Dune.mp4"), "2021")
+		assert.ExpectError("expected error for existing destination")(err)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		assert, conf, dir, cleanup := setup(t, COLLISION_SKIP, "Dune.mp4")
+		defer cleanup()
+		_, err := claimDestination(conf, ti, filepath.Join(dir, "Dune.mp4"), "2021")
+		_, isSkipped := err.(*processor.Skipped)
+		assert.True("expected target to be skipped")(isSkipped)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		assert, conf, dir, cleanup := setup(t, COLLISION_OVERWRITE, "Dune.mp4")
+		defer cleanup()
+		dst := filepath.Join(dir, "Dune.mp4")
+		assert.StringsEqual(dst, assert.StringNotError(claimDestination(conf, ti, dst, "2021")))
+	})
+
+	t.Run("append year", func(t *testing.T) {
+		assert, conf, dir, cleanup := setup(t, COLLISION_APPEND_YEAR, "Dune.mp4")
+		defer cleanup()
+		dst := filepath.Join(dir, "Dune.mp4")
+		assert.StringsEqual(filepath.Join(dir, "Dune (2021).mp4"), assert.StringNotError(claimDestination(conf, ti, dst, "2021")))
+		_, err := claimDestination(conf, ti, dst, "")
+		assert.ExpectError("expected error for unknown year")(err)
+	})
+
+	t.Run("append id", func(t *testing.T) {
+		assert, conf, dir, cleanup := setup(t, COLLISION_APPEND_ID, "Dune.mp4", "Dune (tt0001).mp4")
+		defer cleanup()
+		_, err := claimDestination(conf, ti, filepath.Join(dir, "Dune.mp4"), "2021")
+		assert.ExpectError("expected error if destination with id exists as well")(err)
+	})
+
+	t.Run("numbered suffix", func(t *testing.T) {
+		assert, conf, dir, cleanup := setup(t, COLLISION_NUMBER, "Dune.mp4", "Dune (2).mp4")
+		defer cleanup()
+		dst := filepath.Join(dir, "Dune.mp4")
+		assert.StringsEqual(filepath.Join(dir, "Dune (3).mp4"), assert.StringNotError(claimDestination(conf, ti, dst, "2021")))
+	})
+
+	t.Run("recorded destination is re-used", func(t *testing.T) {
+		assert, conf, dir, cleanup := setup(t, COLLISION_NUMBER, "Dune.mp4", "Dune (2).mp4")
+		defer cleanup()
+		recorded := filepath.Join(dir, "Dune (2).mp4")
+		assert.NotError(recordDestination(conf, ti, recorded))
+		assert.StringsEqual(recorded, assert.StringNotError(claimDestination(conf, ti, filepath.Join(dir, "Dune.mp4"), "2021")))
+		assert.StringsEqual(recorded, assert.StringNotError(DestinationPathFor(conf, ti)))
+	})
+
+	t.Run("expect error for unknown policy", func(t *testing.T) {
+		assert, conf, dir, cleanup := setup(t, "unknown", "Dune.mp4")
+		defer cleanup()
+		_, err := claimDestination(conf, ti, filepath.Join(dir, "Dune.mp4"), "2021")
+		assert.ExpectError("expected error for unknown collision policy")(err)
+	})
+}
+
+func TestTagAt(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	conf := &ripper.AppConf{
+		WorkDirectory: filepath.Join(dir, "work"),
+		Output:        &ripper.OutputConfig{Collision: COLLISION_NUMBER},
+	}
+	ti := targetinfo.NewMovie("dune.avi", "/in", "tt0001")
+	dst := filepath.Join(dir, "out", "Dune.mp4")
+	test.CheckError(t, os.MkdirAll(filepath.Dir(dst), os.ModePerm))
+	test.CheckError(t, ioutil.WriteFile(dst, []byte("remake"), os.ModePerm))
+
+	var tagged string
+	assert.NotError(tagAt(conf, ti, dst, "2021", func(outputFile string) error {
+		tagged = outputFile
+		return nil
+	}))
+	assert.StringsEqual(filepath.Join(dir, "out", "Dune (2).mp4"), tagged)
+	assert.StringsEqual(tagged, assert.StringNotError(RecordedDestination(conf, ti)))
+}
+
+func TestTagAtSkipped(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	conf := &ripper.AppConf{
+		WorkDirectory: filepath.Join(dir, "work"),
+		Output:        &ripper.OutputConfig{Collision: COLLISION_SKIP},
+	}
+	ti := targetinfo.NewMovie("dune.avi", "/in", "tt0001")
+	dst := filepath.Join(dir, "Dune.mp4")
+	test.CheckError(t, ioutil.WriteFile(dst, []byte("remake"), os.ModePerm))
+
+	tagged := false
+	err := tagAt(conf, ti, dst, "2021", func(outputFile string) error {
+		tagged = true
+		return nil
+	})
+	_, isSkipped := err.(*processor.Skipped)
+	assert.True("expected target to be skipped")(isSkipped)
+	assert.False("expected tagger not to run")(tagged)
+	assert.StringsEqual(dst, assert.StringNotError(SkippedDestination(conf, ti)))
+	_, err = DestinationPathFor(conf, ti)
+	assert.ExpectError("expected no destination for skipped target")(err)
+
+	conf.Output.Collision = COLLISION_NUMBER
+	assert.NotError(tagAt(conf, ti, dst, "2021", func(outputFile string) error { return nil }))
+	assert.StringsEqual("", assert.StringNotError(SkippedDestination(conf, ti)))
+	assert.StringsEqual(filepath.Join(dir, "Dune (2).mp4"), assert.StringNotError(DestinationPathFor(conf, ti)))
+}
+
+func TestRecordedDestinationAcrossWorkDirectories(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	conf := &ripper.AppConf{StoragePath: filepath.Join(dir, "storage"), WorkDirectory: filepath.Join(dir, "work-1")}
+	ti := targetinfo.NewMovie("dune.avi", "/in", "tt0087182")
+	assert.NotError(recordSkipped(conf, ti, "/out/Dune.mp4"))
+
+	conf.WorkDirectory = filepath.Join(dir, "work-2")
+	assert.StringsEqual("/out/Dune.mp4", assert.StringNotError(SkippedDestination(conf, ti)))
+
+	l, err := ledger.Open(conf.StateDirectory())
+	assert.NotError(err)
+	assert.NotError(l.Move(ti.GetFullPath(), "/in/moved/dune.avi"))
+	moved := targetinfo.NewMovie("dune.avi", "/in/moved", "tt0087182")
+	assert.StringsEqual("/out/Dune.mp4", assert.StringNotError(SkippedDestination(conf, moved)))
+	assert.StringsEqual("", assert.StringNotError(SkippedDestination(conf, ti)))
+}
@@ -5,12 +5,20 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/thomasschoeftner/go-cli/cli"
 	"github.com/thomasschoeftner/go-cli/commons"
 	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ledger"
+	"github.com/thomasschoeftner/go-ripper/metainfo/audio"
+	"github.com/thomasschoeftner/go-ripper/metainfo/video"
+	"github.com/thomasschoeftner/go-ripper/processor"
 	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/shutdown"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
 )
 
 const conf_tagger_ffmpeg = "ffmpeg"
@@ -23,6 +31,7 @@ const (
 
 	ffmpeg_tagTitleKey       = "title"
 	ffmpeg_tagDescriptionKey = "description"
+	ffmpeg_tagSynopsisKey    = "synopsis"
 	ffmpeg_tagGenreKey       = "genre"
 	ffmpeg_tagYearKey        = "year"
 
@@ -35,20 +44,51 @@ const (
 	ffmpeg_tagCommentKey = "comment"
 	ffmpeg_tagAlbumKey   = "album"
 	ffmpeg_tagTrackKey   = "track"
+
+	ffmpeg_tagArtistKey      = "artist"
+	ffmpeg_tagAlbumArtistKey = "album_artist"
+	ffmpeg_tagDiscKey        = "disc"
+	ffmpeg_tagDateKey        = "date"
+)
+
+var (
+	ffmpeg_mp4Extensions          = []string{"mp4", "m4v"}
+	ffmpeg_textSubtitleExtensions = []string{"srt", "ass", "ssa"}
 )
 
 func createFFMPEGVideoTagger(conf *ripper.AppConf, lazy bool, printf commons.FormatPrinter) (MovieTagger, EpisodeTagger, error) {
-	apConf := conf.Tag.Video.FFMPEG
-	workDir := conf.WorkDirectory
+	tagCtx, err := newFFMPEGTagger(conf.Tag.Video.FFMPEG, conf.WorkDirectory, conf.DryRun, printf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tagCtx.movie, tagCtx.episode, nil
+}
+
+func createFFMPEGAudioTagger(conf *ripper.AppConf, lazy bool, printf commons.FormatPrinter) (TrackTagger, error) {
+	tagCtx, err := newFFMPEGTagger(conf.Tag.Audio.FFMPEG, conf.WorkDirectory, conf.DryRun, printf)
+	if err != nil {
+		return nil, err
+	}
+	return tagCtx.track, nil
+}
+
+func newFFMPEGTagger(apConf *ripper.FFMPEGConfig, workDir string, dryRun bool, printf commons.FormatPrinter) (*ffmpegTagger, error) {
+	if apConf == nil {
+		return nil, fmt.Errorf("ffmpeg tagger is not configured")
+	}
 	tagCtx := &ffmpegTagger{}
 	var err error
 
 	tagCtx.timeout, err = time.ParseDuration(apConf.Timeout)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	tagCtx.path = apConf.Path
+	tagCtx.dryRun = dryRun
+	if !dryRun {
+		processor.DetectToolVersion(ledger.STAGE_TAGGED, conf_tagger_ffmpeg, apConf.Path, "-version")
+	}
 
 	if apConf.ShowErrorOutput {
 		tagCtx.errout = os.Stderr
@@ -59,7 +99,7 @@ func createFFMPEGVideoTagger(conf *ripper.AppConf, lazy bool, printf commons.For
 
 	tagCtx.printf = printf.WithIndent(2)
 	tagCtx.tempDir = filepath.Join(workDir, files.TEMP_DIR_NAME)
-	return tagCtx.movie, tagCtx.episode, nil
+	return tagCtx, nil
 }
 
 type ffmpegTagger struct {
@@ -70,39 +110,191 @@ type ffmpegTagger struct {
 	errout   io.Writer
 	evacuate files.EvacuatorFunc
 	tempDir  string
+	dryRun   bool
 }
 
-func (ffmpeg *ffmpegTagger) movie(inFile string, outFile string, id string, title string, year string, posterPath string) error {
-	cmd := cli.Command(ffmpeg.path, ffmpeg.timeout). //WithQuotes(" ", '"').
-								WithParam(ffmpeg_paramInputFile, inFile, "").
-								WithParam(ffmpeg_paramInputFile, posterPath, "").
-								WithParam("-map", "0", "").
-								WithParam("-map", "1", "").
-								WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagTitleKey, title), "").
-								WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagYearKey, year), "").
-								WithParam("-c", "copy", "").                       // do not perform encode step
-								WithParam("-disposition:v:1", "attached_pic", ""). // use 2nd input file as artwork
-								WithArgument(fmt.Sprintf("%s", outFile))
-
-	ffmpeg.printf(">>>> %s\n", cmd.String()) // TODO - comment out
-	return cmd.ExecuteSync(ffmpeg.stdout, ffmpeg.errout)
+func (ffmpeg *ffmpegTagger) movie(inFile string, outFile string, id string, title string, year string, details *video.Details, posterPath string, sidecars []targetinfo.Sidecar) error {
+	cmd := cli.Command(ffmpeg.path, ffmpeg.timeout).
+		WithParam(ffmpeg_paramInputFile, inFile, "").
+		WithParam(ffmpeg_paramInputFile, posterPath, "")
+	for _, p := range append(sidecarInputs(sidecars), streamMappings(sidecars)...) {
+		cmd = cmd.WithParam(p.key, p.value, "")
+	}
+	cmd = cmd.WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagTitleKey, title), "").
+		WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagYearKey, year), "")
+	for _, p := range detailParams(details) {
+		cmd = cmd.WithParam(p.key, p.value, "")
+	}
+	cmd = cmd.WithParam("-c", "copy", "") // do not perform encode step
+	for _, p := range sidecarStreamParams(sidecars, outFile) {
+		cmd = cmd.WithParam(p.key, p.value, "")
+	}
+	// use 2nd input file as artwork - collisions with existing output files are resolved before tagging
+	cmd = cmd.WithParam("-disposition:v:1", "attached_pic", "").
+		WithArgument("-y").
+		WithArgument(outFile)
+	return ffmpeg.execute(cmd, outFile)
 }
 
-func (ffmpeg *ffmpegTagger) episode(inFile string, outFile string, id string, series string, season int, episode int, title string, year string, posterPath string) error {
-	cmd := cli.Command(ffmpeg.path, ffmpeg.timeout). //WithQuotes(" ", '"').
-								WithParam(ffmpeg_paramInputFile, inFile, "").
-								WithParam(ffmpeg_paramInputFile, posterPath, "").
-								WithParam("-map", "0", "").
-								WithParam("-map", "1", "").
-								WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagTitleKey, title), "").
-								WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagYearKey, year), "").
-								WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagSeriesNameKey, series), "").
-								WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%d", ffmpeg_tagGroupingKey, season), "").
-								WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%d", ffmpeg_tagEpisodeKey, episode), "").
-								WithParam("-c", "copy", "").                       // do not perform encode step
-								WithParam("-disposition:v:1", "attached_pic", ""). // use 2nd input file as artwork
-								WithArgument(fmt.Sprintf("%s", outFile))
-
-	ffmpeg.printf(">>>> %s\n", cmd.String())
-	return cmd.ExecuteSync(ffmpeg.stdout, ffmpeg.errout)
+func (ffmpeg *ffmpegTagger) episode(inFile string, outFile string, id string, series string, season int, episode int, lastEpisode int, title string, year string, details *video.Details, posterPath string, sidecars []targetinfo.Sidecar) error {
+	cmd := cli.Command(ffmpeg.path, ffmpeg.timeout).
+		WithParam(ffmpeg_paramInputFile, inFile, "").
+		WithParam(ffmpeg_paramInputFile, posterPath, "")
+	for _, p := range append(sidecarInputs(sidecars), streamMappings(sidecars)...) {
+		cmd = cmd.WithParam(p.key, p.value, "")
+	}
+	cmd = cmd.WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagTitleKey, title), "").
+		WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagYearKey, year), "").
+		WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagSeriesNameKey, series), "").
+		WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%d", ffmpeg_tagGroupingKey, season), "").
+		WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagEpisodeKey, episodeNumber(episode, lastEpisode)), "")
+	for _, p := range detailParams(details) {
+		cmd = cmd.WithParam(p.key, p.value, "")
+	}
+	cmd = cmd.WithParam("-c", "copy", "") // do not perform encode step
+	for _, p := range sidecarStreamParams(sidecars, outFile) {
+		cmd = cmd.WithParam(p.key, p.value, "")
+	}
+	// use 2nd input file as artwork - collisions with existing output files are resolved before tagging
+	cmd = cmd.WithParam("-disposition:v:1", "attached_pic", "").
+		WithArgument("-y").
+		WithArgument(outFile)
+	return ffmpeg.execute(cmd, outFile)
+}
+
+// ffmpegParam is a command-line parameter of ffmpeg and its value
+type ffmpegParam struct {
+	key   string
+	value string
+}
+
+// detailParams tags the plot as description, the genres and a summary of credits, rating and runtime as comment - empty details are omitted
+func detailParams(details *video.Details) []ffmpegParam {
+	params := []ffmpegParam{}
+	if details == nil {
+		return params
+	}
+	metadata := func(key string, value string) {
+		if len(value) > 0 {
+			params = append(params, ffmpegParam{ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", key, value)})
+		}
+	}
+	metadata(ffmpeg_tagDescriptionKey, details.Plot)
+	metadata(ffmpeg_tagSynopsisKey, details.Plot)
+	metadata(ffmpeg_tagGenreKey, strings.Join(details.Genres, ", "))
+	metadata(ffmpeg_tagCommentKey, detailsSummary(details))
+	return params
+}
+
+// detailsSummary describes credits, rating and runtime, e.g. "Director: Christopher Nolan | Cast: Christian Bale | Rated: PG-13 | Runtime: 152 min | IMDb: 9.0"
+func detailsSummary(details *video.Details) string {
+	summary := []string{}
+	add := func(label string, value string) {
+		if len(value) > 0 {
+			summary = append(summary, fmt.Sprintf("%s: %s", label, value))
+		}
+	}
+	add("Director", strings.Join(details.Directors, ", "))
+	add("Writer", strings.Join(details.Writers, ", "))
+	add("Cast", strings.Join(details.Actors, ", "))
+	add("Rated", details.Rated)
+	if details.Runtime > 0 {
+		add("Runtime", fmt.Sprintf("%d min", details.Runtime))
+	}
+	add("Language", strings.Join(details.Languages, ", "))
+	add("Country", strings.Join(details.Countries, ", "))
+	if details.ImdbRating > 0 {
+		add("IMDb", strconv.FormatFloat(details.ImdbRating, 'f', 1, 64))
+	}
+	return strings.Join(summary, " | ")
+}
+
+// sidecarInputs adds all sidecars as inputs following the video (1st input) and the poster (2nd input)
+func sidecarInputs(sidecars []targetinfo.Sidecar) []ffmpegParam {
+	params := []ffmpegParam{}
+	for _, s := range sidecars {
+		params = append(params, ffmpegParam{ffmpeg_paramInputFile, s.File})
+	}
+	return params
+}
+
+// streamMappings maps the streams of the video, the poster and all sidecars to the output.
+// Sidecars are mapped before the video's own audio and subtitle streams, so their indexes in the output are known.
+func streamMappings(sidecars []targetinfo.Sidecar) []ffmpegParam {
+	if len(sidecars) == 0 {
+		return []ffmpegParam{{"-map", "0"}, {"-map", "1"}}
+	}
+	params := []ffmpegParam{{"-map", "0:v"}, {"-map", "1"}}
+	for i := range sidecars {
+		params = append(params, ffmpegParam{"-map", strconv.Itoa(i + 2)})
+	}
+	return append(params, ffmpegParam{"-map", "0:a?"}, ffmpegParam{"-map", "0:s?"})
+}
+
+// sidecarStreamParams sets language and disposition of all sidecar streams in the output - the video's own audio remains the default.
+// Text subtitles are converted for mp4 outputs, which cannot contain them as they are.
+func sidecarStreamParams(sidecars []targetinfo.Sidecar, outFile string) []ffmpegParam {
+	params := []ffmpegParam{}
+	toMp4 := commons.IsStringAmong(strings.ToLower(files.GetExtension(outFile)), ffmpeg_mp4Extensions)
+	audio, subtitles := 0, 0
+	for _, s := range sidecars {
+		var stream string
+		disposition := "0"
+		if s.Kind == targetinfo.SIDECAR_KIND_AUDIO {
+			stream = fmt.Sprintf("a:%d", audio)
+			audio++
+		} else {
+			stream = fmt.Sprintf("s:%d", subtitles)
+			subtitles++
+			if s.Forced {
+				disposition = "forced"
+			}
+			if toMp4 && commons.IsStringAmong(strings.ToLower(files.GetExtension(s.File)), ffmpeg_textSubtitleExtensions) {
+				params = append(params, ffmpegParam{"-c:" + stream, "mov_text"})
+			}
+		}
+		params = append(params, ffmpegParam{"-disposition:" + stream, disposition})
+		if len(s.Language) > 0 {
+			params = append(params, ffmpegParam{"-metadata:s:" + stream, "language=" + s.Language})
+		}
+	}
+	return params
+}
+
+func (ffmpeg *ffmpegTagger) track(inFile string, outFile string, album *audio.AlbumMetaInfo, track *audio.TrackMetaInfo, posterPath string) error {
+	cmd := cli.Command(ffmpeg.path, ffmpeg.timeout).
+		WithParam(ffmpeg_paramInputFile, inFile, "")
+	if len(posterPath) > 0 {
+		cmd = cmd.WithParam(ffmpeg_paramInputFile, posterPath, "").
+			WithParam("-map", "0:a", "").
+			WithParam("-map", "1", "").
+			WithParam("-disposition:v", "attached_pic", "") // use 2nd input file as cover art
+	}
+	if files.GetExtension(outFile) == "mp3" {
+		cmd = cmd.WithParam("-id3v2_version", "3", "") // most compatible id3 version supporting cover art
+	}
+	cmd = cmd.WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagTitleKey, track.Title), "").
+		WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagArtistKey, track.Artist), "").
+		WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagAlbumArtistKey, album.Artist), "").
+		WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagAlbumKey, album.Title), "").
+		WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%d/%d", ffmpeg_tagTrackKey, track.Track, album.TracksOnDisc(track.Disc)), "").
+		WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%d/%d", ffmpeg_tagDiscKey, track.Disc, album.Discs), "").
+		WithParam(ffmpeg_paramMetaData, fmt.Sprintf("%s=%s", ffmpeg_tagDateKey, album.Year), "").
+		WithParam("-c", "copy", ""). // do not perform encode step
+		WithArgument("-y").
+		WithArgument(outFile)
+	return ffmpeg.execute(cmd, outFile)
+}
+
+func (ffmpeg *ffmpegTagger) execute(cmd shutdown.Executable, outFile string) error {
+	if ffmpeg.dryRun {
+		ffmpeg.printf("dry-run - would run: %s\n", cmd.String())
+		ffmpeg.printf("dry-run - destination: %s\n", outFile)
+		return nil
+	}
+	removePartial := shutdown.OnAbort(fmt.Sprintf("remove partial output %s", outFile), func() error {
+		return os.Remove(outFile)
+	})
+	defer removePartial.Release()
+	return shutdown.ExecuteSync(cmd, ffmpeg.stdout, ffmpeg.errout)
 }
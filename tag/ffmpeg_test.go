@@ -0,0 +1,59 @@
+package tag
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/metainfo/video"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+func paramsToString(params []ffmpegParam) string {
+	s := []string{}
+	for _, p := range params {
+		s = append(s, fmt.Sprintf("%s %s", p.key, p.value))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestStreamMappings(t *testing.T) {
+	assert := test.AssertOn(t)
+	assert.StringsEqual("-map 0 -map 1", paramsToString(streamMappings(nil)))
+
+	sidecars := []targetinfo.Sidecar{
+		{File: "/in/movie.de.srt", Kind: targetinfo.SIDECAR_KIND_SUBTITLE},
+		{File: "/in/movie.en.ac3", Kind: targetinfo.SIDECAR_KIND_AUDIO}}
+	assert.StringsEqual("-i /in/movie.de.srt -i /in/movie.en.ac3", paramsToString(sidecarInputs(sidecars)))
+	assert.StringsEqual("-map 0:v -map 1 -map 2 -map 3 -map 0:a? -map 0:s?", paramsToString(streamMappings(sidecars)))
+}
+
+func TestSidecarStreamParams(t *testing.T) {
+	assert := test.AssertOn(t)
+	sidecars := []targetinfo.Sidecar{
+		{File: "/in/movie.de.srt", Kind: targetinfo.SIDECAR_KIND_SUBTITLE, Language: "ger"},
+		{File: "/in/movie.en.ac3", Kind: targetinfo.SIDECAR_KIND_AUDIO, Language: "eng"},
+		{File: "/in/movie.de.forced.idx", Data: "/in/movie.de.forced.sub", Kind: targetinfo.SIDECAR_KIND_SUBTITLE, Language: "ger", Forced: true},
+		{File: "/in/movie.ass", Kind: targetinfo.SIDECAR_KIND_SUBTITLE}}
+
+	assert.StringsEqual("-c:s:0 mov_text -disposition:s:0 0 -metadata:s:s:0 language=ger "+
+		"-disposition:a:0 0 -metadata:s:a:0 language=eng "+
+		"-disposition:s:1 forced -metadata:s:s:1 language=ger "+
+		"-c:s:2 mov_text -disposition:s:2 0",
+		paramsToString(sidecarStreamParams(sidecars, "/out/movie.mp4")))
+
+	assert.False("expected text subtitles not to be converted for mkv")(strings.Contains(paramsToString(sidecarStreamParams(sidecars, "/out/movie.mkv")), "mov_text"))
+}
+
+func TestDetailParams(t *testing.T) {
+	assert := test.AssertOn(t)
+	assert.IntsEqual(0, len(detailParams(nil)))
+	assert.IntsEqual(0, len(detailParams(&video.Details{})))
+
+	details := &video.Details{Plot: "Batman faces the Joker.", Genres: []string{"Action", "Crime"}, Directors: []string{"Christopher Nolan"},
+		Actors: []string{"Christian Bale", "Heath Ledger"}, Rated: "PG-13", Runtime: 152, ImdbRating: 9}
+	assert.StringsEqual("-metadata description=Batman faces the Joker. -metadata synopsis=Batman faces the Joker. -metadata genre=Action, Crime "+
+		"-metadata comment=Director: Christopher Nolan | Cast: Christian Bale, Heath Ledger | Rated: PG-13 | Runtime: 152 min | IMDb: 9.0",
+		paramsToString(detailParams(details)))
+}
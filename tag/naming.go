@@ -0,0 +1,158 @@
+package tag
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/metainfo/video"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+const (
+	NAMING_PRESET_DEFAULT  = "default"
+	NAMING_PRESET_PLEX     = "plex"
+	NAMING_PRESET_JELLYFIN = "jellyfin"
+)
+
+type namingPreset struct {
+	movie   string
+	episode string
+}
+
+// naming templates produce the output path relative to the output directory, separated by "/" and without file extension.
+// Specials (season 0) are placed in a separate "Specials" folder, multi-episode files are named after their episode range.
+var namingPresets = map[string]namingPreset{
+	NAMING_PRESET_DEFAULT: {
+		movie:   `{{.Movie.Title}}`,
+		episode: `{{.Series.Title}}/{{if .Target.IsSpecial}}Specials{{else}}{{.Episode.Season}}{{end}}/{{.Series.Title}}-s{{printf "%02d" .Episode.Season}}e{{printf "%02d" .Episode.Episode}}{{if .Target.IsMultiEpisode}}-e{{printf "%02d" .Target.LastEpisode}}{{end}}-{{.Episode.Title}}`,
+	},
+	NAMING_PRESET_PLEX: {
+		movie:   `Movies/{{.Movie.Title}}{{if .Movie.Year}} ({{.Movie.Year}}){{end}}/{{.Movie.Title}}{{if .Movie.Year}} ({{.Movie.Year}}){{end}}`,
+		episode: `TV Shows/{{.Series.Title}}/{{if .Target.IsSpecial}}Specials{{else}}Season {{printf "%02d" .Episode.Season}}{{end}}/{{.Series.Title}} - S{{printf "%02d" .Episode.Season}}E{{printf "%02d" .Episode.Episode}}{{if .Target.IsMultiEpisode}}-E{{printf "%02d" .Target.LastEpisode}}{{end}} - {{.Episode.Title}}`,
+	},
+	NAMING_PRESET_JELLYFIN: {
+		movie:   `Movies/{{.Movie.Title}}{{if .Movie.Year}} ({{.Movie.Year}}){{end}}/{{.Movie.Title}}{{if .Movie.Year}} ({{.Movie.Year}}){{end}}`,
+		episode: `Shows/{{.Series.Title}}{{if .Series.Year}} ({{.Series.Year}}){{end}}/{{if .Target.IsSpecial}}Specials{{else}}Season {{printf "%02d" .Episode.Season}}{{end}}/{{.Series.Title}} S{{printf "%02d" .Episode.Season}}E{{printf "%02d" .Episode.Episode}}{{if .Target.IsMultiEpisode}}-E{{printf "%02d" .Target.LastEpisode}}{{end}} - {{.Episode.Title}}`,
+	},
+}
+
+// movieNaming is the data available to movie naming templates
+type movieNaming struct {
+	Target *targetinfo.Movie
+	Movie  *video.MovieMetaInfo
+}
+
+// episodeNaming is the data available to episode naming templates
+type episodeNaming struct {
+	Target  *targetinfo.Episode
+	Series  *video.SeriesMetaInfo
+	Episode *video.EpisodeMetaInfo
+}
+
+type naming struct {
+	invalidChars string
+	movie        *template.Template
+	episode      *template.Template
+}
+
+// namingFor compiles the naming templates of the output config - patterns given explicitly take precedence over the preset
+func namingFor(conf *ripper.OutputConfig) (*naming, error) {
+	presetName := NAMING_PRESET_DEFAULT
+	movie, episode := "", ""
+	if conf.Naming != nil {
+		if len(conf.Naming.Preset) > 0 {
+			presetName = conf.Naming.Preset
+		}
+		movie, episode = conf.Naming.Movie, conf.Naming.Episode
+	}
+	preset, known := namingPresets[presetName]
+	if !known {
+		return nil, fmt.Errorf("unknown naming preset configured: \"%s\"", presetName)
+	}
+
+	n := &naming{invalidChars: conf.InvalidCharactersInFileName}
+	var err error
+	if n.movie, err = template.New("movie").Parse(orDefault(movie, preset.movie)); err != nil {
+		return nil, fmt.Errorf("invalid movie naming pattern: %v", err)
+	}
+	if n.episode, err = template.New("episode").Parse(orDefault(episode, preset.episode)); err != nil {
+		return nil, fmt.Errorf("invalid episode naming pattern: %v", err)
+	}
+	return n, nil
+}
+
+func orDefault(pattern string, defaultPattern string) string {
+	if len(strings.TrimSpace(pattern)) == 0 {
+		return defaultPattern
+	}
+	return pattern
+}
+
+func (n *naming) moviePath(outputDir string, data *movieNaming, fileName string, ext string) (string, error) {
+	sanitized := &movieNaming{
+		Target: n.sanitized(data.Target).(*targetinfo.Movie),
+		Movie:  n.sanitized(data.Movie).(*video.MovieMetaInfo),
+	}
+	return n.path(n.movie, sanitized, outputDir, fileName, ext)
+}
+
+func (n *naming) episodePath(outputDir string, data *episodeNaming, fileName string, ext string) (string, error) {
+	sanitized := &episodeNaming{
+		Target:  n.sanitized(data.Target).(*targetinfo.Episode),
+		Series:  n.sanitized(data.Series).(*video.SeriesMetaInfo),
+		Episode: n.sanitized(data.Episode).(*video.EpisodeMetaInfo),
+	}
+	return n.path(n.episode, sanitized, outputDir, fileName, ext)
+}
+
+// path renders the template and replaces the file name, if provided (e.g. by overrides)
+func (n *naming) path(tmpl *template.Template, data interface{}, outputDir string, fileName string, ext string) (string, error) {
+	buf := bytes.Buffer{}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("unable to render output path: %v", err)
+	}
+
+	pathElems := []string{}
+	for _, elem := range strings.Split(buf.String(), "/") {
+		if elem = strings.TrimSpace(elem); len(elem) > 0 {
+			pathElems = append(pathElems, elem)
+		}
+	}
+	if len(pathElems) == 0 {
+		return "", fmt.Errorf("output path rendered by template \"%s\" is empty", tmpl.Name())
+	}
+	if len(fileName) > 0 {
+		pathElems[len(pathElems)-1] = fileName
+	}
+	pathElems[len(pathElems)-1] = files.WithExtension(pathElems[len(pathElems)-1], ext)
+	return buildDestinationPath(n.invalidChars, outputDir, pathElems...), nil
+}
+
+// sanitized returns a copy of a struct pointer with invalid file name characters removed from all string fields,
+// so meta-info values (e.g. "AC/DC") cannot introduce additional folders
+func (n *naming) sanitized(ptr interface{}) interface{} {
+	original := reflect.ValueOf(ptr).Elem()
+	cp := reflect.New(original.Type())
+	cp.Elem().Set(original)
+	sanitize(cp.Elem(), n.invalidChars)
+	return cp.Interface()
+}
+
+func sanitize(v reflect.Value, invalidChars string) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(commons.RemoveCharacters(v.String(), invalidChars))
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				sanitize(v.Field(i), invalidChars)
+			}
+		}
+	}
+}
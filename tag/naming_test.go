@@ -0,0 +1,114 @@
+package tag
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+	"github.com/thomasschoeftner/go-ripper/metainfo/video"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+func TestNaming(t *testing.T) {
+	movie := &movieNaming{
+		Target: targetinfo.NewMovie("flick.avi", "/in", "tt0001"),
+		Movie:  &video.MovieMetaInfo{IdInfo: metainfo.IdInfo{Id: "tt0001"}, Title: "Some: Flick", Year: "1999"},
+	}
+	episode := &episodeNaming{
+		Target:  targetinfo.NewEpisode("ep.avi", "/in", "tt0002", 1, 2, 10),
+		Series:  &video.SeriesMetaInfo{IdInfo: metainfo.IdInfo{Id: "tt0002"}, Title: "Show", Year: "2005"},
+		Episode: &video.EpisodeMetaInfo{IdInfo: metainfo.IdInfo{Id: "tt0003"}, Title: "Pilot/Part 1", Season: 1, Episode: 2},
+	}
+	outputConf := func(naming *ripper.NamingConfig) *ripper.OutputConfig {
+		return &ripper.OutputConfig{InvalidCharactersInFileName: "/:", Naming: naming}
+	}
+
+	t.Run("default preset", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		n, err := namingFor(outputConf(nil))
+		assert.NotError(err)
+		assert.StringsEqual(filepath.Join("/out", "Some Flick.mp4"), assert.StringNotError(n.moviePath("/out", movie, "", "mp4")))
+		assert.StringsEqual(filepath.Join("/out", "Show", "1", "Show-s01e02-PilotPart 1.mp4"), assert.StringNotError(n.episodePath("/out", episode, "", "mp4")))
+	})
+
+	t.Run("plex preset", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		n, err := namingFor(outputConf(&ripper.NamingConfig{Preset: NAMING_PRESET_PLEX}))
+		assert.NotError(err)
+		assert.StringsEqual(filepath.Join("/out", "Movies", "Some Flick (1999)", "Some Flick (1999).mp4"), assert.StringNotError(n.moviePath("/out", movie, "", "mp4")))
+		assert.StringsEqual(filepath.Join("/out", "TV Shows", "Show", "Season 01", "Show - S01E02 - PilotPart 1.mp4"), assert.StringNotError(n.episodePath("/out", episode, "", "mp4")))
+	})
+
+	t.Run("jellyfin preset", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		n, err := namingFor(outputConf(&ripper.NamingConfig{Preset: NAMING_PRESET_JELLYFIN}))
+		assert.NotError(err)
+		assert.StringsEqual(filepath.Join("/out", "Movies", "Some Flick (1999)", "Some Flick (1999).mp4"), assert.StringNotError(n.moviePath("/out", movie, "", "mp4")))
+		assert.StringsEqual(filepath.Join("/out", "Shows", "Show (2005)", "Season 01", "Show S01E02 - PilotPart 1.mp4"), assert.StringNotError(n.episodePath("/out", episode, "", "mp4")))
+	})
+
+	t.Run("multi-episode files and specials", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		multiEpisode := *episode
+		multiEpisode.Target = targetinfo.NewEpisode("ep.avi", "/in", "tt0002", 1, 2, 10)
+		multiEpisode.Target.LastEpisode = 3
+		special := &episodeNaming{
+			Target:  targetinfo.NewEpisode("making-of.avi", "/in/Specials", "tt0002", 0, 1, 1),
+			Series:  episode.Series,
+			Episode: &video.EpisodeMetaInfo{IdInfo: metainfo.IdInfo{Id: "tt0002"}, Title: "Making Of", Season: 0, Episode: 1},
+		}
+		for _, preset := range []struct{ name, multiEpisode, special string }{
+			{NAMING_PRESET_DEFAULT, filepath.Join("Show", "1", "Show-s01e02-e03-PilotPart 1.mp4"), filepath.Join("Show", "Specials", "Show-s00e01-Making Of.mp4")},
+			{NAMING_PRESET_PLEX, filepath.Join("TV Shows", "Show", "Season 01", "Show - S01E02-E03 - PilotPart 1.mp4"), filepath.Join("TV Shows", "Show", "Specials", "Show - S00E01 - Making Of.mp4")},
+			{NAMING_PRESET_JELLYFIN, filepath.Join("Shows", "Show (2005)", "Season 01", "Show S01E02-E03 - PilotPart 1.mp4"), filepath.Join("Shows", "Show (2005)", "Specials", "Show S00E01 - Making Of.mp4")},
+		} {
+			n, err := namingFor(outputConf(&ripper.NamingConfig{Preset: preset.name}))
+			assert.NotError(err)
+			assert.StringsEqual(filepath.Join("/out", preset.multiEpisode), assert.StringNotError(n.episodePath("/out", &multiEpisode, "", "mp4")))
+			assert.StringsEqual(filepath.Join("/out", preset.special), assert.StringNotError(n.episodePath("/out", special, "", "mp4")))
+		}
+	})
+
+	t.Run("custom patterns take precedence over preset", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		n, err := namingFor(outputConf(&ripper.NamingConfig{Preset: NAMING_PRESET_PLEX, Movie: "{{.Movie.Year}}/{{.Target.Id}}"}))
+		assert.NotError(err)
+		assert.StringsEqual(filepath.Join("/out", "1999", "tt0001.mp4"), assert.StringNotError(n.moviePath("/out", movie, "", "mp4")))
+		assert.StringsEqual(filepath.Join("/out", "TV Shows", "Show", "Season 01", "Show - S01E02 - PilotPart 1.mp4"), assert.StringNotError(n.episodePath("/out", episode, "", "mp4")))
+	})
+
+	t.Run("file name replaces rendered file name", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		n, err := namingFor(outputConf(&ripper.NamingConfig{Preset: NAMING_PRESET_PLEX}))
+		assert.NotError(err)
+		assert.StringsEqual(filepath.Join("/out", "Movies", "Some Flick (1999)", "custom.mp4"), assert.StringNotError(n.moviePath("/out", movie, "custom", "mp4")))
+	})
+
+	t.Run("meta-info is not modified", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		n, err := namingFor(outputConf(nil))
+		assert.NotError(err)
+		assert.StringNotError(n.moviePath("/out", movie, "", "mp4"))
+		assert.StringsEqual("Some: Flick", movie.Movie.Title)
+	})
+
+	t.Run("expect error for unknown preset", func(t *testing.T) {
+		_, err := namingFor(outputConf(&ripper.NamingConfig{Preset: "unknown"}))
+		test.AssertOn(t).ExpectError("expected error for unknown naming preset")(err)
+	})
+
+	t.Run("expect error for invalid pattern", func(t *testing.T) {
+		_, err := namingFor(outputConf(&ripper.NamingConfig{Episode: "{{.Series.Title"}))
+		test.AssertOn(t).ExpectError("expected error for invalid naming pattern")(err)
+	})
+
+	t.Run("expect error for unknown field", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		n, err := namingFor(outputConf(&ripper.NamingConfig{Movie: "{{.Movie.Unknown}}"}))
+		assert.NotError(err)
+		_, err = n.moviePath("/out", movie, "", "mp4")
+		assert.ExpectError("expected error for unknown field in naming pattern")(err)
+	})
+}
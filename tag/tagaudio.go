@@ -0,0 +1,108 @@
+package tag
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-cli/task"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ledger"
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+	"github.com/thomasschoeftner/go-ripper/metainfo/audio"
+	"github.com/thomasschoeftner/go-ripper/processor"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+type TrackTagger func(inFile string, outFile string, album *audio.AlbumMetaInfo, track *audio.TrackMetaInfo, posterPath string) error
+type AudioTaggerFactory func(conf *ripper.AppConf, lazy bool, printf commons.FormatPrinter) (TrackTagger, error)
+
+var AudioTaggerFactories map[string]AudioTaggerFactory
+
+func init() {
+	AudioTaggerFactories = make(map[string]AudioTaggerFactory)
+	AudioTaggerFactories[conf_tagger_ffmpeg] = createFFMPEGAudioTagger
+}
+
+func TagAudio(ctx task.Context) task.HandlerFunc {
+	conf := ctx.Config.(*ripper.AppConf)
+	if conf.Tag.Audio == nil {
+		return ripper.ErrorHandler(errors.New("audio tagging is not configured"))
+	}
+	taggerType := conf.Tag.Audio.Tagger
+
+	var trackTagger TrackTagger
+	var err error
+
+	tf := AudioTaggerFactories[taggerType]
+	if tf == nil {
+		err = fmt.Errorf("unknown audio tagger configured: \"%s\"", taggerType)
+	} else {
+		trackTagger, err = tf(conf, ctx.RunLazy, ctx.Printf)
+	}
+	if err == nil {
+		_, err = collisionPolicyOf(conf.Output)
+	}
+
+	if err != nil {
+		return ripper.ErrorHandler(err)
+	} else {
+		return ripper.ForMedia(processor.Process(ctx, getAudioProcessor(conf, trackTagger), ledger.STAGE_TAGGED, taggerType,
+			processor.NeverLazy(ctx.RunLazy, taggerType, ctx.Printf),
+			processor.DefaultInputFileFor([]string{conf.Output.Audio}),
+			taggedOutputFile(conf)), ripper.MEDIA_AUDIO)
+	}
+}
+
+func getAudioProcessor(conf *ripper.AppConf, trackTagger TrackTagger) processor.Processor {
+	return func(ti targetinfo.TargetInfo, inFile string, outFile string) error {
+		if !targetinfo.IsTrack(ti) {
+			return fmt.Errorf("unknown type of audio target-info found: %s", ti.GetType())
+		}
+		return tagTrack(trackTagger, conf, ti.(*targetinfo.Track), inFile)
+	}
+}
+
+const templateTrackFilename = "%02d - %s"
+const templateMultiDiscTrackFilename = "%d-%02d - %s"
+
+func tagTrack(tag TrackTagger, conf *ripper.AppConf, ti *targetinfo.Track, inputFile string) error {
+	albumMi, trackMi, err := readTrackMetaInfo(conf, ti)
+	if err != nil {
+		return err
+	}
+
+	var coverFile string
+	if len(albumMi.Poster) > 0 {
+		coverFile = audio.CoverFileName(conf.MetaInfoRepo, albumMi)
+	}
+
+	outputFile := trackDestinationPath(conf, albumMi, trackMi, files.GetExtension(inputFile))
+	return tagAt(conf, ti, outputFile, albumMi.Year, func(outputFile string) error {
+		return tag(inputFile, outputFile, albumMi, trackMi, coverFile)
+	})
+}
+
+func readTrackMetaInfo(conf *ripper.AppConf, ti *targetinfo.Track) (*audio.AlbumMetaInfo, *audio.TrackMetaInfo, error) {
+	albumMi := audio.AlbumMetaInfo{}
+	err := metainfo.ReadMetaInfo(audio.AlbumFileName(conf.MetaInfoRepo, ti.Id), &albumMi)
+	if err != nil {
+		return nil, nil, err
+	}
+	trackMi := albumMi.Track(ti.Disc, ti.Track)
+	if len(albumMi.Id) == 0 || trackMi == nil {
+		return nil, nil, fmt.Errorf("could not find meta-info for track: %s\n", ti.String())
+	}
+	return &albumMi, trackMi, nil
+}
+
+func trackDestinationPath(conf *ripper.AppConf, albumMi *audio.AlbumMetaInfo, trackMi *audio.TrackMetaInfo, ext string) string {
+	var fName string
+	if albumMi.Discs > 1 {
+		fName = fmt.Sprintf(templateMultiDiscTrackFilename, trackMi.Disc, trackMi.Track, trackMi.Title)
+	} else {
+		fName = fmt.Sprintf(templateTrackFilename, trackMi.Track, trackMi.Title)
+	}
+	return buildDestinationPath(conf.Output.InvalidCharactersInFileName, conf.OutputDirectory, albumMi.Artist, albumMi.Title, files.WithExtension(fName, ext))
+}
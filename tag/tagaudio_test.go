@@ -0,0 +1,69 @@
+package tag
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+	"github.com/thomasschoeftner/go-ripper/metainfo/audio"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/targetinfo"
+)
+
+type testTrackTagger struct {
+	inFile     string
+	outFile    string
+	album      *audio.AlbumMetaInfo
+	track      *audio.TrackMetaInfo
+	posterPath string
+}
+
+func (tagger *testTrackTagger) TagTrack(inFile string, outFile string, album *audio.AlbumMetaInfo, track *audio.TrackMetaInfo, posterPath string) error {
+	tagger.inFile = inFile
+	tagger.outFile = outFile
+	tagger.album = album
+	tagger.track = track
+	tagger.posterPath = posterPath
+	return nil
+}
+
+func TestTagTrack(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	repoDir := filepath.Join(dir, "repo")
+	outputDir := filepath.Join(dir, "output")
+	conf := &ripper.AppConf{
+		MetaInfoRepo:    repoDir,
+		OutputDirectory: outputDir,
+		Output:          &ripper.OutputConfig{InvalidCharactersInFileName: "/"},
+	}
+
+	album := &audio.AlbumMetaInfo{IdInfo: metainfo.IdInfo{Id: "album-id"}, Title: "best of", Artist: "AC/DC", Year: "1999", Discs: 1, Poster: "http://cover/front-500",
+		Tracks: []*audio.TrackMetaInfo{
+			{IdInfo: metainfo.IdInfo{Id: "t1"}, Title: "first", Artist: "AC/DC", Disc: 1, Track: 1},
+			{IdInfo: metainfo.IdInfo{Id: "t2"}, Title: "second", Artist: "AC/DC", Disc: 1, Track: 2}}}
+	test.CheckError(t, metainfo.SaveMetaInfo(audio.AlbumFileName(repoDir, album.Id), album))
+
+	t.Run("expect error when track is not part of album meta-info", func(t *testing.T) {
+		tagger := &testTrackTagger{}
+		ti := targetinfo.NewTrack("07.mp3", "/some/dir", album.Id, 1, 7, 2)
+		err := tagTrack(tagger.TagTrack, conf, ti, "07.mp3")
+		test.AssertOn(t).ExpectError("expected error when tagging unknown track, but got none")(err)
+	})
+
+	t.Run("invoke track tagger with appropriate params", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		tagger := &testTrackTagger{}
+		ti := targetinfo.NewTrack("02.flac", "/some/dir", album.Id, 1, 2, 2)
+		fileToProcess := files.WithExtension("some/other/file", "mp3")
+
+		assert.NotError(tagTrack(tagger.TagTrack, conf, ti, fileToProcess))
+		assert.StringsEqual(fileToProcess, tagger.inFile)
+		assert.StringsEqual("second", tagger.track.Title)
+		assert.StringsEqual(album.Title, tagger.album.Title)
+		assert.StringsEqual(metainfo.ImageFileName(repoDir, album.Id, "jpg"), tagger.posterPath)
+		assert.StringsEqual(filepath.Join(outputDir, "ACDC", "best of", "02 - second.mp3"), tagger.outFile)
+	})
+}
@@ -2,21 +2,25 @@ package tag
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
-	"strconv"
+	"regexp"
+	"strings"
 
 	"github.com/thomasschoeftner/go-cli/commons"
 	"github.com/thomasschoeftner/go-cli/task"
 	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ledger"
 	"github.com/thomasschoeftner/go-ripper/metainfo"
 	"github.com/thomasschoeftner/go-ripper/metainfo/video"
+	"github.com/thomasschoeftner/go-ripper/override"
 	"github.com/thomasschoeftner/go-ripper/processor"
 	"github.com/thomasschoeftner/go-ripper/ripper"
 	"github.com/thomasschoeftner/go-ripper/targetinfo"
 )
 
-type MovieTagger func(inFile string, outFile string, id string, title string, year string, posterPath string) error
-type EpisodeTagger func(inFile string, outFile string, id string, series string, season int, episode int, title string, year string, posterPath string) error
+type MovieTagger func(inFile string, outFile string, id string, title string, year string, details *video.Details, posterPath string, sidecars []targetinfo.Sidecar) error
+type EpisodeTagger func(inFile string, outFile string, id string, series string, season int, episode int, lastEpisode int, title string, year string, details *video.Details, posterPath string, sidecars []targetinfo.Sidecar) error
 
 type TaggerFactory func(conf *ripper.AppConf, lazy bool, printf commons.FormatPrinter) (MovieTagger, EpisodeTagger, error)
 
@@ -43,14 +47,20 @@ func TagVideo(ctx task.Context) task.HandlerFunc {
 		// movieTagger, episodeTagger, err = createAtomicParsleyVideoTagger(conf, ctx.RunLazy, ctx.Printf)
 		movieTagger, episodeTagger, err = tf(conf, ctx.RunLazy, ctx.Printf)
 	}
+	if err == nil {
+		_, err = namingFor(conf.Output) // fail early on invalid naming patterns
+	}
+	if err == nil {
+		_, err = collisionPolicyOf(conf.Output)
+	}
 
 	if err != nil {
 		return ripper.ErrorHandler(err)
 	} else {
-		return processor.Process(ctx, getProcessor(conf, movieTagger, episodeTagger), taggerType,
+		return ripper.ForMedia(processor.Process(ctx, getProcessor(conf, movieTagger, episodeTagger), ledger.STAGE_TAGGED, taggerType,
 			processor.NeverLazy(ctx.RunLazy, taggerType, ctx.Printf),
 			processor.DefaultInputFileFor([]string{conf.Output.Video}),
-			processor.DefaultOutputFileFor(conf.Output.Video))
+			taggedOutputFile(conf)), ripper.MEDIA_VIDEO)
 	}
 }
 
@@ -71,56 +81,256 @@ func getProcessor(conf *ripper.AppConf, movieTagger MovieTagger, episodeTagger E
 }
 
 func tagMovie(tag MovieTagger, conf *ripper.AppConf, ti *targetinfo.Movie, inputFile string) error {
-	movieMi := video.MovieMetaInfo{}
-	err := metainfo.ReadMetaInfo(video.MovieFileName(conf.MetaInfoRepo, ti.GetId()), &movieMi)
+	movieMi, o, err := readMovieMetaInfo(conf, ti)
 	if err != nil {
 		return err
 	}
-
-	if len(movieMi.Id) == 0 {
-		return fmt.Errorf("could not find meta-info for movie: %s\n", ti.String())
-	}
-	imgFile := metainfo.ImageFileName(conf.MetaInfoRepo, movieMi.Id, files.GetExtension(movieMi.Poster))
+	imgFile := metainfo.ImageFileName(conf.MetaInfoRepo, o.PosterId(movieMi.Id), files.GetExtension(movieMi.Poster))
 	//TODO check if missing poster image is actually an error
 
-	ext := files.GetExtension(inputFile)
-	outputFile := buildDestinationPath(conf.Output.InvalidCharactersInFileName, conf.OutputDirectory, files.WithExtension(movieMi.Title, ext))
+	outputFile, err := movieDestinationPath(conf, ti, movieMi, o, files.GetExtension(inputFile))
+	if err != nil {
+		return err
+	}
 
-	err = tag(inputFile, outputFile, movieMi.Id, movieMi.Title, movieMi.Year, imgFile)
-	return err
+	return tagAt(conf, ti, outputFile, movieMi.Year, func(outputFile string) error {
+		return tag(inputFile, outputFile, movieMi.Id, movieMi.Title, movieMi.Year, &movieMi.Details, imgFile, sidecarsOf(&ti.Video))
+	})
 }
 
-const templateEpisodeFilename = "%s-s%02de%02d-%s"
-
 func tagEpisode(tag EpisodeTagger, conf *ripper.AppConf, ti *targetinfo.Episode, inputFile string) error {
-	episodeMi := video.EpisodeMetaInfo{}
-	err := metainfo.ReadMetaInfo(video.EpisodeFileName(conf.MetaInfoRepo, ti.Id, ti.Season, ti.Episode), &episodeMi)
+	seriesMi, episodeMi, o, err := readEpisodeMetaInfo(conf, ti)
 	if err != nil {
 		return err
 	}
-	if len(episodeMi.Id) == 0 {
-		return fmt.Errorf("could not find meta-info for episode: %s\n", ti.String())
+	imgFile, err := episodePosterFile(conf, ti, seriesMi, o)
+	if err != nil {
+		return err
 	}
 
-	seriesMi := video.SeriesMetaInfo{}
-	err = metainfo.ReadMetaInfo(video.SeriesFileName(conf.MetaInfoRepo, ti.Id), &seriesMi)
+	outputFile, err := episodeDestinationPath(conf, ti, seriesMi, episodeMi, o, files.GetExtension(inputFile))
 	if err != nil {
 		return err
 	}
+
+	details := episodeMi.Details.Or(seriesMi.Details)
+	return tagAt(conf, ti, outputFile, episodeMi.Year, func(outputFile string) error {
+		return tag(inputFile, outputFile, seriesMi.Id, seriesMi.Title, episodeMi.Season, episodeMi.Episode, ti.LastEpisode, episodeMi.Title, episodeMi.Year, &details, imgFile, sidecarsOf(&ti.Video))
+	})
+}
+
+// episodePosterFile returns the poster image of the episode's season - or of the series, if the season has no poster or the poster is overridden.
+// Seasons resolved before their meta-info was introduced fall back to the series poster as well.
+func episodePosterFile(conf *ripper.AppConf, ti *targetinfo.Episode, seriesMi *video.SeriesMetaInfo, o *override.Overrides) (string, error) {
+	if len(o.Poster) == 0 {
+		seasonMi := video.SeasonMetaInfo{}
+		err := metainfo.ReadMetaInfo(video.SeasonFileName(conf.MetaInfoRepo, ti.Id, ti.Season), &seasonMi)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if err == nil && len(seasonMi.Poster) > 0 {
+			return metainfo.ImageFileName(conf.MetaInfoRepo, video.SeasonPosterId(seriesMi.Id, ti.Season), files.GetExtension(seasonMi.Poster)), nil
+		}
+	}
+	return metainfo.ImageFileName(conf.MetaInfoRepo, o.PosterId(seriesMi.Id), files.GetExtension(seriesMi.Poster)), nil
+}
+
+// sidecarsOf returns the sidecars of a video with their full paths
+func sidecarsOf(v *targetinfo.Video) []targetinfo.Sidecar {
+	sidecars := []targetinfo.Sidecar{}
+	for _, s := range v.Sidecars {
+		s.File = filepath.Join(v.Folder, s.File)
+		if len(s.Data) > 0 {
+			s.Data = filepath.Join(v.Folder, s.Data)
+		}
+		sidecars = append(sidecars, s)
+	}
+	return sidecars
+}
+
+// DestinationPathFor calculates the location of the tagged output file for a specific target,
+// unless the actual destination was already recorded when tagging it. Targets whose tagging was skipped have no destination.
+func DestinationPathFor(conf *ripper.AppConf, ti targetinfo.TargetInfo) (string, error) {
+	if existing, err := SkippedDestination(conf, ti); err != nil {
+		return "", err
+	} else if len(existing) > 0 {
+		return "", fmt.Errorf("tagging of %s was skipped - %s is not its output", ti.GetFullPath(), existing)
+	}
+	if recorded, err := RecordedDestination(conf, ti); err != nil || len(recorded) > 0 {
+		return recorded, err
+	}
+	switch ti.GetType() {
+	case targetinfo.TARGETINFO_TYPE_MOVIE:
+		movieMi, o, err := readMovieMetaInfo(conf, ti.(*targetinfo.Movie))
+		if err != nil {
+			return "", err
+		}
+		return movieDestinationPath(conf, ti.(*targetinfo.Movie), movieMi, o, conf.Output.Video)
+	case targetinfo.TARGETINFO_TPYE_EPISODE:
+		seriesMi, episodeMi, o, err := readEpisodeMetaInfo(conf, ti.(*targetinfo.Episode))
+		if err != nil {
+			return "", err
+		}
+		return episodeDestinationPath(conf, ti.(*targetinfo.Episode), seriesMi, episodeMi, o, conf.Output.Video)
+	case targetinfo.TARGETINFO_TYPE_TRACK:
+		albumMi, trackMi, err := readTrackMetaInfo(conf, ti.(*targetinfo.Track))
+		if err != nil {
+			return "", err
+		}
+		return trackDestinationPath(conf, albumMi, trackMi, conf.Output.Audio), nil
+	default:
+		return "", fmt.Errorf("unknown type of target-info found: %s", ti.GetType())
+	}
+}
+
+// TitleFor returns a human-readable title of the target based on the resolved meta-info
+func TitleFor(conf *ripper.AppConf, ti targetinfo.TargetInfo) (string, error) {
+	switch ti.GetType() {
+	case targetinfo.TARGETINFO_TYPE_MOVIE:
+		movieMi, _, err := readMovieMetaInfo(conf, ti.(*targetinfo.Movie))
+		if err != nil {
+			return "", err
+		}
+		return movieMi.Title, nil
+	case targetinfo.TARGETINFO_TPYE_EPISODE:
+		seriesMi, episodeMi, _, err := readEpisodeMetaInfo(conf, ti.(*targetinfo.Episode))
+		if err != nil {
+			return "", err
+		}
+		episode := ti.(*targetinfo.Episode)
+		if episode.IsMultiEpisode() {
+			return fmt.Sprintf("%s S%02dE%02d-E%02d - %s", seriesMi.Title, episodeMi.Season, episodeMi.Episode, episode.LastEpisode, episodeMi.Title), nil
+		}
+		return fmt.Sprintf("%s S%02dE%02d - %s", seriesMi.Title, episodeMi.Season, episodeMi.Episode, episodeMi.Title), nil
+	case targetinfo.TARGETINFO_TYPE_TRACK:
+		albumMi, trackMi, err := readTrackMetaInfo(conf, ti.(*targetinfo.Track))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s - %s", albumMi.Title, trackMi.Title), nil
+	default:
+		return "", fmt.Errorf("unknown type of target-info found: %s", ti.GetType())
+	}
+}
+
+// readMovieMetaInfo reads the movie meta-info from the repo and merges the target's overrides over it
+func readMovieMetaInfo(conf *ripper.AppConf, ti *targetinfo.Movie) (*video.MovieMetaInfo, *override.Overrides, error) {
+	movieMi := video.MovieMetaInfo{}
+	err := metainfo.ReadMetaInfo(video.MovieFileName(conf.MetaInfoRepo, ti.GetId()), &movieMi)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(movieMi.Id) == 0 {
+		return nil, nil, fmt.Errorf("could not find meta-info for movie: %s\n", ti.String())
+	}
+
+	o, err := override.ForFile(ti.GetFullPath())
+	if err != nil {
+		return nil, nil, err
+	}
+	movieMi.Title = override.Or(o.Title, movieMi.Title)
+	movieMi.Year = override.Or(o.Year, movieMi.Year)
+	movieMi.Poster = override.Or(o.Poster, movieMi.Poster)
+	return &movieMi, o, nil
+}
+
+// readEpisodeMetaInfo reads the series and episode meta-info from the repo and merges the target's overrides over it.
+// The meta-info of all episodes in multi-episode files is combined.
+func readEpisodeMetaInfo(conf *ripper.AppConf, ti *targetinfo.Episode) (*video.SeriesMetaInfo, *video.EpisodeMetaInfo, *override.Overrides, error) {
+	episodes := []*video.EpisodeMetaInfo{}
+	for _, episode := range ti.Episodes() {
+		episodeMi := video.EpisodeMetaInfo{}
+		err := metainfo.ReadMetaInfo(video.EpisodeFileName(conf.MetaInfoRepo, ti.Id, ti.Season, episode), &episodeMi)
+		if os.IsNotExist(err) && ti.IsSpecial() {
+			episodeMi, err = *video.SpecialPlaceholder(ti.Id, ti.Season, episode), nil // specials unknown to the meta-info source are not saved
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(episodeMi.Id) == 0 {
+			return nil, nil, nil, fmt.Errorf("could not find meta-info for episode %d of: %s\n", episode, ti.String())
+		}
+		episodes = append(episodes, &episodeMi)
+	}
+	episodeMi := combinedEpisodes(episodes)
+
+	seriesMi := video.SeriesMetaInfo{}
+	err := metainfo.ReadMetaInfo(video.SeriesFileName(conf.MetaInfoRepo, ti.Id), &seriesMi)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	if len(seriesMi.Id) == 0 {
-		return fmt.Errorf("could not find meta-info for series: %s\n", ti.String())
+		return nil, nil, nil, fmt.Errorf("could not find meta-info for series: %s\n", ti.String())
 	}
-	imgFile := metainfo.ImageFileName(conf.MetaInfoRepo, seriesMi.Id, files.GetExtension(seriesMi.Poster))
 
-	fName := files.WithExtension(fmt.Sprintf(templateEpisodeFilename, seriesMi.Title, episodeMi.Season, episodeMi.Episode, episodeMi.Title), files.GetExtension(inputFile))
-	outputFile := buildDestinationPath(conf.Output.InvalidCharactersInFileName, conf.OutputDirectory, seriesMi.Title, strconv.Itoa(episodeMi.Season), fName)
-	err = files.CreateFolderStructure(filepath.Dir(outputFile))
+	o, err := override.ForFile(ti.GetFullPath())
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
+	seriesMi.Title = override.Or(o.Series, seriesMi.Title)
+	seriesMi.Poster = override.Or(o.Poster, seriesMi.Poster)
+	episodeMi.Title = override.Or(o.Title, episodeMi.Title)
+	episodeMi.Year = override.Or(o.Year, episodeMi.Year)
+	return &seriesMi, episodeMi, o, nil
+}
+
+// trailing part numbers of episode titles, e.g. "Pilot (1)" or "The Beginning, Part 2"
+var episodePartSuffix = regexp.MustCompile(`(?i)[\s,:-]*(?:\(\s*(?:part\s*)?\d+\s*\)|part\s*\d+)$`)
+
+// combinedEpisodes merges the meta-info of the episodes of multi-episode files into the first episode.
+// Titles of a story split into parts (e.g. "Pilot (1)" and "Pilot (2)") are joined to the common title,
+// all other titles and plots are concatenated and runtimes are summed up.
+func combinedEpisodes(episodes []*video.EpisodeMetaInfo) *video.EpisodeMetaInfo {
+	combined := *episodes[0]
+	if len(episodes) == 1 {
+		return &combined
+	}
+
+	titles, plots := []string{}, []string{}
+	commonTitle := episodePartSuffix.ReplaceAllString(combined.Title, "")
+	combined.Runtime = 0
+	for _, e := range episodes {
+		titles = append(titles, e.Title)
+		if len(e.Plot) > 0 {
+			plots = append(plots, e.Plot)
+		}
+		combined.Runtime += e.Runtime
+		if episodePartSuffix.ReplaceAllString(e.Title, "") != commonTitle {
+			commonTitle = ""
+		}
+	}
+	if len(commonTitle) > 0 {
+		combined.Title = commonTitle
+	} else {
+		combined.Title = strings.Join(titles, " / ")
+	}
+	combined.Plot = strings.Join(plots, " ")
+	return &combined
+}
+
+// episodeNumber formats the episode# of (multi-)episode files for tagging, e.g. "1" or "1-2"
+func episodeNumber(episode int, lastEpisode int) string {
+	if lastEpisode > episode {
+		return fmt.Sprintf("%d-%d", episode, lastEpisode)
+	}
+	return fmt.Sprintf("%d", episode)
+}
+
+func movieDestinationPath(conf *ripper.AppConf, ti *targetinfo.Movie, movieMi *video.MovieMetaInfo, o *override.Overrides, ext string) (string, error) {
+	n, err := namingFor(conf.Output)
+	if err != nil {
+		return "", err
+	}
+	return n.moviePath(conf.OutputDirectory, &movieNaming{Target: ti, Movie: movieMi}, o.FileName, ext)
+}
 
-	err = tag(inputFile, outputFile, seriesMi.Id, seriesMi.Title, episodeMi.Season, episodeMi.Episode, episodeMi.Title, episodeMi.Year, imgFile)
-	return err
+func episodeDestinationPath(conf *ripper.AppConf, ti *targetinfo.Episode, seriesMi *video.SeriesMetaInfo, episodeMi *video.EpisodeMetaInfo, o *override.Overrides, ext string) (string, error) {
+	n, err := namingFor(conf.Output)
+	if err != nil {
+		return "", err
+	}
+	return n.episodePath(conf.OutputDirectory, &episodeNaming{Target: ti, Series: seriesMi, Episode: episodeMi}, o.FileName, ext)
 }
 
 func buildDestinationPath(invalidFileNameChars string, outputDir string, pathElems ...string) string {
@@ -3,6 +3,8 @@ package tag
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strconv"
 	"testing"
@@ -14,12 +16,15 @@ import (
 	"github.com/thomasschoeftner/go-ripper/files"
 	"github.com/thomasschoeftner/go-ripper/metainfo"
 	"github.com/thomasschoeftner/go-ripper/metainfo/video"
+	"github.com/thomasschoeftner/go-ripper/override"
 	"github.com/thomasschoeftner/go-ripper/ripper"
 	"github.com/thomasschoeftner/go-ripper/targetinfo"
 )
 
 const expectedVideoExtension = "mp4"
 
+const defaultEpisodeFileName = "%s-s%02de%02d-%s"
+
 func newTestVideoTagger(raiseError error) func(conf *ripper.AppConf, lazy bool, printf commons.FormatPrinter) (MovieTagger, EpisodeTagger, error) {
 	return func(conf *ripper.AppConf, lazy bool, printf commons.FormatPrinter) (MovieTagger, EpisodeTagger, error) {
 		var tagger = &testTagger{conf: conf, raiseError: raiseError}
@@ -28,31 +33,36 @@ func newTestVideoTagger(raiseError error) func(conf *ripper.AppConf, lazy bool,
 }
 
 type testTagger struct {
-	raiseError error
-	conf       *ripper.AppConf
-	inFile     string
-	outFile    string
-	id         string
-	title      string
-	year       string
-	posterPath string
-	series     string
-	season     int
-	episode    int
+	raiseError  error
+	conf        *ripper.AppConf
+	inFile      string
+	outFile     string
+	id          string
+	title       string
+	year        string
+	posterPath  string
+	series      string
+	season      int
+	episode     int
+	lastEpisode int
+	details     *video.Details
+	sidecars    []targetinfo.Sidecar
 }
 
-func (tagger *testTagger) TagMovie(inFile string, outFile string, id string, title string, year string, posterPath string) error {
+func (tagger *testTagger) TagMovie(inFile string, outFile string, id string, title string, year string, details *video.Details, posterPath string, sidecars []targetinfo.Sidecar) error {
 	// fmt.Printf("tag movie %s with {id=%s, title=%s, year=%s, image=%s} -> write to %s\n", inFile, id, title, year, posterPath, outFile)
 	tagger.inFile = inFile
 	tagger.outFile = outFile
 	tagger.id = id
 	tagger.title = title
 	tagger.year = year
+	tagger.details = details
 	tagger.posterPath = posterPath
+	tagger.sidecars = sidecars
 	return tagger.raiseError
 }
 
-func (tagger *testTagger) TagEpisode(inFile string, outFile string, id string, series string, season int, episode int, title string, year string, posterPath string) error {
+func (tagger *testTagger) TagEpisode(inFile string, outFile string, id string, series string, season int, episode int, lastEpisode int, title string, year string, details *video.Details, posterPath string, sidecars []targetinfo.Sidecar) error {
 	// fmt.Printf("tag episode %s with {id=%s, title=%s, year=%s, image=%s} -> write to %s\n", inFile, id, title, year, posterPath, outFile)
 	tagger.inFile = inFile
 	tagger.outFile = outFile
@@ -60,9 +70,12 @@ func (tagger *testTagger) TagEpisode(inFile string, outFile string, id string, s
 	tagger.series = series
 	tagger.season = season
 	tagger.episode = episode
+	tagger.lastEpisode = lastEpisode
 	tagger.title = title
 	tagger.year = year
+	tagger.details = details
 	tagger.posterPath = posterPath
+	tagger.sidecars = sidecars
 	return tagger.raiseError
 }
 
@@ -92,9 +105,11 @@ func TestTagMovie(t *testing.T) {
 		defer test.RmTempFolder(t, dir)
 		repoDir := filepath.Join(dir, "repo")
 
-		mi := video.MovieMetaInfo{IdInfo: metainfo.IdInfo{Id: "movie-id"}, Title: "true art", Year: "1966", Poster: "/a/b/c/art.png"}
+		mi := video.MovieMetaInfo{IdInfo: metainfo.IdInfo{Id: "movie-id"}, Title: "true art", Year: "1966", Poster: "/a/b/c/art.png",
+			Details: video.Details{Plot: "art is true", Genres: []string{"Documentary"}, Runtime: 90}}
 		metainfo.SaveMetaInfo(video.MovieFileName(repoDir, mi.Id), mi)
 		ti := targetinfo.NewMovie(files.WithExtension("movie", expectedVideoExtension), "/some/dir", mi.Id)
+		ti.Sidecars = []targetinfo.Sidecar{{File: "movie.de.srt", Kind: targetinfo.SIDECAR_KIND_SUBTITLE, Language: "ger"}}
 		fileToProcess := files.WithExtension("some/other/file", expectedVideoExtension)
 
 		outputDir := filepath.Join(dir, "output")
@@ -113,9 +128,52 @@ func TestTagMovie(t *testing.T) {
 		assert.StringsEqual(mi.Id, tagger.id)
 		assert.StringsEqual(mi.Title, tagger.title)
 		assert.StringsEqual(mi.Year, tagger.year)
+		assert.StringsEqual(mi.Plot, tagger.details.Plot)
+		assert.StringSlicesEqual(mi.Genres, tagger.details.Genres)
+		assert.IntsEqual(mi.Runtime, tagger.details.Runtime)
 		assert.StringsEqual(metainfo.ImageFileName(repoDir, mi.Id, files.GetExtension(mi.Poster)), tagger.posterPath)
 		assert.StringsEqual(fileToProcess, tagger.inFile)
 		assert.StringsEqual(filepath.Join(outputDir, files.WithExtension(mi.Title, expectedVideoExtension)), tagger.outFile)
+		assert.IntsEqual(1, len(tagger.sidecars))
+		assert.StringsEqual(filepath.Join("/some/dir", "movie.de.srt"), tagger.sidecars[0].File)
+		assert.StringsEqual("movie.de.srt", ti.Sidecars[0].File)
+	})
+
+	t.Run("merge overrides over movie meta-info", func(t *testing.T) {
+		assert := test.AssertOn(t)
+
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		repoDir := filepath.Join(dir, "repo")
+		inDir := filepath.Join(dir, "in")
+		test.CheckError(t, files.CreateFolderStructure(inDir))
+
+		mi := video.MovieMetaInfo{IdInfo: metainfo.IdInfo{Id: "movie-id"}, Title: "wrong title", Year: "1966", Poster: "/a/b/c/art.png"}
+		metainfo.SaveMetaInfo(video.MovieFileName(repoDir, mi.Id), mi)
+		ti := targetinfo.NewMovie(files.WithExtension("movie", expectedVideoExtension), inDir, mi.Id)
+		o := `{"title": "right title", "year": "1967", "poster": "http://x/poster.jpg", "fileName": "custom name"}`
+		test.CheckError(t, ioutil.WriteFile(filepath.Join(inDir, "movie.json"), []byte(o), os.ModePerm))
+
+		outputDir := filepath.Join(dir, "output")
+		conf := &ripper.AppConf{
+			MetaInfoRepo: repoDir,
+			Output: &ripper.OutputConfig{
+				InvalidCharactersInFileName: "",
+			},
+			OutputDirectory: outputDir,
+		}
+
+		tagger := &testTagger{conf: conf}
+		err := tagMovie(tagger.TagMovie, tagger.conf, ti, "file.mp4")
+		assert.NotError(err)
+		assert.StringsEqual(mi.Id, tagger.id)
+		assert.StringsEqual("right title", tagger.title)
+		assert.StringsEqual("1967", tagger.year)
+		assert.StringsEqual(metainfo.ImageFileName(repoDir, (&override.Overrides{Poster: "http://x/poster.jpg"}).PosterId(mi.Id), "jpg"), tagger.posterPath)
+		assert.StringsEqual(filepath.Join(outputDir, files.WithExtension("custom name", expectedVideoExtension)), tagger.outFile)
+
+		title, err := TitleFor(conf, ti)
+		assert.StringsEqual("right title", assert.StringNotError(title, err))
 	})
 }
 
@@ -167,8 +225,10 @@ func TestTagEpisode(t *testing.T) {
 		defer test.RmTempFolder(t, dir)
 		repoDir := filepath.Join(dir, "repo")
 
-		seriesMi := video.SeriesMetaInfo{IdInfo: metainfo.IdInfo{"series-id"}, Title: "traffic education", Seasons: 9, Year: "2010", Poster: "/pic/of/a/car.jpeg"}
-		episodeMi := video.EpisodeMetaInfo{IdInfo: metainfo.IdInfo{"episode-id"}, Title: "crash boom", Season: 4, Episode: 2, Year: "2014"}
+		seriesMi := video.SeriesMetaInfo{IdInfo: metainfo.IdInfo{"series-id"}, Title: "traffic education", Seasons: 9, Year: "2010", Poster: "/pic/of/a/car.jpeg",
+			Details: video.Details{Plot: "learn to drive", Genres: []string{"Comedy"}}}
+		episodeMi := video.EpisodeMetaInfo{IdInfo: metainfo.IdInfo{"episode-id"}, Title: "crash boom", Season: 4, Episode: 2, Year: "2014",
+			Details: video.Details{Plot: "a car crashes"}}
 		metainfo.SaveMetaInfo(video.SeriesFileName(repoDir, seriesMi.Id), seriesMi)
 		metainfo.SaveMetaInfo(video.EpisodeFileName(repoDir, seriesMi.Id, episodeMi.Season, episodeMi.Episode), episodeMi)
 		ti := targetinfo.NewEpisode(files.WithExtension("trafficeducation-s4e2", expectedVideoExtension), "/some/dir", seriesMi.Id, episodeMi.Season, episodeMi.Episode, 9)
@@ -193,11 +253,113 @@ func TestTagEpisode(t *testing.T) {
 		assert.IntsEqual(episodeMi.Season, tagger.season)
 		assert.IntsEqual(episodeMi.Episode, tagger.episode)
 		assert.StringsEqual(seriesMi.Title, tagger.series)
+		assert.StringsEqual("a car crashes", tagger.details.Plot)
+		assert.StringSlicesEqual([]string{"Comedy"}, tagger.details.Genres) // missing episode details are taken from the series
 		assert.StringsEqual(metainfo.ImageFileName(repoDir, seriesMi.Id, files.GetExtension(seriesMi.Poster)), tagger.posterPath)
 		assert.StringsEqual(fileToProcess, tagger.inFile)
-		expectedFileName := files.WithExtension(fmt.Sprintf(templateEpisodeFilename, seriesMi.Title, episodeMi.Season, episodeMi.Episode, episodeMi.Title), expectedVideoExtension)
+		expectedFileName := files.WithExtension(fmt.Sprintf(defaultEpisodeFileName, seriesMi.Title, episodeMi.Season, episodeMi.Episode, episodeMi.Title), expectedVideoExtension)
 		assert.StringsEqual(filepath.Join(outputDir, seriesMi.Title, strconv.Itoa(episodeMi.Season), expectedFileName), tagger.outFile)
 	})
+
+	t.Run("prefer season poster over series poster", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		repoDir := filepath.Join(dir, "repo")
+
+		seriesMi := video.SeriesMetaInfo{IdInfo: metainfo.IdInfo{Id: "series-id"}, Title: "traffic education", Seasons: 9, Year: "2010", Poster: "/pic/of/a/car.jpeg"}
+		seasonMi := video.SeasonMetaInfo{IdInfo: metainfo.IdInfo{Id: "series-id"}, Season: 4, Poster: "/pic/of/season4.png"}
+		episodeMi := video.EpisodeMetaInfo{IdInfo: metainfo.IdInfo{Id: "episode-id"}, Title: "crash boom", Season: 4, Episode: 2, Year: "2014"}
+		metainfo.SaveMetaInfo(video.SeriesFileName(repoDir, seriesMi.Id), seriesMi)
+		metainfo.SaveMetaInfo(video.SeasonFileName(repoDir, seriesMi.Id, 4), seasonMi)
+		metainfo.SaveMetaInfo(video.EpisodeFileName(repoDir, seriesMi.Id, 4, 2), episodeMi)
+		ti := targetinfo.NewEpisode(files.WithExtension("trafficeducation-s4e2", expectedVideoExtension), "/some/dir", seriesMi.Id, 4, 2, 9)
+		conf := &ripper.AppConf{MetaInfoRepo: repoDir, Output: &ripper.OutputConfig{}, OutputDirectory: filepath.Join(dir, "output")}
+
+		tagger := testTagger{conf: conf}
+		assert.NotError(tagEpisode(tagger.TagEpisode, tagger.conf, ti, files.WithExtension("some/file", expectedVideoExtension)))
+		assert.StringsEqual(metainfo.ImageFileName(repoDir, video.SeasonPosterId(seriesMi.Id, 4), "png"), tagger.posterPath)
+	})
+
+	t.Run("tag multi-episode file with combined meta-info", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		repoDir := filepath.Join(dir, "repo")
+
+		seriesMi := video.SeriesMetaInfo{IdInfo: metainfo.IdInfo{Id: "series-id"}, Title: "traffic education", Seasons: 9, Year: "2010", Poster: "/pic/of/a/car.jpeg"}
+		metainfo.SaveMetaInfo(video.SeriesFileName(repoDir, seriesMi.Id), seriesMi)
+		for no, title := range map[int]string{1: "crash boom (1)", 2: "crash boom (2)"} {
+			episodeMi := video.EpisodeMetaInfo{IdInfo: metainfo.IdInfo{Id: "episode-id"}, Title: title, Season: 4, Episode: no, Year: "2014"}
+			metainfo.SaveMetaInfo(video.EpisodeFileName(repoDir, seriesMi.Id, 4, no), episodeMi)
+		}
+		ti := targetinfo.NewEpisode(files.WithExtension("trafficeducation-s4e1-e2", expectedVideoExtension), "/some/dir", seriesMi.Id, 4, 1, 9)
+		ti.LastEpisode = 2
+		conf := &ripper.AppConf{MetaInfoRepo: repoDir, Output: &ripper.OutputConfig{}, OutputDirectory: filepath.Join(dir, "output")}
+
+		tagger := testTagger{conf: conf}
+		assert.NotError(tagEpisode(tagger.TagEpisode, tagger.conf, ti, files.WithExtension("some/file", expectedVideoExtension)))
+		assert.StringsEqual("crash boom", tagger.title)
+		assert.IntsEqual(1, tagger.episode)
+		assert.IntsEqual(2, tagger.lastEpisode)
+		assert.StringsEqual(filepath.Join(conf.OutputDirectory, seriesMi.Title, "4", files.WithExtension("traffic education-s04e01-e02-crash boom", expectedVideoExtension)), tagger.outFile)
+		assert.StringsEqual("traffic education S04E01-E02 - crash boom", assert.StringNotError(TitleFor(conf, ti)))
+	})
+
+	t.Run("tag special unknown to meta-info source with placeholder", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		repoDir := filepath.Join(dir, "repo")
+
+		seriesMi := video.SeriesMetaInfo{IdInfo: metainfo.IdInfo{Id: "series-id"}, Title: "traffic education", Seasons: 9, Year: "2010", Poster: "/pic/of/a/car.jpeg"}
+		metainfo.SaveMetaInfo(video.SeriesFileName(repoDir, seriesMi.Id), seriesMi)
+		ti := targetinfo.NewEpisode(files.WithExtension("making-of", expectedVideoExtension), "/some/dir/Specials", seriesMi.Id, 0, 3, 1)
+		conf := &ripper.AppConf{MetaInfoRepo: repoDir, Output: &ripper.OutputConfig{}, OutputDirectory: filepath.Join(dir, "output")}
+
+		tagger := testTagger{conf: conf}
+		assert.NotError(tagEpisode(tagger.TagEpisode, tagger.conf, ti, files.WithExtension("some/file", expectedVideoExtension)))
+		assert.StringsEqual("Special 3", tagger.title)
+		assert.IntsEqual(0, tagger.season)
+		assert.IntsEqual(3, tagger.episode)
+	})
+}
+
+func TestCombinedEpisodes(t *testing.T) {
+	episode := func(no int, title string) *video.EpisodeMetaInfo {
+		return &video.EpisodeMetaInfo{IdInfo: metainfo.IdInfo{Id: fmt.Sprintf("id-%d", no)}, Title: title, Season: 1, Episode: no, Year: "2001"}
+	}
+	t.Run("single episode remains unchanged", func(t *testing.T) {
+		test.AssertOn(t).StringsEqual("Pilot (1)", combinedEpisodes([]*video.EpisodeMetaInfo{episode(1, "Pilot (1)")}).Title)
+	})
+	t.Run("join parts of the same story", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		combined := combinedEpisodes([]*video.EpisodeMetaInfo{episode(1, "The Beginning, Part 1"), episode(2, "The Beginning, Part 2")})
+		assert.StringsEqual("The Beginning", combined.Title)
+		assert.StringsEqual("id-1", combined.Id)
+		assert.IntsEqual(1, combined.Episode)
+	})
+	t.Run("concatenate different titles", func(t *testing.T) {
+		combined := combinedEpisodes([]*video.EpisodeMetaInfo{episode(1, "Pilot"), episode(2, "Second")})
+		test.AssertOn(t).StringsEqual("Pilot / Second", combined.Title)
+	})
+	t.Run("concatenate plots and sum up runtimes", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		first, second := episode(1, "Pilot"), episode(2, "Second")
+		first.Plot, first.Runtime = "It begins.", 45
+		second.Plot, second.Runtime = "It goes on.", 42
+		combined := combinedEpisodes([]*video.EpisodeMetaInfo{first, second})
+		assert.StringsEqual("It begins. It goes on.", combined.Plot)
+		assert.IntsEqual(87, combined.Runtime)
+		assert.IntsEqual(45, first.Runtime)
+	})
+}
+
+func TestEpisodeNumber(t *testing.T) {
+	assert := test.AssertOn(t)
+	assert.StringsEqual("3", episodeNumber(3, 0))
+	assert.StringsEqual("3", episodeNumber(3, 3))
+	assert.StringsEqual("3-4", episodeNumber(3, 4))
 }
 
 func TestTagVideo(t *testing.T) {
@@ -297,3 +459,48 @@ func TestTagVideo(t *testing.T) {
 		assert.IntsEqual(1, len(jobs))
 	})
 }
+
+func TestDestinationPathFor(t *testing.T) {
+	conf := &ripper.AppConf{
+		MetaInfoRepo:    "./testdata/meta",
+		OutputDirectory: "/out",
+		Output:          &ripper.OutputConfig{Video: expectedVideoExtension},
+	}
+
+	t.Run("movie", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		ti := targetinfo.NewMovie("flick.avi", "./testdata/in", "some-flick")
+		dst := assert.StringNotError(DestinationPathFor(conf, ti))
+		assert.StringsEqual(filepath.Join("/out", files.WithExtension("some flick", expectedVideoExtension)), dst)
+	})
+
+	t.Run("episode", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		ti := targetinfo.NewEpisode("part1.avi", "./testdata/in", "part1", 3, 1, 3)
+		dst := assert.StringNotError(DestinationPathFor(conf, ti))
+		expectedFileName := files.WithExtension(fmt.Sprintf(defaultEpisodeFileName, "in many parts", 3, 1, "the real 1st part"), expectedVideoExtension)
+		assert.StringsEqual(filepath.Join("/out", "in many parts", "3", expectedFileName), dst)
+	})
+
+	t.Run("expect error for missing meta-info", func(t *testing.T) {
+		ti := targetinfo.NewMovie("flick.avi", "./testdata/in", "unknown-flick")
+		_, err := DestinationPathFor(conf, ti)
+		test.AssertOn(t).ExpectError("expected error for missing meta-info, but got none")(err)
+	})
+}
+
+func TestTitleFor(t *testing.T) {
+	conf := &ripper.AppConf{MetaInfoRepo: "./testdata/meta"}
+
+	t.Run("movie", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		ti := targetinfo.NewMovie("flick.avi", "./testdata/in", "some-flick")
+		assert.StringsEqual("some flick", assert.StringNotError(TitleFor(conf, ti)))
+	})
+
+	t.Run("episode", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		ti := targetinfo.NewEpisode("part1.avi", "./testdata/in", "part1", 3, 1, 3)
+		assert.StringsEqual("in many parts S03E01 - the real 1st part", assert.StringNotError(TitleFor(conf, ti)))
+	})
+}
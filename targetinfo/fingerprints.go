@@ -0,0 +1,121 @@
+package targetinfo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/thomasschoeftner/go-ripper/files"
+)
+
+// the fingerprint index maps the fingerprints of all scanned targets to all targets with identical inputs (i.e. copies).
+// It is kept in the state directory, so targets moved between runs with varying work directories are found as well.
+const fingerprintIndexFileName = "fingerprints.json"
+
+var fingerprintIndexLock = sync.Mutex{}
+
+// indexedTarget is a target scanned with a fingerprint and the work directory holding its artifacts
+type indexedTarget struct {
+	Path          string `json:"path"`
+	WorkDirectory string `json:"workDirectory"`
+}
+
+func readFingerprintIndex(stateDir string) (map[string][]indexedTarget, error) {
+	index := map[string][]indexedTarget{}
+	raw, err := ioutil.ReadFile(filepath.Join(stateDir, fingerprintIndexFileName))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return index, json.Unmarshal(raw, &index)
+}
+
+// lookupFingerprint returns all targets scanned with a fingerprint
+func lookupFingerprint(stateDir string, fingerprint string) ([]indexedTarget, error) {
+	fingerprintIndexLock.Lock()
+	defer fingerprintIndexLock.Unlock()
+	index, err := readFingerprintIndex(stateDir)
+	if err != nil {
+		return nil, err
+	}
+	return index[fingerprint], nil
+}
+
+// indexedFingerprint returns the fingerprint a path was last scanned with, regardless of the work directory - or an empty string if it was not scanned yet
+func indexedFingerprint(stateDir string, path string) (string, error) {
+	fingerprintIndexLock.Lock()
+	defer fingerprintIndexLock.Unlock()
+	index, err := readFingerprintIndex(stateDir)
+	if err != nil {
+		return "", err
+	}
+	path = absPath(path)
+	for fingerprint, targets := range index {
+		for _, t := range targets {
+			if t.Path == path {
+				return fingerprint, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// indexFingerprint records the path and work directory of a target with a fingerprint.
+// Paths which no longer exist are dropped, as well as the path itself from its previous fingerprint (i.e. if its input changed).
+// The index is written to a temp file first, so it is never truncated.
+func indexFingerprint(stateDir string, fingerprint string, path string, workDir string) error {
+	fingerprintIndexLock.Lock()
+	defer fingerprintIndexLock.Unlock()
+	index, err := readFingerprintIndex(stateDir)
+	if err != nil {
+		return err
+	}
+	path, workDir = absPath(path), absPath(workDir)
+	for f, targets := range index {
+		if f == fingerprint {
+			continue
+		}
+		remaining := []indexedTarget{}
+		for _, t := range targets {
+			if t.Path != path {
+				remaining = append(remaining, t)
+			}
+		}
+		if len(remaining) == 0 {
+			delete(index, f)
+		} else {
+			index[f] = remaining
+		}
+	}
+	targets := []indexedTarget{{Path: path, WorkDirectory: workDir}}
+	for _, t := range index[fingerprint] {
+		if exists, _ := files.Exists(t.Path); exists && t.Path != path {
+			targets = append(targets, t)
+		}
+	}
+	index[fingerprint] = targets
+
+	if err := files.CreateFolderStructure(stateDir); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(stateDir, fingerprintIndexFileName)
+	if err := ioutil.WriteFile(file+".tmp", raw, os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(file+".tmp", file)
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,32 @@
+package targetinfo
+
+import "time"
+
+// StageResult records a processing stage completed for a target
+type StageResult struct {
+	Stage   string    `json:"stage"`
+	Tool    string    `json:"tool"`
+	Version string    `json:"version,omitempty"` // version of the tool, if known
+	Output  string    `json:"output,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+func (t *Typed) GetHistory() []StageResult {
+	return t.History
+}
+
+// AddStageResult appends a result to the history - previous results are never changed
+func (t *Typed) AddStageResult(result StageResult) {
+	t.History = append(t.History, result)
+}
+
+// LastResultOf returns the latest result of a stage in the history of a target, or nil if the stage was never completed
+func LastResultOf(ti TargetInfo, stage string) *StageResult {
+	history := ti.GetHistory()
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Stage == stage {
+			return &history[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package targetinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+func TestSaveAtomically(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+
+	movie := NewMovie("m.mkv", "/a/b/c", "tt1")
+	assert.NotError(Save(dir, movie))
+	assert.NotError(Save(dir, movie))
+	written, err := ioutil.ReadDir(dir)
+	assert.NotError(err)
+	assert.IntsEqual(1, len(written)) // no temporary files are left behind
+	assert.StringsEqual(fileName(movie), written[0].Name())
+
+	read, err := read(dir, movie.File)
+	assert.NotError(err)
+	assert.IntsEqual(CURRENT_VERSION, read.typed().Version)
+}
+
+func TestReadTruncated(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, "m.mkv."+targetinfo_file_extension), []byte(`{"type": "movie", "fi`), os.ModePerm))
+	_, err := read(dir, "m.mkv")
+	assert.ExpectError("expected error when reading truncated target-info")(err)
+}
+
+func TestMigrateUnversioned(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	unversioned := `{"type": "episode", "file": "e.avi", "folder": "/a/b", "id": "tt1", "season": 1, "episode": 2, "itemstotal": 10}`
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, "e.avi."+targetinfo_file_extension), []byte(unversioned), os.ModePerm))
+
+	read, err := read(dir, "e.avi")
+	assert.NotError(err)
+	episode := read.(*Episode)
+	assert.IntsEqual(CURRENT_VERSION, episode.Version)
+	assert.IntsEqual(2, episode.Episode)
+	assert.IntsEqual(0, len(episode.GetHistory()))
+}
+
+func TestRejectFutureVersion(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, "m.mkv."+targetinfo_file_extension), []byte(`{"type": "movie", "version": 999}`), os.ModePerm))
+	_, err := read(dir, "m.mkv")
+	assert.ExpectError("expected error when reading target-info of unknown version")(err)
+}
+
+func TestStageHistory(t *testing.T) {
+	assert := test.AssertOn(t)
+	movie := NewMovie("m.mkv", "/a/b/c", "tt1")
+	assert.True("expected no result before stage is completed")(LastResultOf(movie, "ripped") == nil)
+
+	movie.AddStageResult(StageResult{Stage: "ripped", Tool: "handbrake", Output: "first.mp4", At: time.Now()})
+	movie.AddStageResult(StageResult{Stage: "tagged", Tool: "ffmpeg", At: time.Now()})
+	movie.AddStageResult(StageResult{Stage: "ripped", Tool: "handbrake", Output: "second.mp4", At: time.Now()})
+	assert.IntsEqual(3, len(movie.GetHistory()))
+	assert.StringsEqual("second.mp4", LastResultOf(movie, "ripped").Output)
+}
+
+func TestSaveScanned(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	inDir := filepath.Join(dir, "in")
+	workDir := filepath.Join(dir, "work")
+	test.CheckError(t, os.MkdirAll(inDir, os.ModePerm))
+	source := filepath.Join(inDir, "m.mkv")
+	test.CheckError(t, ioutil.WriteFile(source, []byte("hello world"), os.ModePerm))
+	workFolder, err := ripper.GetWorkPathForTargetFolder(workDir, inDir)
+	test.CheckError(t, err)
+
+	scanned := NewMovie("m.mkv", inDir, "tt1")
+	_, err = SaveScanned(workDir, workDir, scanned)
+	assert.NotError(err)
+	assert.StringsEqual(assert.StringNotError(files.Fingerprint(source)), scanned.GetFingerprint())
+	scanned.AddStageResult(StageResult{Stage: "ripped", Tool: "handbrake", At: time.Now()})
+	assert.NotError(Save(workFolder, scanned))
+
+	t.Run("keep history and fingerprint of unchanged source", func(t *testing.T) {
+		rescanned := NewMovie("m.mkv", inDir, "tt1")
+		rescan, err := SaveScanned(workDir, workDir, rescanned)
+		assert.NotError(err)
+		assert.False("expected unchanged source not to be invalidated")(rescan.Invalidated)
+		assert.IntsEqual(1, len(rescanned.GetHistory()))
+		assert.StringsEqual(scanned.GetFingerprint(), rescanned.GetFingerprint())
+	})
+
+	t.Run("re-calculate fingerprint and drop history of changed source", func(t *testing.T) {
+		test.CheckError(t, ioutil.WriteFile(source, []byte("hello world!"), os.ModePerm))
+		rescanned := NewMovie("m.mkv", inDir, "tt1")
+		rescan, err := SaveScanned(workDir, workDir, rescanned)
+		assert.NotError(err)
+		assert.True("expected changed source to be invalidated")(rescan.Invalidated)
+		assert.IntsEqual(0, len(rescanned.GetHistory()))
+		assert.True("expected new fingerprint of changed source")(scanned.GetFingerprint() != rescanned.GetFingerprint())
+	})
+}
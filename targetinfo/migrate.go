@@ -0,0 +1,48 @@
+package targetinfo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// migrations convert raw target-infos of version i to version i+1
+var migrations = []func(raw map[string]interface{}) error{
+	migrateToV1,
+}
+
+// migrate converts raw target-infos of older versions to the current version
+func migrate(jsonRaw []byte) ([]byte, error) {
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(jsonRaw, &raw); err != nil {
+		return nil, err
+	}
+	version := 0
+	if v, isNumber := raw["version"].(float64); isNumber {
+		version = int(v)
+	}
+	if version == CURRENT_VERSION {
+		return jsonRaw, nil
+	}
+	if version > CURRENT_VERSION {
+		return nil, fmt.Errorf("unsupported version %d - the latest supported version is %d", version, CURRENT_VERSION)
+	}
+
+	for ; version < CURRENT_VERSION; version++ {
+		if err := migrations[version](raw); err != nil {
+			return nil, err
+		}
+		raw["version"] = version + 1
+	}
+	return json.Marshal(raw)
+}
+
+// target-infos without version lack the fingerprint and the history - only the history is added here.
+// The fingerprint is not computed during migration, as reading target-infos must not access their input files.
+// SaveScanned is the only place where fingerprints are set, so migrated target-infos get one when their target is scanned again -
+// until then, they are neither found by fingerprint after moving their input, nor invalidated after their input changed.
+func migrateToV1(raw map[string]interface{}) error {
+	if _, hasHistory := raw["history"]; !hasHistory {
+		raw["history"] = []interface{}{}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package targetinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+// Rescan tells how a scanned target relates to the work artifacts of previous scans
+type Rescan struct {
+	MovedFrom   string // previous path of a moved or renamed target, whose artifacts were taken over
+	Invalidated bool   // the input changed since the previous scan, so all artifacts of the target were removed
+}
+
+// SaveScanned saves a newly scanned target-info to the work directory.
+// If the target was scanned before, its history is kept - unless the fingerprint of its input changed, which invalidates all artifacts.
+// Changed input is detected by the fingerprint index as well, so targets scanned in the work directories of previous runs are invalidated too.
+// Targets scanned for the first time are looked up by fingerprint, so artifacts of moved or renamed targets are taken over -
+// also from the work directories of previous runs, as the fingerprint index is kept in the state directory.
+// Input files are never hashed completely, as the fingerprint reads only their first and last chunks.
+func SaveScanned(stateDir string, workDir string, ti TargetInfo) (*Rescan, error) {
+	rescan := &Rescan{}
+	workFolder, err := ripper.GetWorkPathForTargetFolder(workDir, ti.GetFolder())
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := files.Fingerprint(InputFiles(ti)...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the indexed fingerprint reveals changed input also if the target was scanned in another work directory before
+	indexed, err := indexedFingerprint(stateDir, ti.GetFullPath())
+	if err != nil {
+		return nil, err
+	}
+
+	t := ti.typed()
+	t.Fingerprint = fingerprint
+	previous, err := read(workFolder, ti.GetFile())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if previous == nil {
+		if previous, err = takeOverMoved(stateDir, workDir, workFolder, ti); err != nil {
+			return nil, err
+		}
+		if previous != nil {
+			rescan.MovedFrom = previous.GetFullPath()
+		} else if len(indexed) > 0 && indexed != fingerprint {
+			rescan.Invalidated = true
+		}
+	} else if recorded := fingerprintOf(previous, indexed); len(recorded) > 0 && recorded != fingerprint {
+		if err := removeArtifacts(workFolder, previous); err != nil {
+			return nil, err
+		}
+		rescan.Invalidated = true
+		previous = nil
+	}
+
+	if previous != nil && previous.GetType() == ti.GetType() {
+		t.History = previous.typed().History
+	}
+	if err := Save(workFolder, ti); err != nil {
+		return nil, err
+	}
+	return rescan, indexFingerprint(stateDir, fingerprint, ti.GetFullPath(), workDir)
+}
+
+// fingerprintOf returns the fingerprint of a previous target-info - target-infos migrated without fingerprint fall back to the indexed one
+func fingerprintOf(previous TargetInfo, indexed string) string {
+	if len(previous.typed().Fingerprint) > 0 {
+		return previous.typed().Fingerprint
+	}
+	return indexed
+}
+
+// takeOverMoved looks up a target by fingerprint - if the previous input no longer exists, its artifacts are moved to the target.
+// Artifacts of copies, whose inputs still exist, are not shared.
+func takeOverMoved(stateDir string, workDir string, workFolder string, ti TargetInfo) (TargetInfo, error) {
+	previousTargets, err := lookupFingerprint(stateDir, ti.typed().Fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	for _, previousTarget := range previousTargets {
+		if exists, err := files.Exists(previousTarget.Path); err != nil || exists {
+			continue
+		}
+		previousWorkDir := previousTarget.WorkDirectory
+		if len(previousWorkDir) == 0 {
+			previousWorkDir = workDir
+		}
+		previousFolder, previousFile := filepath.Split(previousTarget.Path)
+		previousWorkFolder, err := ripper.GetWorkPathForTargetFolder(previousWorkDir, previousFolder)
+		if err != nil {
+			return nil, err
+		}
+		previous, err := read(previousWorkFolder, previousFile)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := moveArtifacts(previousWorkFolder, previous, workFolder, ti.GetFile()); err != nil {
+			return nil, err
+		}
+		return previous, nil
+	}
+	return nil, nil
+}
+
+// artifactsOf returns the names of all artifacts of a target in its work folder - i.e. files named after the target file
+// (e.g. movie.avi.targetinfo) and outputs recorded in the target's history (e.g. movie.mp4).
+// Artifacts named after the target file without extension are not taken otherwise, as they might belong to a sibling (e.g. movie.mkv).
+func artifactsOf(workFolder string, ti TargetInfo) ([]string, error) {
+	entries, err := ioutil.ReadDir(workFolder)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	recorded := map[string]bool{}
+	for _, result := range ti.GetHistory() {
+		if len(result.Output) > 0 && sameFolder(filepath.Dir(result.Output), workFolder) {
+			recorded[filepath.Base(result.Output)] = true
+		}
+	}
+	artifacts := []string{}
+	for _, entry := range entries {
+		n := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(n, ti.GetFile()+".") || recorded[n] {
+			artifacts = append(artifacts, n)
+		}
+	}
+	return artifacts, nil
+}
+
+func sameFolder(a string, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+func removeArtifacts(workFolder string, ti TargetInfo) error {
+	artifacts, err := artifactsOf(workFolder, ti)
+	if err != nil {
+		return err
+	}
+	for _, a := range artifacts {
+		if err := os.Remove(filepath.Join(workFolder, a)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveArtifacts moves all artifacts of a target to the work folder of its new location and renames them after the new target file
+func moveArtifacts(fromFolder string, from TargetInfo, toFolder string, toFile string) error {
+	fromFile := from.GetFile()
+	artifacts, err := artifactsOf(fromFolder, from)
+	if err != nil {
+		return err
+	}
+	if err := files.CreateFolderStructure(toFolder); err != nil {
+		return err
+	}
+	fromName, _ := files.SplitExtension(fromFile)
+	toName, _ := files.SplitExtension(toFile)
+	for _, a := range artifacts {
+		renamed := a
+		if strings.HasPrefix(a, fromFile+".") {
+			renamed = toFile + strings.TrimPrefix(a, fromFile)
+		} else if strings.HasPrefix(a, fromName+".") {
+			renamed = toName + strings.TrimPrefix(a, fromName)
+		}
+		if err := files.Move(filepath.Join(fromFolder, a), filepath.Join(toFolder, renamed)); err != nil {
+			return fmt.Errorf("failed to move artifact %s of moved target - %s", a, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package targetinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+func TestRescanMovedTarget(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	workDir := filepath.Join(dir, "work")
+	oldDir, newDir := filepath.Join(dir, "old"), filepath.Join(dir, "new")
+	test.CheckError(t, os.MkdirAll(oldDir, os.ModePerm))
+	test.CheckError(t, os.MkdirAll(newDir, os.ModePerm))
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(oldDir, "m.avi"), []byte("movie"), os.ModePerm))
+
+	scanned := NewMovie("m.avi", oldDir, "tt1")
+	_, err := SaveScanned(workDir, workDir, scanned)
+	assert.NotError(err)
+	oldWorkFolder, err := ripper.GetWorkPathForTargetFolder(workDir, oldDir)
+	test.CheckError(t, err)
+	scanned.AddStageResult(StageResult{Stage: "ripped", Tool: "handbrake", Output: filepath.Join(oldWorkFolder, "m.mp4"), At: time.Now()})
+	test.CheckError(t, Save(oldWorkFolder, scanned))
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(oldWorkFolder, "m.mp4"), []byte("ripped"), os.ModePerm))
+
+	t.Run("copies do not share artifacts", func(t *testing.T) {
+		copyDir := filepath.Join(dir, "copy")
+		test.CheckError(t, os.MkdirAll(copyDir, os.ModePerm))
+		test.CheckError(t, ioutil.WriteFile(filepath.Join(copyDir, "m.avi"), []byte("movie"), os.ModePerm))
+		copied := NewMovie("m.avi", copyDir, "tt1")
+		rescan, err := SaveScanned(workDir, workDir, copied)
+		assert.NotError(err)
+		assert.StringsEqual("", rescan.MovedFrom)
+		assert.IntsEqual(0, len(copied.GetHistory()))
+	})
+
+	t.Run("take over artifacts and history of moved target", func(t *testing.T) {
+		test.CheckError(t, os.Rename(filepath.Join(oldDir, "m.avi"), filepath.Join(newDir, "renamed.avi")))
+		moved := NewMovie("renamed.avi", newDir, "tt1")
+		rescan, err := SaveScanned(workDir, workDir, moved)
+		assert.NotError(err)
+		assert.True("expected target to be taken over")(len(rescan.MovedFrom) > 0)
+		assert.IntsEqual(1, len(moved.GetHistory()))
+
+		newWorkFolder, err := ripper.GetWorkPathForTargetFolder(workDir, newDir)
+		test.CheckError(t, err)
+		assert.TrueNotError("expected ripped artifact to be renamed after moved target")(files.Exists(filepath.Join(newWorkFolder, "renamed.mp4")))
+		assert.FalseNotError("expected artifacts to be removed from previous work folder")(files.Exists(filepath.Join(oldWorkFolder, "m.mp4")))
+		_, err = read(oldWorkFolder, "m.avi")
+		assert.ExpectError("expected previous target-info to be moved")(err)
+	})
+}
+
+func TestRescanChangedTarget(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	workDir := filepath.Join(dir, "work")
+	inDir := filepath.Join(dir, "in")
+	test.CheckError(t, os.MkdirAll(inDir, os.ModePerm))
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(inDir, "m.avi"), []byte("movie"), os.ModePerm))
+	scanned := NewMovie("m.avi", inDir, "tt1")
+	_, err := SaveScanned(workDir, workDir, scanned)
+	assert.NotError(err)
+	workFolder, err := ripper.GetWorkPathForTargetFolder(workDir, inDir)
+	test.CheckError(t, err)
+	ripped, other, sibling := filepath.Join(workFolder, "m.mp4"), filepath.Join(workFolder, "m.sample.mp4"), filepath.Join(workFolder, "m.m4v")
+	test.CheckError(t, ioutil.WriteFile(ripped, []byte("ripped"), os.ModePerm))
+	test.CheckError(t, ioutil.WriteFile(other, []byte("other"), os.ModePerm))
+	test.CheckError(t, ioutil.WriteFile(sibling, []byte("ripped m.mkv"), os.ModePerm))
+	scanned.AddStageResult(StageResult{Stage: "ripped", Tool: "handbrake", Output: ripped, At: time.Now()})
+	test.CheckError(t, Save(workFolder, scanned))
+
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(inDir, "m.avi"), []byte("changed movie"), os.ModePerm))
+	rescan, err := SaveScanned(workDir, workDir, NewMovie("m.avi", inDir, "tt1"))
+	assert.NotError(err)
+	assert.True("expected changed target to be invalidated")(rescan.Invalidated)
+	assert.FalseNotError("expected stale artifact to be removed")(files.Exists(ripped))
+	assert.TrueNotError("expected artifacts of other targets to be kept")(files.Exists(other))
+	assert.TrueNotError("expected unrecorded artifacts of siblings with the same name to be kept")(files.Exists(sibling))
+}
+
+func TestRescanTargetMovedBetweenRuns(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	stateDir, previousWorkDir, workDir := filepath.Join(dir, "storage"), filepath.Join(dir, "work-1"), filepath.Join(dir, "work-2")
+	oldDir, newDir := filepath.Join(dir, "old"), filepath.Join(dir, "new")
+	test.CheckError(t, os.MkdirAll(oldDir, os.ModePerm))
+	test.CheckError(t, os.MkdirAll(newDir, os.ModePerm))
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(oldDir, "m.avi"), []byte("movie"), os.ModePerm))
+
+	scanned := NewMovie("m.avi", oldDir, "tt1")
+	_, err := SaveScanned(stateDir, previousWorkDir, scanned)
+	assert.NotError(err)
+	assert.TrueNotError("expected fingerprint index in state directory")(files.Exists(filepath.Join(stateDir, fingerprintIndexFileName)))
+
+	test.CheckError(t, os.Rename(filepath.Join(oldDir, "m.avi"), filepath.Join(newDir, "m.avi")))
+	moved := NewMovie("m.avi", newDir, "tt1")
+	rescan, err := SaveScanned(stateDir, workDir, moved)
+	assert.NotError(err)
+	assert.StringsEqual(filepath.Join(oldDir, "m.avi"), rescan.MovedFrom)
+	newWorkFolder, err := ripper.GetWorkPathForTargetFolder(workDir, newDir)
+	test.CheckError(t, err)
+	assert.TrueNotError("expected target-info to be taken over into current work directory")(files.Exists(filepath.Join(newWorkFolder, "m.avi.targetinfo")))
+}
+
+func TestRescanTargetChangedBetweenRuns(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	stateDir, previousWorkDir, workDir := filepath.Join(dir, "storage"), filepath.Join(dir, "work-1"), filepath.Join(dir, "work-2")
+	input := filepath.Join(dir, "in", "m.avi")
+	test.CheckError(t, os.MkdirAll(filepath.Dir(input), os.ModePerm))
+	test.CheckError(t, ioutil.WriteFile(input, []byte("movie"), os.ModePerm))
+
+	_, err := SaveScanned(stateDir, previousWorkDir, NewMovie("m.avi", filepath.Dir(input), "tt1"))
+	assert.NotError(err)
+	previousFingerprint := assert.StringNotError(indexedFingerprint(stateDir, input))
+
+	test.CheckError(t, ioutil.WriteFile(input, []byte("another movie"), os.ModePerm))
+	rescan, err := SaveScanned(stateDir, workDir, NewMovie("m.avi", filepath.Dir(input), "tt1"))
+	assert.NotError(err)
+	assert.True("expected changed input to be invalidated, although it was scanned in another work directory")(rescan.Invalidated)
+
+	fingerprint := assert.StringNotError(indexedFingerprint(stateDir, input))
+	assert.False("expected path to be indexed with the new fingerprint")(fingerprint == previousFingerprint)
+	previousTargets, err := lookupFingerprint(stateDir, previousFingerprint)
+	assert.NotError(err)
+	assert.IntsEqual(0, len(previousTargets))
+
+	rescan, err = SaveScanned(stateDir, previousWorkDir, NewMovie("m.avi", filepath.Dir(input), "tt1"))
+	assert.NotError(err)
+	assert.True("expected outdated target-info in previous work directory to be invalidated")(rescan.Invalidated)
+}
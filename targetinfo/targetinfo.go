@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/thomasschoeftner/go-ripper/files"
 	"github.com/thomasschoeftner/go-ripper/ripper"
@@ -14,9 +15,13 @@ import (
 
 const targetinfo_file_extension = "targetinfo"
 
+// CURRENT_VERSION is the schema version of written target-info files - files of older versions are migrated when read
+const CURRENT_VERSION = 1
+
 const (
 	TARGETINFO_TYPE_MOVIE   = "movie"
 	TARGETINFO_TPYE_EPISODE = "episode"
+	TARGETINFO_TYPE_TRACK   = "track"
 )
 
 type TargetInfo interface {
@@ -26,32 +31,81 @@ type TargetInfo interface {
 	GetType() string
 	GetId() string
 	GetFullPath() string
+	GetFingerprint() string
+	GetHistory() []StageResult
+	AddStageResult(result StageResult)
+	typed() *Typed
 }
 
 func fileName(ti TargetInfo) string {
 	return files.WithExtension(ti.GetFile(), targetinfo_file_extension)
 }
 
+const (
+	SIDECAR_KIND_SUBTITLE = "subtitle"
+	SIDECAR_KIND_AUDIO    = "audio"
+)
+
+// Typed holds the type and the fields common to all types of target-infos
 type Typed struct {
-	Type string `json:"type"`
+	Type        string        `json:"type"`
+	Version     int           `json:"version"`
+	Fingerprint string        `json:"fingerprint,omitempty"` // partial content hash of all input files - identifies them after moving or renaming them (set by SaveScanned only)
+	History     []StageResult `json:"history,omitempty"`     // append-only
+}
+
+func (t *Typed) GetFingerprint() string {
+	return t.Fingerprint
+}
+
+func (t *Typed) typed() *Typed {
+	return t
 }
 
 type Video struct {
 	Typed
-	File   string `json:"file"`
-	Folder string `json:"folder"`
-	Id     string `json:"id"`
+	File     string    `json:"file"`
+	Folder   string    `json:"folder"`
+	Id       string    `json:"id"`
+	Title    string    `json:"title,omitempty"` // title and year of videos without id - used to look up the id when resolving
+	Year     string    `json:"year,omitempty"`
+	Sidecars []Sidecar `json:"sidecars,omitempty"` // external subtitles and audio tracks muxed into the output
+}
+
+// Sidecar is an external subtitle or audio file sharing the base name of a video in the same folder (e.g. movie.de.srt)
+type Sidecar struct {
+	File     string `json:"file"`
+	Data     string `json:"data,omitempty"` // data file of subtitles split into index and data (i.e. VobSub .idx/.sub)
+	Kind     string `json:"kind"`
+	Language string `json:"language,omitempty"` // ISO 639-2 code (e.g. "ger"), empty if unknown
+	Forced   bool   `json:"forced,omitempty"`
 }
 
 type Movie struct {
 	Video
+	Parts []string `json:"parts,omitempty"` // ordered files of multi-part movies - the first part is the movie's file
 }
 
 type Episode struct {
 	//Typed
 	Video
-	Season     int `json:"season"`
-	Episode    int `json:"episode"`
+	Season      int `json:"season"`
+	Episode     int `json:"episode"`
+	LastEpisode int `json:"lastEpisode,omitempty"` // last episode of multi-episode files (e.g. s01e01-e02)
+	ItemsTotal  int `json:"itemstotal"`
+}
+
+type Audio struct {
+	Typed
+	File   string `json:"file"`
+	Folder string `json:"folder"`
+	Id     string `json:"id"` //id of album
+}
+
+type Track struct {
+	Audio
+	Disc       int `json:"disc"`
+	Track      int `json:"track"`
 	ItemsTotal int `json:"itemstotal"`
 }
 
@@ -76,25 +130,115 @@ func (v *Movie) GetType() string {
 }
 
 func (m *Movie) String() string {
+	if m.IsMultiPart() {
+		return fmt.Sprintf("movie   (id=%s, file=%s, parts=%d)", m.Id, filepath.Join(m.Folder, m.File), len(m.Parts))
+	}
 	return fmt.Sprintf("movie   (id=%s, file=%s)", m.Id, filepath.Join(m.Folder, m.File))
 }
 
+func (m *Movie) IsMultiPart() bool {
+	return len(m.Parts) > 1
+}
+
 func (e *Episode) GetType() string {
 	return TARGETINFO_TPYE_EPISODE
 }
 
 func (e *Episode) String() string {
+	if e.IsMultiEpisode() {
+		return fmt.Sprintf("episode (id=%s, season=%-4d, episodes=%d-%d, totalItems=%-4d, file=%s)", e.Id, e.Season, e.Episode, e.LastEpisode, e.ItemsTotal, filepath.Join(e.Folder, e.File))
+	}
 	return fmt.Sprintf("episode (id=%s, season=%-4d, episode=%-4d, totalItems=%-4d, file=%s)", e.Id, e.Season, e.Episode, e.ItemsTotal, filepath.Join(e.Folder, e.File))
 }
 
+func (e *Episode) IsMultiEpisode() bool {
+	return e.LastEpisode > e.Episode
+}
+
+// IsSpecial returns true for specials, which are filed under season 0
+func (e *Episode) IsSpecial() bool {
+	return e.Season == 0
+}
+
+// Episodes returns the numbers of all episodes contained in the episode's file
+func (e *Episode) Episodes() []int {
+	episodes := []int{e.Episode}
+	for no := e.Episode + 1; no <= e.LastEpisode; no++ {
+		episodes = append(episodes, no)
+	}
+	return episodes
+}
+
+func (a *Audio) GetFile() string {
+	return a.File
+}
+
+func (a *Audio) GetFolder() string {
+	return a.Folder
+}
+
+func (a *Audio) GetId() string {
+	return a.Id
+}
+
+func (a *Audio) GetFullPath() string {
+	return filepath.Join(a.Folder, a.File)
+}
+
+func (t *Track) GetType() string {
+	return TARGETINFO_TYPE_TRACK
+}
+
+func (t *Track) String() string {
+	return fmt.Sprintf("track   (id=%s, disc=%-4d, track=%-4d, totalItems=%-4d, file=%s)", t.Id, t.Disc, t.Track, t.ItemsTotal, filepath.Join(t.Folder, t.File))
+}
+
 func NewMovie(file string, folder string, id string) *Movie {
-	return &Movie{Video{Typed: Typed{Type: TARGETINFO_TYPE_MOVIE}, File: file, Folder: folder, Id: id}}
+	return &Movie{Video: Video{Typed: Typed{Type: TARGETINFO_TYPE_MOVIE}, File: file, Folder: folder, Id: id}}
+}
+
+// NewMultiPartMovie creates a movie which is split into several files (e.g. cd1, cd2) in the same folder
+func NewMultiPartMovie(parts []string, folder string, id string) *Movie {
+	movie := NewMovie(parts[0], folder, id)
+	movie.Parts = parts
+	return movie
 }
 
 func IsMovie(ti TargetInfo) bool {
 	return ti != nil && TARGETINFO_TYPE_MOVIE == ti.GetType()
 }
 
+// InputFiles returns the paths of all original input files of a target
+func InputFiles(ti TargetInfo) []string {
+	if movie, isMovie := ti.(*Movie); isMovie && movie.IsMultiPart() {
+		paths := []string{}
+		for _, part := range movie.Parts {
+			paths = append(paths, filepath.Join(movie.Folder, part))
+		}
+		return paths
+	}
+	return []string{ti.GetFullPath()}
+}
+
+// SidecarFiles returns the paths of all sidecar files of a video target
+func SidecarFiles(ti TargetInfo) []string {
+	var sidecars []Sidecar
+	switch v := ti.(type) {
+	case *Movie:
+		sidecars = v.Sidecars
+	case *Episode:
+		sidecars = v.Sidecars
+	}
+	paths := []string{}
+	for _, s := range sidecars {
+		paths = append(paths, filepath.Join(ti.GetFolder(), s.File))
+		if len(s.Data) > 0 {
+			paths = append(paths, filepath.Join(ti.GetFolder(), s.Data))
+		}
+	}
+	return paths
+}
+
 func NewEpisode(file string, folder string, id string, season int, episode int, itemsTotal int) *Episode {
 	vid := Video{Typed: Typed{Type: TARGETINFO_TPYE_EPISODE}, File: file, Folder: folder, Id: id}
 	return &Episode{Video: vid, Season: season, Episode: episode, ItemsTotal: itemsTotal}
@@ -104,8 +248,37 @@ func IsEpisode(ti TargetInfo) bool {
 	return ti != nil && TARGETINFO_TPYE_EPISODE == ti.GetType()
 }
 
+func NewTrack(file string, folder string, id string, disc int, track int, itemsTotal int) *Track {
+	aud := Audio{Typed: Typed{Type: TARGETINFO_TYPE_TRACK}, File: file, Folder: folder, Id: id}
+	return &Track{Audio: aud, Disc: disc, Track: track, ItemsTotal: itemsTotal}
+}
+
+func IsTrack(ti TargetInfo) bool {
+	return ti != nil && TARGETINFO_TYPE_TRACK == ti.GetType()
+}
+
+// target-infos planned during dry-runs by path of their target
+var (
+	plannedLock = sync.Mutex{}
+	planned     = map[string]TargetInfo{}
+)
+
+// Plan keeps a target-info in memory instead of saving it to the work folder (used for dry-runs)
+func Plan(ti TargetInfo) {
+	plannedLock.Lock()
+	defer plannedLock.Unlock()
+	planned[filepath.Clean(ti.GetFullPath())] = ti
+}
+
 // read TargetInfo for specific target file (input file)
 func ForTarget(workDir string, targetPath string) (TargetInfo, error) {
+	plannedLock.Lock()
+	ti := planned[filepath.Clean(targetPath)]
+	plannedLock.Unlock()
+	if ti != nil {
+		return ti, nil
+	}
+
 	targetFolder, targetFile := filepath.Split(targetPath)
 
 	workDir, err := ripper.GetWorkPathForTargetFolder(workDir, targetFolder)
@@ -116,17 +289,24 @@ func ForTarget(workDir string, targetPath string) (TargetInfo, error) {
 	return read(workDir, targetFile)
 }
 
-// read TargetInfo with specific filename
+// read TargetInfo with specific filename - files of older versions are migrated
 func read(workFolder string, targetFileName string) (TargetInfo, error) {
 	targetInfoFile := filepath.Join(workFolder, files.WithExtension(targetFileName, targetinfo_file_extension))
 	jsonRaw, err := ioutil.ReadFile(targetInfoFile)
 	if err != nil {
 		return nil, err
 	}
+	jsonRaw, err = migrate(jsonRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target info file %s - %s", targetInfoFile, err)
+	}
 
 	//1. get type
 	typed := Typed{}
 	err = json.Unmarshal(jsonRaw, &typed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target info file %s - %s", targetInfoFile, err)
+	}
 
 	var ti TargetInfo
 	switch typed.Type {
@@ -134,6 +314,8 @@ func read(workFolder string, targetFileName string) (TargetInfo, error) {
 		ti = &Movie{}
 	case TARGETINFO_TPYE_EPISODE:
 		ti = &Episode{}
+	case TARGETINFO_TYPE_TRACK:
+		ti = &Track{}
 	default:
 		return nil, errors.New(fmt.Sprintf("target info file contains invalid target info type: \"%s\"", typed.Type))
 	}
@@ -146,10 +328,12 @@ func read(workFolder string, targetFileName string) (TargetInfo, error) {
 	return ti, nil
 }
 
+// Save writes a target-info atomically - readers never see partially written files, even if saving is interrupted
 func Save(workFolder string, ti TargetInfo) error {
 	if ti == nil {
 		return errors.New("target info is nil")
 	}
+	ti.typed().Version = CURRENT_VERSION
 
 	err := files.CreateFolderStructure(workFolder)
 	if err != nil {
@@ -159,7 +343,22 @@ func Save(workFolder string, ti TargetInfo) error {
 	if err != nil {
 		return err
 	}
-	targetFile := filepath.Join(workFolder, fileName(ti))
-	err = ioutil.WriteFile(targetFile, bytes, os.ModePerm)
-	return nil
+
+	tmp, err := ioutil.TempFile(workFolder, fileName(ti)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails after successful rename
+	if _, err := tmp.Write(bytes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(workFolder, fileName(ti)))
 }
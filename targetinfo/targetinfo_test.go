@@ -3,6 +3,7 @@ package targetinfo
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/thomasschoeftner/go-cli/test"
@@ -10,6 +11,7 @@ import (
 
 var video = NewMovie("f.g", "/a/b/c", "test")
 var episode = NewEpisode("f.g", "/a/b/c", "tt987654321", 3, 12, 24)
+var track = NewTrack("03.flac", "/a/b/c", "a1b2c3", 2, 3, 14)
 
 func TestSaveJson(t *testing.T) {
 	t.Run("save single video", func(t *testing.T) {
@@ -58,11 +60,95 @@ func TestReadJson(t *testing.T) {
 	test.CheckError(t, err)
 
 	readEpisode := read.(*Episode)
-	if *episode != *readEpisode {
+	if !reflect.DeepEqual(episode, readEpisode) {
 		t.Errorf("targetinfo does not match:\n  to json   %v\n  from json %v", *episode, *readEpisode)
 	}
 }
 
+func TestReadMultiPartMovieJson(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+
+	movie := NewMultiPartMovie([]string{"m-cd1.avi", "m-cd2.avi"}, "/a/b/c", "tt1")
+	assert.NotError(Save(dir, movie))
+	read, err := read(dir, movie.File)
+	assert.NotError(err)
+	readMovie := read.(*Movie)
+	assert.StringsEqual("m-cd1.avi", readMovie.File)
+	assert.StringSlicesEqual(movie.Parts, readMovie.Parts)
+	assert.True("expected movie with several parts")(readMovie.IsMultiPart())
+}
+
+func TestReadMultiEpisodeJson(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+
+	e := NewEpisode("s01e01-e03.avi", "/a/b/c", "tt1", 1, 1, 10)
+	e.LastEpisode = 3
+	assert.NotError(Save(dir, e))
+	read, err := read(dir, e.File)
+	assert.NotError(err)
+	readEpisode := read.(*Episode)
+	assert.True("expected episode with several episodes")(readEpisode.IsMultiEpisode())
+	assert.IntsEqual(3, readEpisode.LastEpisode)
+	assert.IntsEqual(3, len(readEpisode.Episodes()))
+	assert.IntsEqual(12, episode.Episodes()[0])
+	assert.IntsEqual(1, len(episode.Episodes()))
+	assert.False("expected regular episode not to be a special")(episode.IsSpecial())
+}
+
+func TestInputFiles(t *testing.T) {
+	assert := test.AssertOn(t)
+	assert.StringSlicesEqual([]string{filepath.Join("/a/b/c", "f.g")}, InputFiles(video))
+	assert.StringSlicesEqual([]string{filepath.Join("/a/b/c", "f.g")}, InputFiles(episode))
+	movie := NewMultiPartMovie([]string{"m-cd1.avi", "m-cd2.avi"}, "/a/b/c", "tt1")
+	assert.StringSlicesEqual([]string{filepath.Join("/a/b/c", "m-cd1.avi"), filepath.Join("/a/b/c", "m-cd2.avi")}, InputFiles(movie))
+}
+
+func TestReadSidecarsJson(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+
+	movie := NewMovie("m.mkv", "/a/b/c", "tt1")
+	movie.Sidecars = []Sidecar{
+		{File: "m.de.srt", Kind: SIDECAR_KIND_SUBTITLE, Language: "ger"},
+		{File: "m.forced.idx", Data: "m.forced.sub", Kind: SIDECAR_KIND_SUBTITLE, Forced: true},
+		{File: "m.en.ac3", Kind: SIDECAR_KIND_AUDIO, Language: "eng"}}
+	assert.NotError(Save(dir, movie))
+	read, err := read(dir, movie.File)
+	assert.NotError(err)
+	assert.True("expected sidecars to be read")(reflect.DeepEqual(movie.Sidecars, read.(*Movie).Sidecars))
+
+	assert.StringSlicesEqual([]string{
+		filepath.Join("/a/b/c", "m.de.srt"),
+		filepath.Join("/a/b/c", "m.forced.idx"),
+		filepath.Join("/a/b/c", "m.forced.sub"),
+		filepath.Join("/a/b/c", "m.en.ac3")}, SidecarFiles(movie))
+	assert.IntsEqual(0, len(SidecarFiles(track)))
+}
+
+func TestReadTrackJson(t *testing.T) {
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+
+	err := Save(dir, track)
+	test.CheckError(t, err)
+
+	read, err := read(dir, track.File)
+	test.CheckError(t, err)
+
+	if !IsTrack(read) {
+		t.Fatalf("expected track target-info, but got %s", read.GetType())
+	}
+	readTrack := read.(*Track)
+	if !reflect.DeepEqual(track, readTrack) {
+		t.Errorf("targetinfo does not match:\n  to json   %v\n  from json %v", *track, *readTrack)
+	}
+}
+
 func TestSaveNilTargetInfo(t *testing.T) {
 	err := Save(".", nil)
 	if err == nil {
@@ -1,16 +1,40 @@
 package main
 
 import (
+	"github.com/thomasschoeftner/go-cli/commons"
 	"github.com/thomasschoeftner/go-cli/task"
 	"github.com/thomasschoeftner/go-ripper/clean"
 	"github.com/thomasschoeftner/go-ripper/scan"
 	"errors"
 	"github.com/thomasschoeftner/go-ripper/metainfo/video"
+	"github.com/thomasschoeftner/go-ripper/metainfo/audio"
 	"github.com/thomasschoeftner/go-ripper/tag"
 	"github.com/thomasschoeftner/go-ripper/rip"
+	"github.com/thomasschoeftner/go-ripper/remove"
+	"github.com/thomasschoeftner/go-ripper/ledger"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/report"
 )
 
-const TaskName_Tasks = "tasks"
+const (
+	TaskName_Tasks      = "tasks"
+	TaskName_Watch      = "watch"
+	TaskName_Serve      = "serve"
+	TaskName_ShowConfig = "showConfig"
+	TaskName_Status     = "status"
+)
+
+// read-only tasks neither process targets, nor write reports
+var readOnlyTasks = []string{TaskName_Tasks, TaskName_ShowConfig, TaskName_Status}
+
+func isReadOnly(taskNames []string) bool {
+	for _, name := range taskNames {
+		if !commons.IsStringAmong(name, readOnlyTasks) {
+			return false
+		}
+	}
+	return true
+}
 
 func NotImplementedYetHandler(ctx task.Context) task.HandlerFunc {
 	return func (job task.Job) ([]task.Job, error) {
@@ -18,38 +42,67 @@ func NotImplementedYetHandler(ctx task.Context) task.HandlerFunc {
 	}
 }
 
-func CreateTasks() task.TaskSequence {
+// ModeHandler passes on all jobs - used by tasks which only switch the mode of operation (e.g. watch, serve)
+func ModeHandler(ctx task.Context) task.HandlerFunc {
+	return func (job task.Job) ([]task.Job, error) {
+		return []task.Job{job}, nil
+	}
+}
+
+func ShowConfigHandler(ctx task.Context) task.HandlerFunc {
+	return func (job task.Job) ([]task.Job, error) {
+		masked, err := ctx.Config.(*ripper.AppConf).Masked()
+		if err != nil {
+			return nil, err
+		}
+		ctx.Printf("effective config (secrets masked):\n%s\n", masked)
+		return []task.Job{job}, nil
+	}
+}
+
+func CreateTasks(resume bool, dryRun bool, rec *report.Recorder) task.TaskSequence {
 	taskTasks := task.NewTask(TaskName_Tasks,"show all available tasks and their dependencies", task.TasksOverviewHandler )
+	taskWatch := task.NewTask(TaskName_Watch,"monitor target folders and run the other tasks on new input files as they arrive (until terminated)", ModeHandler)
+	taskServe := task.NewTask(TaskName_Serve,"run HTTP control API to submit targets (with tasks to run) and query their processing state (until terminated)", ModeHandler)
+	taskShowConfig := task.NewTask(TaskName_ShowConfig,"show effective config merged from config file, profile file, and environment variables (secrets masked)", ShowConfigHandler)
+	taskStatus := task.NewTask(TaskName_Status,"show processing state of all targets recorded in the ledger, grouped by state", ledger.StatusHandler)
+
+	tracked := func(stage string, handler task.Handler, media ...string) task.Handler {
+		if dryRun {
+			return handler // dry-runs neither change the ledger, nor write reports
+		}
+		return ledger.Tracked(stage, resume, report.Timed(rec, stage, handler, media...), media...)
+	}
 
-	//taskScanAudio := task.NewTask("scanAudio","scan folder and direct sub-folders for audio input", NotImplementedYetHandler)
-	taskScanVideo := task.NewTask("scanVideo","scan folder and direct sub-folders for video input", scan.ScanVideo)
-	taskScan      := task.NewTask("scan","scan folder and direct sub-folders for audio and video input", nil).WithDependencies(/*taskScanAudio,*/ taskScanVideo)
+	taskScanAudio := task.NewTask("scanAudio","scan folder and direct sub-folders for audio input", tracked(ledger.STAGE_SCANNED, scan.ScanAudio))
+	taskScanVideo := task.NewTask("scanVideo","scan folder and direct sub-folders for video input", tracked(ledger.STAGE_SCANNED, scan.ScanVideo))
+	taskScan      := task.NewTask("scan","scan folder and direct sub-folders for audio and video input", nil).WithDependencies(taskScanAudio, taskScanVideo)
 
-	//taskResolveAudio := task.NewTask("resolveAudio","resolve & download audio meta-info from FreeDB", NotImplementedYetHandler )
-	taskResolveVideo := task.NewTask("resolveVideo","resolve & download video meta-info from IMDB", video.ResolveVideo)
-	taskResolve      := task.NewTask("resolve","resolve & download audio and video meta-info from various sources", nil).WithDependencies(taskScan, /*taskResolveAudio, */ taskResolveVideo)
+	taskResolveAudio := task.NewTask("resolveAudio","resolve & download audio meta-info from MusicBrainz", tracked(ledger.STAGE_RESOLVED, audio.ResolveAudio, ripper.MEDIA_AUDIO))
+	taskResolveVideo := task.NewTask("resolveVideo","resolve & download video meta-info from IMDB", tracked(ledger.STAGE_RESOLVED, video.ResolveVideo, ripper.MEDIA_VIDEO))
+	taskResolve      := task.NewTask("resolve","resolve & download audio and video meta-info from various sources", nil).WithDependencies(taskScan, taskResolveAudio, taskResolveVideo)
 
-	//taskRipAudio := task.NewTask("ripAudio","digitalize (\"rip\") audio", NotImplementedYetHandler)
-	taskRipVideo := task.NewTask("ripVideo","digitalize (\"rip\") video", rip.RipVideo)
-	taskRip      := task.NewTask("rip","digitalize (\"rip\") audio and video", nil).WithDependencies(taskResolve, /*taskRipAudio, */ taskRipVideo)
+	taskRipAudio := task.NewTask("ripAudio","digitalize (\"rip\") audio", tracked(ledger.STAGE_RIPPED, rip.RipAudio, ripper.MEDIA_AUDIO))
+	taskRipVideo := task.NewTask("ripVideo","digitalize (\"rip\") video", tracked(ledger.STAGE_RIPPED, rip.RipVideo, ripper.MEDIA_VIDEO))
+	taskRip      := task.NewTask("rip","digitalize (\"rip\") audio and video", nil).WithDependencies(taskResolve, taskRipAudio, taskRipVideo)
 
-	//taskTagAudio := task.NewTask("tagAudio","apply meta-info from local file to audio", NotImplementedYetHandler)
-	taskTagVideo := task.NewTask("tagVideo","apply meta-info from local file to video", tag.TagVideo)
-	taskTag      := task.NewTask("tag","apply meta-info from local file to audio and video", nil).WithDependencies(taskRip, /* taskTagAudio, */ taskTagVideo)
+	taskTagAudio := task.NewTask("tagAudio","apply meta-info from local file to audio", tracked(ledger.STAGE_TAGGED, tag.TagAudio, ripper.MEDIA_AUDIO))
+	taskTagVideo := task.NewTask("tagVideo","apply meta-info from local file to video", tracked(ledger.STAGE_TAGGED, tag.TagVideo, ripper.MEDIA_VIDEO))
+	taskTag      := task.NewTask("tag","apply meta-info from local file to audio and video", nil).WithDependencies(taskRip, taskTagAudio, taskTagVideo)
 
-	//taskAudio  := task.NewTask("audio","process all audio files in folder and direct sub-folders", nil).WithDependencies(taskScanAudio, taskResolveAudio, taskRipAudio, taskTagAudio, taskRemoveOriginalAudio )
+	taskAudio  := task.NewTask("audio","process all audio files in folder and direct sub-folders", nil).WithDependencies(taskScanAudio, taskResolveAudio, taskRipAudio, taskTagAudio)
 	taskVideo  := task.NewTask("video","process all video files in folder and direct sub-folders", nil).WithDependencies(taskScanVideo, taskResolveVideo, taskRipVideo, taskTagVideo)
 
 	taskClean := task.NewTask("clean","cleans all processing artifacts related to a specific input file from work folder", clean.CleanHandler)
-	taskRemoveOriginal := task.NewTask("removeOriginal", "deletes original input file", NotImplementedYetHandler)
+	taskRemoveOriginal := task.NewTask("removeOriginal", "deletes original input file (or moves it to trash) after checking the tagged output", tracked(ledger.STAGE_REMOVED, remove.RemoveOriginal))
 
 
 	return task.LoadTasks(
-		taskTasks,
-		/* taskScanAudio, */ taskScanVideo, taskScan,
-		/* taskResolveAudio, */ taskResolveVideo, taskResolve,
-		/* taskRipAudio, */ taskRipVideo, taskRip,
-		/* taskTagAudio, */ taskTagVideo, taskTag,
+		taskTasks, taskShowConfig, taskWatch, taskServe, taskStatus,
+		taskScanAudio, taskScanVideo, taskScan,
+		taskResolveAudio, taskResolveVideo, taskResolve,
+		taskRipAudio, taskRipVideo, taskRip,
+		taskTagAudio, taskTagVideo, taskTag,
 		taskClean, taskRemoveOriginal,
-		/* taskAudio, */ taskVideo)
+		taskAudio, taskVideo)
 }
@@ -0,0 +1,317 @@
+package tmdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-ripper/metainfo/video"
+)
+
+// max. # of actors in the details of videos - tmdb lists the complete cast
+const maxActors = 5
+
+// region used for age ratings, if the configured language has no region
+const defaultRegion = "US"
+
+type named struct {
+	Name string `json:"name"`
+}
+
+type language struct {
+	Name        string `json:"name"`
+	EnglishName string `json:"english_name"`
+}
+
+type credits struct {
+	Cast []named `json:"cast"`
+	Crew []struct {
+		Name       string `json:"name"`
+		Job        string `json:"job"`
+		Department string `json:"department"`
+	} `json:"crew"`
+	GuestStars []named `json:"guest_stars"`
+}
+
+type findResponse struct {
+	MovieResults []struct {
+		Id int `json:"id"`
+	} `json:"movie_results"`
+	TvResults []struct {
+		Id int `json:"id"`
+	} `json:"tv_results"`
+}
+
+type configurationResponse struct {
+	Images struct {
+		SecureBaseUrl string   `json:"secure_base_url"`
+		PosterSizes   []string `json:"poster_sizes"`
+	} `json:"images"`
+}
+
+type movieResponse struct {
+	Title               string     `json:"title"`
+	ReleaseDate         string     `json:"release_date"`
+	PosterPath          string     `json:"poster_path"`
+	Overview            string     `json:"overview"`
+	Runtime             int        `json:"runtime"`
+	Genres              []named    `json:"genres"`
+	SpokenLanguages     []language `json:"spoken_languages"`
+	ProductionCountries []named    `json:"production_countries"`
+	Credits             credits    `json:"credits"`
+	ReleaseDates        struct {
+		Results []struct {
+			Region       string `json:"iso_3166_1"`
+			ReleaseDates []struct {
+				Certification string `json:"certification"`
+			} `json:"release_dates"`
+		} `json:"results"`
+	} `json:"release_dates"`
+}
+
+type tvResponse struct {
+	Name                string     `json:"name"`
+	FirstAirDate        string     `json:"first_air_date"`
+	PosterPath          string     `json:"poster_path"`
+	Overview            string     `json:"overview"`
+	NumberOfSeasons     int        `json:"number_of_seasons"`
+	EpisodeRunTime      []int      `json:"episode_run_time"`
+	Genres              []named    `json:"genres"`
+	CreatedBy           []named    `json:"created_by"`
+	SpokenLanguages     []language `json:"spoken_languages"`
+	ProductionCountries []named    `json:"production_countries"`
+	Credits             credits    `json:"credits"`
+	ContentRatings      struct {
+		Results []struct {
+			Region string `json:"iso_3166_1"`
+			Rating string `json:"rating"`
+		} `json:"results"`
+	} `json:"content_ratings"`
+}
+
+type seasonResponse struct {
+	SeasonNumber int    `json:"season_number"`
+	PosterPath   string `json:"poster_path"`
+	Episodes     []struct {
+		EpisodeNumber int    `json:"episode_number"`
+		Name          string `json:"name"`
+	} `json:"episodes"`
+}
+
+type episodeResponse struct {
+	Name          string  `json:"name"`
+	AirDate       string  `json:"air_date"`
+	Overview      string  `json:"overview"`
+	Runtime       int     `json:"runtime"`
+	SeasonNumber  int     `json:"season_number"`
+	EpisodeNumber int     `json:"episode_number"`
+	Credits       credits `json:"credits"`
+	ExternalIds   struct {
+		ImdbId string `json:"imdb_id"`
+	} `json:"external_ids"`
+}
+
+type searchResponse struct {
+	Results []struct {
+		Id           int    `json:"id"`
+		Title        string `json:"title"`
+		Name         string `json:"name"`
+		ReleaseDate  string `json:"release_date"`
+		FirstAirDate string `json:"first_air_date"`
+	} `json:"results"`
+}
+
+type externalIdsResponse struct {
+	ImdbId string `json:"imdb_id"`
+}
+
+// toMovieMetaInfo maps movie details - the id remains the IMDb id used to look up the movie
+func toMovieMetaInfo(raw []byte, id string, posterUrl func(string) string, region string) (*video.MovieMetaInfo, error) {
+	rsp := movieResponse{}
+	if err := json.Unmarshal(raw, &rsp); err != nil {
+		return nil, err
+	}
+	if len(rsp.Title) == 0 {
+		return nil, fmt.Errorf("mapping tmdb-response to movie meta-info failed: title of %s is missing", id)
+	}
+	movie := &video.MovieMetaInfo{Title: rsp.Title, Year: yearOf(rsp.ReleaseDate), Poster: posterUrl(rsp.PosterPath)}
+	movie.Id = id
+	movie.Details = video.Details{
+		Plot:      rsp.Overview,
+		Genres:    names(rsp.Genres),
+		Actors:    actors(rsp.Credits.Cast),
+		Directors: crew(rsp.Credits, isDirector),
+		Writers:   crew(rsp.Credits, isWriter),
+		Runtime:   rsp.Runtime,
+		Languages: languages(rsp.SpokenLanguages),
+		Countries: names(rsp.ProductionCountries),
+	}
+	for _, r := range rsp.ReleaseDates.Results {
+		for _, d := range r.ReleaseDates {
+			if len(d.Certification) > 0 {
+				movie.Rated = ratingFor(movie.Rated, r.Region, d.Certification, region)
+			}
+		}
+	}
+	return movie, nil
+}
+
+func toSeriesMetaInfo(raw []byte, id string, posterUrl func(string) string, region string) (*video.SeriesMetaInfo, error) {
+	rsp := tvResponse{}
+	if err := json.Unmarshal(raw, &rsp); err != nil {
+		return nil, err
+	}
+	if len(rsp.Name) == 0 {
+		return nil, fmt.Errorf("mapping tmdb-response to series meta-info failed: name of %s is missing", id)
+	}
+	series := &video.SeriesMetaInfo{Title: rsp.Name, Year: yearOf(rsp.FirstAirDate), Seasons: rsp.NumberOfSeasons, Poster: posterUrl(rsp.PosterPath)}
+	series.Id = id
+	series.Details = video.Details{
+		Plot:      rsp.Overview,
+		Genres:    names(rsp.Genres),
+		Actors:    actors(rsp.Credits.Cast),
+		Writers:   names(rsp.CreatedBy),
+		Languages: languages(rsp.SpokenLanguages),
+		Countries: names(rsp.ProductionCountries),
+	}
+	if len(rsp.EpisodeRunTime) > 0 {
+		series.Runtime = rsp.EpisodeRunTime[0]
+	}
+	for _, r := range rsp.ContentRatings.Results {
+		if len(r.Rating) > 0 {
+			series.Rated = ratingFor(series.Rated, r.Region, r.Rating, region)
+		}
+	}
+	return series, nil
+}
+
+func toSeasonMetaInfo(raw []byte, id string, posterUrl func(string) string) (*video.SeasonMetaInfo, error) {
+	rsp := seasonResponse{}
+	if err := json.Unmarshal(raw, &rsp); err != nil {
+		return nil, err
+	}
+	season := &video.SeasonMetaInfo{Season: rsp.SeasonNumber, Poster: posterUrl(rsp.PosterPath), Episodes: []*video.SeasonEpisode{}}
+	season.Id = id
+	for _, e := range rsp.Episodes {
+		season.Episodes = append(season.Episodes, &video.SeasonEpisode{Episode: e.EpisodeNumber, Title: e.Name})
+	}
+	return season, nil
+}
+
+// toEpisodeMetaInfo maps episode details - episodes without IMDb id get the id of their series
+func toEpisodeMetaInfo(raw []byte, seriesId string) (*video.EpisodeMetaInfo, error) {
+	rsp := episodeResponse{}
+	if err := json.Unmarshal(raw, &rsp); err != nil {
+		return nil, err
+	}
+	episode := &video.EpisodeMetaInfo{Title: rsp.Name, Year: yearOf(rsp.AirDate), Season: rsp.SeasonNumber, Episode: rsp.EpisodeNumber}
+	episode.Id = rsp.ExternalIds.ImdbId
+	if len(episode.Id) == 0 {
+		episode.Id = seriesId
+	}
+	episode.Details = video.Details{
+		Plot:      rsp.Overview,
+		Actors:    actors(append(rsp.Credits.Cast, rsp.Credits.GuestStars...)),
+		Directors: crew(rsp.Credits, isDirector),
+		Writers:   crew(rsp.Credits, isWriter),
+		Runtime:   rsp.Runtime,
+	}
+	return episode, nil
+}
+
+func yearOf(date string) string {
+	if len(date) < 4 {
+		return ""
+	}
+	return date[:4]
+}
+
+// ratingFor prefers the age rating of the region of the configured language, then the rating of the default region
+func ratingFor(current string, region string, rating string, preferredRegion string) string {
+	switch {
+	case region == preferredRegion:
+		return rating
+	case region == defaultRegion && len(current) == 0:
+		return rating
+	default:
+		return current
+	}
+}
+
+// regionOf returns the region of a language (e.g. "DE" for "de-DE")
+func regionOf(language string) string {
+	if parts := strings.Split(language, "-"); len(parts) == 2 {
+		return strings.ToUpper(parts[1])
+	}
+	return defaultRegion
+}
+
+func names(named []named) []string {
+	result := []string{}
+	for _, n := range named {
+		if len(n.Name) > 0 && !commons.IsStringAmong(n.Name, result) {
+			result = append(result, n.Name)
+		}
+	}
+	return result
+}
+
+func actors(cast []named) []string {
+	result := names(cast)
+	if len(result) > maxActors {
+		return result[:maxActors]
+	}
+	return result
+}
+
+func isDirector(job string, department string) bool {
+	return job == "Director"
+}
+
+func isWriter(job string, department string) bool {
+	return department == "Writing"
+}
+
+func crew(c credits, matches func(job string, department string) bool) []string {
+	result := []string{}
+	for _, member := range c.Crew {
+		if matches(member.Job, member.Department) && !commons.IsStringAmong(member.Name, result) {
+			result = append(result, member.Name)
+		}
+	}
+	return result
+}
+
+func languages(spoken []language) []string {
+	result := []string{}
+	for _, l := range spoken {
+		name := l.EnglishName
+		if len(name) == 0 {
+			name = l.Name
+		}
+		if len(name) > 0 {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// toSearchResults maps search results of movies or series - the results still lack the IMDb ids
+func toSearchResults(raw []byte) ([]int, []*video.VideoSearchResult, error) {
+	rsp := searchResponse{}
+	if err := json.Unmarshal(raw, &rsp); err != nil {
+		return nil, nil, err
+	}
+	tmdbIds := []int{}
+	results := []*video.VideoSearchResult{}
+	for _, r := range rsp.Results {
+		title, date := r.Title, r.ReleaseDate
+		if len(title) == 0 {
+			title, date = r.Name, r.FirstAirDate
+		}
+		tmdbIds = append(tmdbIds, r.Id)
+		results = append(results, &video.VideoSearchResult{Title: title, Year: yearOf(date)})
+	}
+	return tmdbIds, results, nil
+}
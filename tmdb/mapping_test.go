@@ -0,0 +1,34 @@
+package tmdb
+
+import (
+	"testing"
+
+	"github.com/thomasschoeftner/go-cli/test"
+)
+
+func TestRegionOf(t *testing.T) {
+	assert := test.AssertOn(t)
+	assert.StringsEqual("DE", regionOf("de-DE"))
+	assert.StringsEqual("AT", regionOf("de-at"))
+	assert.StringsEqual(defaultRegion, regionOf("de"))
+	assert.StringsEqual(defaultRegion, regionOf(""))
+}
+
+func TestRatingFor(t *testing.T) {
+	assert := test.AssertOn(t)
+	assert.StringsEqual("PG-13", ratingFor("", "US", "PG-13", "DE"))
+	assert.StringsEqual("12", ratingFor("PG-13", "DE", "12", "DE"))
+	assert.StringsEqual("12", ratingFor("12", "US", "PG-13", "DE"))
+	assert.StringsEqual("", ratingFor("", "FR", "U", "DE"))
+}
+
+func TestToSearchResults(t *testing.T) {
+	assert := test.AssertOn(t)
+	ids, results, err := toSearchResults([]byte(`{"results": [{"id": 1399, "name": "Game of Thrones", "first_air_date": "2011-04-17"}, {"id": 2, "name": "Unaired"}]}`))
+	assert.NotError(err)
+	assert.IntsEqual(2, len(ids))
+	assert.IntsEqual(1399, ids[0])
+	assert.StringsEqual("Game of Thrones", results[0].Title)
+	assert.StringsEqual("2011", results[0].Year)
+	assert.StringsEqual("", results[1].Year)
+}
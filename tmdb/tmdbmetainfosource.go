@@ -0,0 +1,339 @@
+package tmdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/commons"
+	"github.com/thomasschoeftner/go-ripper/metainfo"
+	"github.com/thomasschoeftner/go-ripper/metainfo/video"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+const CONF_TMDB_RESOLVER = "tmdb"
+
+// poster size used if the configured size is not provided by tmdb
+const posterSizeOriginal = "original"
+
+// max. # of search results, whose IMDb ids are looked up
+const maxSearchResults = 10
+
+// initial delay before retrying requests rejected by tmdb's rate limit - doubled on every retry
+var rateLimitBackoff = time.Second
+
+const (
+	tmdb_kindMovie = "movie"
+	tmdb_kindTv    = "tv"
+)
+
+func NewTmdbVideoMetaInfoSource(conf *ripper.VideoResolveConfig) (video.VideoMetaInfoSource, error) {
+	if conf == nil || conf.Tmdb == nil {
+		return nil, errors.New("cannot initialize tmdb meta-info source without TmdbConfig")
+	}
+	if len(conf.Tmdb.ApiKey) == 0 && len(conf.Tmdb.AccessToken) == 0 {
+		return nil, errors.New("cannot initialize tmdb meta-info source without api key or access token")
+	}
+	if len(conf.Tmdb.ApiUrl) == 0 {
+		return nil, errors.New("cannot initialize tmdb meta-info source without api url")
+	}
+	httpClient := &http.Client{Timeout: time.Second * time.Duration(conf.Tmdb.Timeout)}
+	return &tmdbVideoMetaInfoSource{conf: conf.Tmdb, httpClient: httpClient, tmdbIds: map[string]int{}}, nil
+}
+
+type tmdbVideoMetaInfoSource struct {
+	conf       *ripper.TmdbConfig
+	httpClient *http.Client
+
+	lock         sync.Mutex
+	tmdbIds      map[string]int // tmdb ids by kind and IMDb id
+	imageBaseUrl string         // incl. poster size - fetched from tmdb's configuration on first use
+}
+
+func (tmdb *tmdbVideoMetaInfoSource) FetchMovieInfo(id string) (*video.MovieMetaInfo, error) {
+	tmdbId, err := tmdb.tmdbId(tmdb_kindMovie, id)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := tmdb.getWithRetries(fmt.Sprintf("/movie/%d", tmdbId), url.Values{"append_to_response": {"credits,release_dates"}})
+	if err != nil {
+		return nil, err
+	}
+	posterUrl, err := tmdb.posterUrl()
+	if err != nil {
+		return nil, err
+	}
+	return toMovieMetaInfo(raw, id, posterUrl, regionOf(tmdb.conf.Language))
+}
+
+func (tmdb *tmdbVideoMetaInfoSource) FetchSeriesInfo(id string) (*video.SeriesMetaInfo, error) {
+	tmdbId, err := tmdb.tmdbId(tmdb_kindTv, id)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := tmdb.getWithRetries(fmt.Sprintf("/tv/%d", tmdbId), url.Values{"append_to_response": {"credits,content_ratings"}})
+	if err != nil {
+		return nil, err
+	}
+	posterUrl, err := tmdb.posterUrl()
+	if err != nil {
+		return nil, err
+	}
+	return toSeriesMetaInfo(raw, id, posterUrl, regionOf(tmdb.conf.Language))
+}
+
+func (tmdb *tmdbVideoMetaInfoSource) FetchSeasonInfo(id string, season int) (*video.SeasonMetaInfo, error) {
+	tmdbId, err := tmdb.tmdbId(tmdb_kindTv, id)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := tmdb.getWithRetries(fmt.Sprintf("/tv/%d/season/%d", tmdbId, season), url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	posterUrl, err := tmdb.posterUrl()
+	if err != nil {
+		return nil, err
+	}
+	return toSeasonMetaInfo(raw, id, posterUrl)
+}
+
+func (tmdb *tmdbVideoMetaInfoSource) FetchEpisodeInfo(id string, season int, episode int) (*video.EpisodeMetaInfo, error) {
+	tmdbId, err := tmdb.tmdbId(tmdb_kindTv, id)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := tmdb.getWithRetries(fmt.Sprintf("/tv/%d/season/%d/episode/%d", tmdbId, season, episode), url.Values{"append_to_response": {"credits,external_ids"}})
+	if err != nil {
+		return nil, err
+	}
+	return toEpisodeMetaInfo(raw, id)
+}
+
+// SearchVideo looks up movies or series by title (and year, if known) - only results with IMDb id are returned
+func (tmdb *tmdbVideoMetaInfoSource) SearchVideo(kind string, title string, year string) ([]*video.VideoSearchResult, error) {
+	tmdbKind, yearParam := tmdb_kindMovie, "year"
+	if kind == video.SEARCH_KIND_SERIES {
+		tmdbKind, yearParam = tmdb_kindTv, "first_air_date_year"
+	}
+	params := url.Values{"query": {title}}
+	if len(year) > 0 {
+		params.Set(yearParam, year)
+	}
+	raw, err := tmdb.getWithRetries("/search/"+tmdbKind, params)
+	if err != nil {
+		return nil, err
+	}
+	tmdbIds, candidates, err := toSearchResults(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*video.VideoSearchResult{}
+	for i, candidate := range candidates {
+		if i == maxSearchResults {
+			break
+		}
+		raw, err := tmdb.getWithRetries(fmt.Sprintf("/%s/%d/external_ids", tmdbKind, tmdbIds[i]), url.Values{})
+		if err != nil {
+			continue // candidates without IMDb id cannot be resolved anyway
+		}
+		ids := externalIdsResponse{}
+		if err := json.Unmarshal(raw, &ids); err != nil {
+			continue
+		}
+		if len(ids.ImdbId) > 0 {
+			candidate.Id = ids.ImdbId
+			tmdb.rememberTmdbId(tmdbKind, ids.ImdbId, tmdbIds[i])
+			results = append(results, candidate)
+		}
+	}
+	return results, nil
+}
+
+func (tmdb *tmdbVideoMetaInfoSource) FetchImage(location string) (metainfo.Image, error) {
+	return tmdb.getUrlWithRetries(location, false)
+}
+
+// tmdbId looks up the tmdb id of a movie or tv show by its IMDb id - ids are looked up only once
+func (tmdb *tmdbVideoMetaInfoSource) tmdbId(kind string, imdbId string) (int, error) {
+	tmdb.lock.Lock()
+	tmdbId, known := tmdb.tmdbIds[kind+"/"+imdbId]
+	tmdb.lock.Unlock()
+	if known {
+		return tmdbId, nil
+	}
+
+	raw, err := tmdb.getWithRetries("/find/"+url.PathEscape(imdbId), url.Values{"external_source": {"imdb_id"}})
+	if err != nil {
+		return 0, err
+	}
+	rsp := findResponse{}
+	if err := json.Unmarshal(raw, &rsp); err != nil {
+		return 0, err
+	}
+	if kind == tmdb_kindMovie && len(rsp.MovieResults) > 0 {
+		tmdbId = rsp.MovieResults[0].Id
+	} else if kind == tmdb_kindTv && len(rsp.TvResults) > 0 {
+		tmdbId = rsp.TvResults[0].Id
+	} else {
+		return 0, &video.NotFound{Msg: fmt.Sprintf("no %s with IMDb id %s found on tmdb", kind, imdbId)}
+	}
+	tmdb.rememberTmdbId(kind, imdbId, tmdbId)
+	return tmdbId, nil
+}
+
+func (tmdb *tmdbVideoMetaInfoSource) rememberTmdbId(kind string, imdbId string, tmdbId int) {
+	tmdb.lock.Lock()
+	defer tmdb.lock.Unlock()
+	tmdb.tmdbIds[kind+"/"+imdbId] = tmdbId
+}
+
+// posterUrl returns a function building the urls of posters in the configured size from their tmdb paths
+func (tmdb *tmdbVideoMetaInfoSource) posterUrl() (func(string) string, error) {
+	tmdb.lock.Lock()
+	baseUrl := tmdb.imageBaseUrl
+	tmdb.lock.Unlock()
+	if len(baseUrl) == 0 {
+		raw, err := tmdb.getWithRetries("/configuration", url.Values{})
+		if err != nil {
+			return nil, err
+		}
+		rsp := configurationResponse{}
+		if err := json.Unmarshal(raw, &rsp); err != nil {
+			return nil, err
+		}
+		size := tmdb.conf.PosterSize
+		if !commons.IsStringAmong(size, rsp.Images.PosterSizes) {
+			size = posterSizeOriginal
+		}
+		baseUrl = strings.TrimSuffix(rsp.Images.SecureBaseUrl, "/") + "/" + size
+		tmdb.lock.Lock()
+		tmdb.imageBaseUrl = baseUrl
+		tmdb.lock.Unlock()
+	}
+	return func(path string) string {
+		if len(path) == 0 {
+			return ""
+		}
+		return baseUrl + path
+	}, nil
+}
+
+// getWithRetries queries a path of the tmdb api in the configured language
+func (tmdb *tmdbVideoMetaInfoSource) getWithRetries(path string, params url.Values) ([]byte, error) {
+	if len(tmdb.conf.Language) > 0 {
+		params.Set("language", tmdb.conf.Language)
+	}
+	if len(tmdb.conf.ApiKey) > 0 {
+		params.Set("api_key", tmdb.conf.ApiKey)
+	}
+	return tmdb.getUrlWithRetries(strings.TrimSuffix(tmdb.conf.ApiUrl, "/")+path+"?"+params.Encode(), true)
+}
+
+// getUrlWithRetries retries failed requests - except for unknown resources and invalid credentials, where retrying does not help.
+// Requests rejected by tmdb's rate limit are retried after the delay requested by tmdb, or after an increasing delay.
+func (tmdb *tmdbVideoMetaInfoSource) getUrlWithRetries(url string, authorize bool) ([]byte, error) {
+	var errs []error
+	var delay time.Duration
+	for i := 0; i <= tmdb.conf.Retries; i++ {
+		time.Sleep(delay)
+		raw, err := tmdb.get(url, authorize)
+		if err == nil {
+			return raw, nil
+		}
+		if _, isUnauthorized := err.(*unauthorized); isUnauthorized || video.IsNotFound(err) {
+			return nil, err
+		}
+		errs = append(errs, err)
+
+		delay = 0
+		if limited, isRateLimited := err.(*rateLimited); isRateLimited {
+			delay = limited.retryAfter
+			if delay == 0 {
+				delay = rateLimitBackoff << uint(i)
+			}
+		}
+	}
+
+	errMsg := fmt.Sprintf("unable to resolve meta-info after %d tries due to: \n", tmdb.conf.Retries+1)
+	for _, err := range errs {
+		errMsg = fmt.Sprintf("%s   -%s\n", errMsg, err.Error())
+	}
+	return nil, errors.New(errMsg)
+}
+
+func (tmdb *tmdbVideoMetaInfoSource) get(rawUrl string, authorize bool) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authorize && len(tmdb.conf.AccessToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+tmdb.conf.AccessToken)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	httpRsp, err := tmdb.httpClient.Do(req)
+	if uerr, isUrlErr := err.(*url.Error); isUrlErr {
+		return nil, fmt.Errorf("request to %s failed: %v", withoutApiKey(rawUrl), uerr.Err) // the url of the error contains the api key
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer httpRsp.Body.Close()
+
+	switch httpRsp.StatusCode {
+	case http.StatusOK:
+		return ioutil.ReadAll(httpRsp.Body)
+	case http.StatusUnauthorized:
+		return nil, &unauthorized{fmt.Sprintf("invalid tmdb api key or access token used for URL: %s", withoutApiKey(rawUrl))}
+	case http.StatusNotFound:
+		return nil, &video.NotFound{Msg: fmt.Sprintf("nothing found on tmdb at %s", withoutApiKey(rawUrl))}
+	case http.StatusTooManyRequests:
+		retryAfter, _ := strconv.Atoi(httpRsp.Header.Get("Retry-After"))
+		return nil, &rateLimited{fmt.Sprintf("too many requests when getting %s", withoutApiKey(rawUrl)), time.Duration(retryAfter) * time.Second}
+	default:
+		return nil, fmt.Errorf("received unexpected response code %d when getting %s", httpRsp.StatusCode, withoutApiKey(rawUrl))
+	}
+}
+
+// unauthorized is returned for invalid api keys or access tokens
+type unauthorized struct {
+	msg string
+}
+
+func (u *unauthorized) Error() string {
+	return u.msg
+}
+
+// rateLimited is returned for requests rejected by tmdb's rate limit - retryAfter is 0 if tmdb does not request a delay
+type rateLimited struct {
+	msg        string
+	retryAfter time.Duration
+}
+
+func (r *rateLimited) Error() string {
+	return r.msg
+}
+
+// withoutApiKey removes the api key from urls shown in errors
+func withoutApiKey(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	q := u.Query()
+	if q.Get("api_key") == "" {
+		return rawUrl
+	}
+	q.Set("api_key", "***")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
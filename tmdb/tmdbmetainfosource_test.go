@@ -0,0 +1,305 @@
+package tmdb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/metainfo/video"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+)
+
+const apiKey = "secret"
+
+func conf(apiUrl string) *ripper.VideoResolveConfig {
+	return &ripper.VideoResolveConfig{
+		Resolver: CONF_TMDB_RESOLVER,
+		Tmdb: &ripper.TmdbConfig{
+			Timeout:    1,
+			Retries:    1,
+			ApiUrl:     apiUrl,
+			ApiKey:     apiKey,
+			Language:   "de-DE",
+			PosterSize: "w780"},
+	}
+}
+
+// fakeTmdb serves localized responses for the configured language only
+func fakeTmdb(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/images/") {
+			if r.URL.Path == "/images/w780/dark-knight.jpg" {
+				w.Write([]byte{1, 2, 3})
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			return
+		}
+		if r.URL.Query().Get("api_key") != apiKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		german := r.URL.Query().Get("language") == "de-DE"
+		switch r.URL.Path {
+		case "/configuration":
+			fmt.Fprintf(w, `{"images": {"secure_base_url": "%s/images/", "poster_sizes": ["w92", "w780", "original"]}}`, server.URL)
+		case "/find/tt0468569":
+			fmt.Fprint(w, `{"movie_results": [{"id": 155}], "tv_results": []}`)
+		case "/find/tt0944947":
+			fmt.Fprint(w, `{"movie_results": [], "tv_results": [{"id": 1399}]}`)
+		case "/find/tt0000000":
+			fmt.Fprint(w, `{"movie_results": [], "tv_results": []}`)
+		case "/movie/155":
+			title := "The Dark Knight"
+			if german {
+				title = "The Dark Knight (German)"
+			}
+			fmt.Fprintf(w, movieJson, title)
+		case "/tv/1399":
+			fmt.Fprint(w, tvJson)
+		case "/tv/1399/season/1":
+			fmt.Fprint(w, `{"season_number": 1, "poster_path": "/got-s1.jpg", "episodes": [{"episode_number": 1, "name": "Der Winter naht"}, {"episode_number": 2, "name": "Der Königsweg"}]}`)
+		case "/tv/1399/season/1/episode/1":
+			fmt.Fprint(w, episodeJson)
+		case "/search/movie":
+			if r.URL.Query().Get("query") != "the dark knight" || r.URL.Query().Get("year") != "2008" {
+				fmt.Fprint(w, `{"results": []}`)
+				return
+			}
+			fmt.Fprint(w, `{"results": [{"id": 155, "title": "The Dark Knight", "release_date": "2008-07-16"}, {"id": 999, "title": "The Dark Knight Fan Cut", "release_date": "2008-12-24"}, {"id": 777, "title": "The Dark Knight Returns", "release_date": "2008-01-01"}]}`)
+		case "/movie/155/external_ids":
+			fmt.Fprint(w, `{"imdb_id": "tt0468569"}`)
+		case "/movie/999/external_ids":
+			fmt.Fprint(w, `{"imdb_id": null}`)
+		case "/movie/777/external_ids":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			t.Logf("unexpected request %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+const movieJson = `{
+	"title": "%s", "release_date": "2008-07-16", "poster_path": "/dark-knight.jpg", "overview": "Batman nimmt den Kampf gegen den Joker auf.", "runtime": 152,
+	"genres": [{"name": "Drama"}, {"name": "Action"}],
+	"spoken_languages": [{"english_name": "English", "name": "English"}, {"english_name": "Mandarin", "name": "普通话"}],
+	"production_countries": [{"name": "United States of America"}],
+	"credits": {
+		"cast": [{"name": "Christian Bale"}, {"name": "Heath Ledger"}, {"name": "Aaron Eckhart"}, {"name": "Michael Caine"}, {"name": "Maggie Gyllenhaal"}, {"name": "Gary Oldman"}],
+		"crew": [{"name": "Christopher Nolan", "job": "Director", "department": "Directing"}, {"name": "Jonathan Nolan", "job": "Screenplay", "department": "Writing"},
+			{"name": "Christopher Nolan", "job": "Screenplay", "department": "Writing"}, {"name": "Hans Zimmer", "job": "Original Music Composer", "department": "Sound"}]},
+	"release_dates": {"results": [{"iso_3166_1": "US", "release_dates": [{"certification": "PG-13"}]}, {"iso_3166_1": "DE", "release_dates": [{"certification": ""}, {"certification": "16"}]}]}
+}`
+
+const tvJson = `{
+	"name": "Game of Thrones", "first_air_date": "2011-04-17", "poster_path": "/got.jpg", "overview": "Sieben Königreiche...", "number_of_seasons": 8, "episode_run_time": [60],
+	"genres": [{"name": "Sci-Fi & Fantasy"}, {"name": "Drama"}], "created_by": [{"name": "David Benioff"}, {"name": "D. B. Weiss"}],
+	"credits": {"cast": [{"name": "Emilia Clarke"}]},
+	"content_ratings": {"results": [{"iso_3166_1": "US", "rating": "TV-MA"}]}
+}`
+
+const episodeJson = `{
+	"name": "Der Winter naht", "air_date": "2011-04-17", "overview": "Lord Stark...", "runtime": 62, "season_number": 1, "episode_number": 1,
+	"credits": {"cast": [{"name": "Emilia Clarke"}], "guest_stars": [{"name": "Jason Momoa"}], "crew": [{"name": "Tim Van Patten", "job": "Director", "department": "Directing"}]},
+	"external_ids": {"imdb_id": "tt1480055"}
+}`
+
+func TestNewTmdbVideoMetaInfoSource(t *testing.T) {
+	t.Run("nil config", func(t *testing.T) {
+		_, err := NewTmdbVideoMetaInfoSource(nil)
+		test.AssertOn(t).ExpectError("expected error for nil config, but got none")(err)
+	})
+
+	t.Run("missing api key and access token", func(t *testing.T) {
+		c := conf("http://localhost")
+		c.Tmdb.ApiKey = ""
+		_, err := NewTmdbVideoMetaInfoSource(c)
+		test.AssertOn(t).ExpectError("expected error for missing credentials, but got none")(err)
+	})
+}
+
+func TestFetchMovieInfo(t *testing.T) {
+	server := fakeTmdb(t)
+	defer server.Close()
+	assert := test.AssertOn(t)
+	src, err := NewTmdbVideoMetaInfoSource(conf(server.URL))
+	assert.NotError(err)
+
+	movie, err := src.FetchMovieInfo("tt0468569")
+	assert.NotError(err)
+	assert.StringsEqual("tt0468569", movie.Id)
+	assert.StringsEqual("The Dark Knight (German)", movie.Title)
+	assert.StringsEqual("2008", movie.Year)
+	assert.StringsEqual(server.URL+"/images/w780/dark-knight.jpg", movie.Poster)
+	assert.StringsEqual("Batman nimmt den Kampf gegen den Joker auf.", movie.Plot)
+	assert.StringSlicesEqual([]string{"Drama", "Action"}, movie.Genres)
+	assert.IntsEqual(maxActors, len(movie.Actors))
+	assert.StringSlicesEqual([]string{"Christopher Nolan"}, movie.Directors)
+	assert.StringSlicesEqual([]string{"Jonathan Nolan", "Christopher Nolan"}, movie.Writers)
+	assert.StringSlicesEqual([]string{"English", "Mandarin"}, movie.Languages)
+	assert.StringsEqual("16", movie.Rated) // rating of the configured language's region
+	assert.IntsEqual(152, movie.Runtime)
+
+	img, err := src.FetchImage(movie.Poster)
+	assert.NotError(err)
+	assert.IntsEqual(3, len(img))
+
+	_, err = src.FetchMovieInfo("tt0000000")
+	assert.ExpectError("expected error for unknown IMDb id, but got none")(err)
+	_, err = src.FetchMovieInfo("tt0944947")
+	assert.ExpectError("expected error when fetching tv show as movie, but got none")(err)
+}
+
+func TestFetchSeriesInfo(t *testing.T) {
+	server := fakeTmdb(t)
+	defer server.Close()
+	assert := test.AssertOn(t)
+	src, err := NewTmdbVideoMetaInfoSource(conf(server.URL))
+	assert.NotError(err)
+
+	series, err := src.FetchSeriesInfo("tt0944947")
+	assert.NotError(err)
+	assert.StringsEqual("tt0944947", series.Id)
+	assert.StringsEqual("Game of Thrones", series.Title)
+	assert.StringsEqual("2011", series.Year)
+	assert.IntsEqual(8, series.Seasons)
+	assert.StringsEqual(server.URL+"/images/w780/got.jpg", series.Poster)
+	assert.StringSlicesEqual([]string{"David Benioff", "D. B. Weiss"}, series.Writers)
+	assert.StringsEqual("TV-MA", series.Rated) // no rating of the configured language's region
+	assert.IntsEqual(60, series.Runtime)
+
+	season, err := src.FetchSeasonInfo("tt0944947", 1)
+	assert.NotError(err)
+	assert.StringsEqual("tt0944947", season.Id)
+	assert.IntsEqual(1, season.Season)
+	assert.IntsEqual(2, season.EpisodeCount())
+	assert.StringsEqual(server.URL+"/images/w780/got-s1.jpg", season.Poster)
+
+	episode, err := src.FetchEpisodeInfo("tt0944947", 1, 1)
+	assert.NotError(err)
+	assert.StringsEqual("tt1480055", episode.Id)
+	assert.StringsEqual("Der Winter naht", episode.Title)
+	assert.StringsEqual("2011", episode.Year)
+	assert.IntsEqual(1, episode.Season)
+	assert.IntsEqual(1, episode.Episode)
+	assert.StringSlicesEqual([]string{"Emilia Clarke", "Jason Momoa"}, episode.Actors)
+	assert.StringSlicesEqual([]string{"Tim Van Patten"}, episode.Directors)
+
+	_, err = src.FetchEpisodeInfo("tt0944947", 1, 99)
+	assert.ExpectError("expected error for unknown episode, but got none")(err)
+}
+
+func TestSearchVideo(t *testing.T) {
+	server := fakeTmdb(t)
+	defer server.Close()
+	assert := test.AssertOn(t)
+	src, err := NewTmdbVideoMetaInfoSource(conf(server.URL))
+	assert.NotError(err)
+
+	results, err := src.SearchVideo(video.SEARCH_KIND_MOVIE, "the dark knight", "2008")
+	assert.NotError(err)
+	assert.IntsEqual(1, len(results)) // results without IMDb id or failing to provide it are dropped
+	assert.StringsEqual("tt0468569", results[0].Id)
+	assert.StringsEqual("The Dark Knight", results[0].Title)
+	assert.StringsEqual("2008", results[0].Year)
+
+	results, err = src.SearchVideo(video.SEARCH_KIND_MOVIE, "unknown", "")
+	assert.NotError(err)
+	assert.IntsEqual(0, len(results))
+}
+
+func TestPosterSize(t *testing.T) {
+	server := fakeTmdb(t)
+	defer server.Close()
+	assert := test.AssertOn(t)
+	c := conf(server.URL)
+	c.Tmdb.PosterSize = "w1000"
+	src, err := NewTmdbVideoMetaInfoSource(c)
+	assert.NotError(err)
+
+	movie, err := src.FetchMovieInfo("tt0468569")
+	assert.NotError(err)
+	assert.StringsEqual(server.URL+"/images/original/dark-knight.jpg", movie.Poster)
+}
+
+func TestInvalidApiKey(t *testing.T) {
+	server := fakeTmdb(t)
+	defer server.Close()
+	assert := test.AssertOn(t)
+	c := conf(server.URL)
+	c.Tmdb.ApiKey = "wrong"
+	src, err := NewTmdbVideoMetaInfoSource(c)
+	assert.NotError(err)
+
+	_, err = src.FetchMovieInfo("tt0468569")
+	assert.ExpectError("expected error for invalid api key, but got none")(err)
+	assert.False("expected api key not to be shown in errors")(strings.Contains(err.Error(), "wrong"))
+}
+
+func TestRetries(t *testing.T) {
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/unknown":
+			w.WriteHeader(http.StatusNotFound)
+		case "/unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/failing":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/rate-limited":
+			if requests[r.URL.Path] == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+	rateLimitBackoff = time.Millisecond
+	defer func() { rateLimitBackoff = time.Second }()
+
+	assert := test.AssertOn(t)
+	c := conf(server.URL)
+	c.Tmdb.Retries = 2
+	src, err := NewTmdbVideoMetaInfoSource(c)
+	assert.NotError(err)
+	tmdb := src.(*tmdbVideoMetaInfoSource)
+
+	_, err = tmdb.getWithRetries("/unknown", url.Values{})
+	assert.True("expected not found error")(video.IsNotFound(err))
+	assert.IntsEqual(1, requests["/unknown"])
+
+	_, err = tmdb.getWithRetries("/unauthorized", url.Values{})
+	assert.ExpectError("expected error for invalid api key")(err)
+	assert.IntsEqual(1, requests["/unauthorized"])
+
+	_, err = tmdb.getWithRetries("/failing", url.Values{})
+	assert.ExpectError("expected error after all retries")(err)
+	assert.IntsEqual(3, requests["/failing"])
+
+	_, err = tmdb.getWithRetries("/rate-limited", url.Values{})
+	assert.NotError(err)
+	assert.IntsEqual(2, requests["/rate-limited"])
+}
+
+func TestUnreachableApiUrl(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	unreachable := server.URL
+	server.Close() // nothing listens at the url anymore
+	assert := test.AssertOn(t)
+	src, err := NewTmdbVideoMetaInfoSource(conf(unreachable))
+	assert.NotError(err)
+
+	_, err = src.FetchMovieInfo("tt0468569")
+	assert.ExpectError("expected error for unreachable api url, but got none")(err)
+	assert.False("expected api key not to be shown in errors")(strings.Contains(err.Error(), apiKey))
+}
@@ -0,0 +1,223 @@
+package watch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/scan"
+)
+
+type fileState struct {
+	size        int64
+	modTime     time.Time
+	stableSince time.Time
+	submitted   bool
+	held        bool // stable part of a multi-part movie waiting for its other parts
+}
+
+// Watcher polls folders for new input files and reports each file once it was not modified for a while
+type Watcher struct {
+	folders      []string
+	ignorePrefix string
+	extensions   []string
+	discs        bool
+	partPattern  *regexp.Regexp
+	stableFor    time.Duration
+	Interval     time.Duration
+	files        map[string]*fileState
+}
+
+func NewWatcher(conf *ripper.AppConf, folders []string) (*Watcher, error) {
+	if conf.Watch == nil {
+		return nil, errors.New("watching folders is not configured")
+	}
+	stableFor, err := time.ParseDuration(conf.Watch.StableFor)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := time.ParseDuration(conf.Watch.Interval)
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := []string{}
+	discs := false
+	if conf.Scan != nil {
+		for _, scanConf := range []*ripper.ScanConfig{conf.Scan.Video, conf.Scan.Audio} {
+			if scanConf != nil {
+				extensions = append(extensions, scanConf.AllowedExtensions...)
+				discs = discs || scanConf.Discs
+			}
+		}
+	}
+	if discs {
+		extensions = append(extensions, files.DISC_IMAGE_EXTENSION)
+	}
+	var partPattern *regexp.Regexp
+	if conf.Scan != nil {
+		if partPattern, err = scan.CompilePartPattern(conf.Scan.Video); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Watcher{
+		folders:      folders,
+		ignorePrefix: conf.IgnorePrefix,
+		extensions:   extensions,
+		discs:        discs,
+		partPattern:  partPattern,
+		stableFor:    stableFor,
+		Interval:     interval,
+		files:        map[string]*fileState{}}, nil
+}
+
+// Poll returns all files which became stable since the previous poll.
+// Files are returned again if they are modified after being returned.
+// Multi-part movies are returned once all of their parts are stable - by their first part only, as scanning a part includes all other parts.
+func (w *Watcher) Poll(now time.Time) ([]string, error) {
+	present := map[string]bool{}
+	stable := []string{}
+
+	for _, folder := range w.folders {
+		// ignore files are read on every poll, as they may change while watching
+		ignore := scan.NewIgnoreRules(w.ignorePrefix, w.extensions)
+		if err := ignore.LoadAncestors(folder); err != nil {
+			return nil, err
+		}
+		err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil // file was removed while walking
+				}
+				return err
+			}
+			if isIgnored(ignore, folder, path, info) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() && w.discs && files.IsDiscFolder(path) {
+				// disc folders are reported as a whole, once none of their files changes anymore
+				size, modTime, err := discState(path)
+				if err != nil {
+					return err
+				}
+				present[path] = true
+				if w.isStable(path, size, modTime, now) {
+					stable = append(stable, path)
+				}
+				return filepath.SkipDir
+			}
+			if info.IsDir() {
+				return ignore.Load(path)
+			}
+
+			present[path] = true
+			if w.isStable(path, info.Size(), info.ModTime(), now) {
+				stable = append(stable, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// forget removed files
+	for path := range w.files {
+		if !present[path] {
+			delete(w.files, path)
+		}
+	}
+	return w.groupParts(stable), nil
+}
+
+// groupParts holds back stable parts of multi-part movies until all parts in the same folder are stable
+func (w *Watcher) groupParts(stable []string) []string {
+	if w.partPattern == nil {
+		return stable
+	}
+	result, movieResults := []string{}, []string{}
+	for _, path := range stable {
+		if _, isPart := w.partKey(path); isPart {
+			w.files[path].held = true
+		} else {
+			result = append(result, path)
+		}
+	}
+
+	movies := map[string][]string{}
+	for path := range w.files {
+		if key, isPart := w.partKey(path); isPart {
+			movies[key] = append(movies[key], path)
+		}
+	}
+	for _, parts := range movies {
+		complete, held := true, false
+		for _, part := range parts {
+			complete = complete && w.files[part].submitted
+			held = held || w.files[part].held
+		}
+		if complete && held {
+			sort.Strings(parts)
+			movieResults = append(movieResults, parts[0])
+			for _, part := range parts {
+				w.files[part].held = false
+			}
+		}
+	}
+	sort.Strings(movieResults)
+	return append(result, movieResults...)
+}
+
+// partKey identifies the multi-part movie a file belongs to - by folder and base name of all parts
+func (w *Watcher) partKey(path string) (string, bool) {
+	base, part := scan.SplitPart(filepath.Base(path), w.partPattern)
+	return filepath.Join(filepath.Dir(path), base), part != nil
+}
+
+// isIgnored applies the same rules as scanning - so no files are submitted, which are skipped by the scan anyway
+func isIgnored(ignore *scan.IgnoreRules, root string, path string, info os.FileInfo) bool {
+	if path == root {
+		return false
+	}
+	if info.IsDir() {
+		return len(ignore.FolderReason(path)) > 0
+	}
+	return len(ignore.FileReason(path)) > 0
+}
+
+func (w *Watcher) isStable(path string, size int64, modTime time.Time, now time.Time) bool {
+	state := w.files[path]
+	if state == nil || state.size != size || !state.modTime.Equal(modTime) {
+		w.files[path] = &fileState{size: size, modTime: modTime, stableSince: now}
+		return false
+	}
+	if state.submitted || now.Sub(state.stableSince) < w.stableFor {
+		return false
+	}
+	state.submitted = true
+	return true
+}
+
+// discState returns the total size and latest modification of all files within a disc folder
+func discState(folder string) (size int64, modTime time.Time, err error) {
+	err = filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+		return nil
+	})
+	return size, modTime, err
+}
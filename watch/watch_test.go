@@ -0,0 +1,137 @@
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/thomasschoeftner/go-cli/test"
+	"github.com/thomasschoeftner/go-ripper/files"
+	"github.com/thomasschoeftner/go-ripper/ripper"
+	"github.com/thomasschoeftner/go-ripper/scan"
+)
+
+func writeFile(t *testing.T, path string, size int) {
+	test.CheckError(t, files.CreateFolderStructure(filepath.Dir(path)))
+	test.CheckError(t, ioutil.WriteFile(path, make([]byte, size), os.ModePerm))
+}
+
+func newTestWatcher(t *testing.T, folder string) *Watcher {
+	conf := &ripper.AppConf{
+		IgnorePrefix: ".",
+		Scan:         &ripper.ScanConfigGroup{Video: &ripper.ScanConfig{AllowedExtensions: []string{"mkv"}}},
+		Watch:        &ripper.WatchConfig{StableFor: "10s", Interval: "1s"},
+	}
+	w, err := NewWatcher(conf, []string{folder})
+	test.CheckError(t, err)
+	return w
+}
+
+func poll(assert *test.Assertion, w *Watcher, now time.Time) []string {
+	stable, err := w.Poll(now)
+	assert.NotError(err)
+	return stable
+}
+
+func TestPoll(t *testing.T) {
+	t.Run("report files once they are stable", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		movie := filepath.Join(dir, "tt123", "movie.mkv")
+		writeFile(t, movie, 10)
+
+		w := newTestWatcher(t, dir)
+		start := time.Now()
+		assert.IntsEqual(0, len(poll(assert, w, start)))
+		assert.IntsEqual(0, len(poll(assert, w, start.Add(5*time.Second))))
+		assert.StringSlicesEqual([]string{movie}, poll(assert, w, start.Add(11*time.Second)))
+		assert.IntsEqual(0, len(poll(assert, w, start.Add(20*time.Second))))
+	})
+
+	t.Run("restart stable period if file is still growing", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		movie := filepath.Join(dir, "tt123", "movie.mkv")
+		writeFile(t, movie, 10)
+
+		w := newTestWatcher(t, dir)
+		start := time.Now()
+		w.Poll(start)
+		writeFile(t, movie, 20)
+		assert.IntsEqual(0, len(poll(assert, w, start.Add(11*time.Second))))
+		assert.StringSlicesEqual([]string{movie}, poll(assert, w, start.Add(22*time.Second)))
+	})
+
+	t.Run("ignore files and folders with ignore prefix and unknown extensions", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		writeFile(t, filepath.Join(dir, "tt1", ".partial.mkv"), 10)
+		writeFile(t, filepath.Join(dir, ".hidden", "tt2", "movie.mkv"), 10)
+		writeFile(t, filepath.Join(dir, "tt3", "notes.txt"), 10)
+
+		w := newTestWatcher(t, dir)
+		start := time.Now()
+		w.Poll(start)
+		assert.IntsEqual(0, len(poll(assert, w, start.Add(time.Minute))))
+	})
+	t.Run("report disc folders as a whole", func(t *testing.T) {
+		assert := test.AssertOn(t)
+		dir := test.MkTempFolder(t)
+		defer test.RmTempFolder(t, dir)
+		disc := filepath.Join(dir, "tt123", "VIDEO_TS")
+		writeFile(t, filepath.Join(disc, "VTS_01_1.VOB"), 10)
+		image := filepath.Join(dir, "tt456.iso")
+		writeFile(t, image, 10)
+
+		w := newTestWatcher(t, dir)
+		w.discs = true
+		w.extensions = append(w.extensions, files.DISC_IMAGE_EXTENSION)
+		start := time.Now()
+		w.Poll(start)
+		writeFile(t, filepath.Join(disc, "VTS_01_2.VOB"), 10)
+		assert.StringSlicesEqual([]string{image}, poll(assert, w, start.Add(11*time.Second)))
+		assert.StringSlicesEqual([]string{disc}, poll(assert, w, start.Add(22*time.Second)))
+	})
+}
+
+func TestPollMultiPartMovies(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	part1, part2 := filepath.Join(dir, "tt123", "movie-cd1.mkv"), filepath.Join(dir, "tt123", "movie-cd2.mkv")
+	writeFile(t, part1, 10)
+	writeFile(t, part2, 10)
+
+	w := newTestWatcher(t, dir)
+	w.partPattern = regexp.MustCompile("(?i)[ ._-]*(?:cd|part)[ ._-]*([0-9]+)$")
+	start := time.Now()
+	w.Poll(start)
+	writeFile(t, part2, 20)
+	assert.IntsEqual(0, len(poll(assert, w, start.Add(11*time.Second))))
+	assert.StringSlicesEqual([]string{part1}, poll(assert, w, start.Add(22*time.Second)))
+	assert.IntsEqual(0, len(poll(assert, w, start.Add(33*time.Second))))
+}
+
+func TestPollAppliesIgnoreFiles(t *testing.T) {
+	assert := test.AssertOn(t)
+	dir := test.MkTempFolder(t)
+	defer test.RmTempFolder(t, dir)
+	watched := filepath.Join(dir, "in")
+	movie := filepath.Join(watched, "tt1", "movie.mkv")
+	writeFile(t, movie, 10)
+	writeFile(t, filepath.Join(watched, "tt1", "@eaDir", "movie.mkv"), 10)
+	writeFile(t, filepath.Join(watched, "tt2", "movie.sample.mkv"), 10)
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(dir, scan.IGNORE_FILE_NAME), []byte("@eaDir/\n"), os.ModePerm))
+	test.CheckError(t, ioutil.WriteFile(filepath.Join(watched, "tt2", scan.IGNORE_FILE_NAME), []byte("*.sample.mkv\n"), os.ModePerm))
+
+	w := newTestWatcher(t, watched)
+	start := time.Now()
+	w.Poll(start)
+	assert.StringSlicesEqual([]string{movie}, poll(assert, w, start.Add(time.Minute)))
+}